@@ -4,16 +4,80 @@ import (
 	"io"
 )
 
+// A DecodeOption configures the behavior of Decode.
+type DecodeOption func(*lexer)
+
+// Filename sets the file name reported in any ParseError returned by Decode.
+// If it isn't given and the io.Reader passed to Decode has a Name() method,
+// as an *os.File does, the result of that method is used instead.
+func Filename(name string) DecodeOption {
+	return func(l *lexer) {
+		l.pos.Filename = name
+	}
+}
+
+// Positions causes Decode to wrap each Statement it returns, including those
+// nested within other Statements, in a *SourceStatement recording where in
+// the text the Statement was found.
+func Positions() DecodeOption {
+	return func(l *lexer) {
+		l.positions = true
+	}
+}
+
+// Comments causes Decode to preserve the comments found in the text, so that
+// they're written out again by Encode. It implies the Positions option; each
+// comment is attached to the *SourceStatement it precedes or, if it's on the
+// same line, follows. Comments with no following statement in their block,
+// such as those immediately before a closing brace, are returned as
+// CommentStatements. Those on the same line as an opening brace, or around
+// the "elsif" and "else" of a conditional, are attached to the
+// *SourceStatement or ConditionalStatement whose block they're beside. Blank
+// lines between comments are kept too. Comments found elsewhere within a
+// statement are discarded.
+func Comments() DecodeOption {
+	return func(l *lexer) {
+		l.positions = true
+		l.comments = true
+		l.leadingComments = make(map[int][]string)
+		l.trailingComments = make(map[int]string)
+		l.openingComments = make(map[int]string)
+		l.branchComments = make(map[int]string)
+	}
+}
+
+// InlineIncludes causes DecodeFS and DecodeFile to replace each
+// IncludeStatement with the Statements of the file it includes, rather than
+// attaching them to the IncludeStatement. It has no effect on Decode.
+func InlineIncludes() DecodeOption {
+	return func(l *lexer) {
+		l.inlineIncludes = true
+	}
+}
+
 // Decode analyzes a slice of bytes, constructing primitive ISC-DHCP config
 // objects.
-func Decode(dataStream io.Reader) ([]Statement, error) {
+//
+// If the bytes can't be parsed, the error returned is a *ParseError.
+func Decode(dataStream io.Reader, opts ...DecodeOption) ([]Statement, error) {
 	l := newLexer(dataStream)
+	if named, ok := dataStream.(interface{ Name() string }); ok {
+		l.pos.Filename = named.Name()
+	}
+	for _, opt := range opts {
+		opt(l)
+	}
+	return l.decode()
+}
 
+func (l *lexer) decode() ([]Statement, error) {
 	parser := yyNewParser()
+	l.parser = parser
 	exitCode := parser.Parse(l)
 	if exitCode != 0 || l.hasErrored {
 		return nil, l.err
 	}
 
+	l.attachTrailingComments()
 	return l.dirtyHackReturn, nil
 }
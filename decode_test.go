@@ -0,0 +1,261 @@
+package iscdhcp
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestDecode_parseError(t *testing.T) {
+	testCases := []struct {
+		data     string
+		expected ParseError
+	}{
+		{
+			data: "host foo {\n  hardware ethernet 0:1:2:3:4:5\n}\n",
+			expected: ParseError{
+				Position: Position{Filename: "dhcpd.conf", Offset: 43, Line: 3, Column: 1},
+				Token:    "}",
+				Expected: []string{`";"`},
+				Msg:      "syntax error",
+			},
+		},
+		{
+			data: "subnet 1.2.3.0 netmask 255.255.255.0 {\n\trange 1.2.3.4 1.2.3.5 1.2.3.6;\n}\n",
+			expected: ParseError{
+				Position: Position{Filename: "dhcpd.conf", Offset: 62, Line: 2, Column: 24},
+				Token:    "1.2.3.6",
+				Expected: []string{`";"`},
+				Msg:      "syntax error",
+			},
+		},
+		{
+			data: "authoritative",
+			expected: ParseError{
+				Position: Position{Filename: "dhcpd.conf", Offset: 13, Line: 1, Column: 14},
+				Expected: []string{`";"`},
+				Msg:      "syntax error",
+			},
+		},
+		{
+			data: "use-host-decl-names maybe;",
+			expected: ParseError{
+				Position: Position{Filename: "dhcpd.conf", Offset: 20, Line: 1, Column: 21},
+				Token:    "maybe",
+				Expected: []string{`"false"`, `"off"`, `"on"`, `"true"`},
+				Msg:      "syntax error",
+			},
+		},
+		{
+			data: "\n\nddns-update-style bogus;",
+			expected: ParseError{
+				Position: Position{Filename: "dhcpd.conf", Offset: 25, Line: 3, Column: 24},
+				Token:    ";",
+				Msg:      `unknown ddns-update-style "bogus"`,
+			},
+		},
+		// the grammar actions of option statements are replayed in working
+		// out the expected tokens
+		{
+			data: "option routers 10.0.0.1;\nhost x { hardware ethernet 0:1:2:3:4:5 }",
+			expected: ParseError{
+				Position: Position{Filename: "dhcpd.conf", Offset: 64, Line: 2, Column: 40},
+				Token:    "}",
+				Expected: []string{`";"`},
+				Msg:      "syntax error",
+			},
+		},
+		{
+			data: "option space foo;\noption foo.bar code 1 = text;\nauthoritative",
+			expected: ParseError{
+				Position: Position{Filename: "dhcpd.conf", Offset: 61, Line: 3, Column: 14},
+				Expected: []string{`";"`},
+				Msg:      "syntax error",
+			},
+		},
+	}
+
+	for _, tc := range testCases {
+		_, err := Decode(strings.NewReader(tc.data), Filename("dhcpd.conf"))
+		pe, ok := err.(*ParseError)
+		if !ok {
+			t.Errorf("%q: expected *ParseError, got %#v", tc.data, err)
+			continue
+		}
+		if !reflect.DeepEqual(*pe, tc.expected) {
+			t.Errorf("%q: expected %#v, got %#v", tc.data, tc.expected, *pe)
+		}
+	}
+}
+
+func TestParseError_Error(t *testing.T) {
+	pe := &ParseError{
+		Position: Position{Filename: "dhcpd.conf", Offset: 43, Line: 3, Column: 1},
+		Token:    "}",
+		Expected: []string{`";"`, `","`},
+		Msg:      "syntax error",
+	}
+	expected := `dhcpd.conf:3:1: syntax error at token "}", expected ";" or ","`
+	if pe.Error() != expected {
+		t.Errorf("expected %q, got %q", expected, pe.Error())
+	}
+}
+
+func TestDecode_comments(t *testing.T) {
+	data := `# dhcpd.conf
+# for the office network
+authoritative; # we're the only server
+
+subnet 10.0.0.0 netmask 255.255.255.0 {
+    # the printer
+    host printer {
+        hardware ethernet 0:1:2:3:4:5; # label on the back
+        # fixed-address 10.0.0.9;
+    }
+    if known {
+        # nothing yet
+    } # end if
+    else {
+        deny booting;
+    }
+}
+# end of file
+`
+	expected := `# dhcpd.conf
+# for the office network
+authoritative; # we're the only server
+subnet 10.0.0.0 netmask 255.255.255.0 {
+    # the printer
+    host printer {
+        hardware ethernet 0:1:2:3:4:5; # label on the back
+        # fixed-address 10.0.0.9;
+    }
+    if known {
+        # nothing yet
+    } # end if
+    else {
+        deny booting;
+    }
+}
+# end of file
+`
+	statements, err := Decode(strings.NewReader(data), Comments())
+	if err != nil {
+		t.Fatalf("Decode(): %s", err)
+	}
+	var sb strings.Builder
+	if err := Encode(&sb, statements); err != nil {
+		t.Fatalf("Encode(): %s", err)
+	}
+	if sb.String() != expected {
+		t.Errorf("expected:\n%s\ngot:\n%s", expected, sb.String())
+	}
+
+	// The comments should survive a second trip unchanged.
+	statements, err = Decode(strings.NewReader(sb.String()), Comments())
+	if err != nil {
+		t.Fatalf("Decode(): %s", err)
+	}
+	sb.Reset()
+	if err := Encode(&sb, statements); err != nil {
+		t.Fatalf("Encode(): %s", err)
+	}
+	if sb.String() != expected {
+		t.Errorf("expected:\n%s\ngot:\n%s", expected, sb.String())
+	}
+
+	// Without the option, comments are discarded.
+	statements, err = Decode(strings.NewReader(data))
+	if err != nil {
+		t.Fatalf("Decode(): %s", err)
+	}
+	for _, stmt := range statements {
+		if _, ok := stmt.(CommentStatement); ok {
+			t.Errorf("unexpected %#v", stmt)
+		}
+	}
+}
+
+func TestDecode_commentPlacement(t *testing.T) {
+	testCases := map[string]string{
+		"trailing if-block": `if known {
+} # c
+else {
+}
+`,
+		"trailing last block": `if known {
+}
+else {
+} # c
+`,
+		"before else": `if known {
+}
+# c
+else {
+}
+`,
+		"before elsif": `if known {
+} # b
+# c
+elsif known {
+} # d
+# e
+else {
+}
+`,
+		"opening brace": `host printer { # c
+    fixed-address 10.0.0.9;
+}
+if known { # d
+}
+else { # e
+    deny booting;
+}
+`,
+		"blank lines": `# a
+
+# b
+authoritative; # c
+
+# d
+host printer {
+    # e
+
+    # f
+}
+if known {
+}
+# g
+
+# h
+else {
+}
+`,
+	}
+	for name, data := range testCases {
+		statements, err := Decode(strings.NewReader(data), Comments())
+		if err != nil {
+			t.Errorf("%s: Decode(): %s", name, err)
+			continue
+		}
+		var sb strings.Builder
+		if err := Encode(&sb, statements); err != nil {
+			t.Errorf("%s: Encode(): %s", name, err)
+			continue
+		}
+		if sb.String() != data {
+			t.Errorf("%s: expected:\n%s\ngot:\n%s", name, data, sb.String())
+		}
+	}
+}
+
+func TestDecode_commentsOnly(t *testing.T) {
+	statements, err := Decode(strings.NewReader("# nothing here\n"), Comments())
+	if err != nil {
+		t.Fatalf("Decode(): %s", err)
+	}
+	expected := []Statement{CommentStatement("# nothing here")}
+	if !reflect.DeepEqual(expected, statements) {
+		t.Errorf("expected %#v, got %#v", expected, statements)
+	}
+}
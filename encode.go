@@ -0,0 +1,136 @@
+package iscdhcp
+
+import (
+	"bufio"
+	"io"
+	"strings"
+)
+
+// An EncodeOption configures the output produced by Encode.
+type EncodeOption func(*encoder)
+
+// IndentSpaces causes Encode to indent nested statements by n spaces per
+// level. The default is four spaces.
+func IndentSpaces(n int) EncodeOption {
+	return func(e *encoder) {
+		e.indent = strings.Repeat(" ", n)
+	}
+}
+
+// IndentTabs causes Encode to indent nested statements by one tab per level.
+func IndentTabs() EncodeOption {
+	return func(e *encoder) {
+		e.indent = "\t"
+	}
+}
+
+// Encode writes the config-file representation of a list of Statements to
+// dataStream, in the form expected by dhcpd. It is the counterpart to Decode.
+//
+// Output is written as it is generated, rather than being built up in memory
+// first. The first error encountered while writing is returned.
+func Encode(dataStream io.Writer, statements []Statement, opts ...EncodeOption) error {
+	bw := bufio.NewWriter(dataStream)
+	e := &encoder{
+		w:      bw,
+		indent: defaultIndent,
+	}
+	for _, opt := range opts {
+		opt(e)
+	}
+
+	e.writeStatements("", statements)
+	if e.err != nil {
+		return e.err
+	}
+	return bw.Flush()
+}
+
+// blockEncoder is implemented by Statements which enclose a block of other
+// Statements, so that the enclosed Statements can be written using the
+// encoder's configured indent rather than the default.
+type blockEncoder interface {
+	encode(e *encoder, prefix string)
+}
+
+// indentedString implements Statement.IndentedString for a blockEncoder.
+func indentedString(be blockEncoder, prefix string) string {
+	var sb strings.Builder
+	e := &encoder{
+		w:      &sb,
+		indent: defaultIndent,
+	}
+	be.encode(e, prefix)
+	return sb.String()
+}
+
+type encoder struct {
+	w      io.Writer
+	indent string
+	err    error
+}
+
+func (e *encoder) writeString(s string) {
+	if e.err != nil {
+		return
+	}
+	_, e.err = io.WriteString(e.w, s)
+}
+
+func (e *encoder) writeStatements(prefix string, statements []Statement) {
+	for _, statement := range statements {
+		e.writeStatement(prefix, statement)
+	}
+}
+
+func (e *encoder) writeStatement(prefix string, statement Statement) {
+	if be, ok := statement.(blockEncoder); ok {
+		be.encode(e, prefix)
+		return
+	}
+	e.writeString(statement.IndentedString(prefix))
+}
+
+func (e *encoder) writeBlock(prefix, header string, statements []Statement) {
+	e.writeString(prefix + header + " {\n")
+	e.writeStatements(prefix+e.indent, statements)
+	e.writeString(prefix + "}\n")
+}
+
+// writeComments writes comments each on its own line, with an empty string
+// written as a blank line.
+func (e *encoder) writeComments(prefix string, comments []string) {
+	for _, comment := range comments {
+		if comment == "" {
+			e.writeString("\n")
+			continue
+		}
+		e.writeString(prefix + comment + "\n")
+	}
+}
+
+// writeCommented writes whatever write does, with opening appended to its
+// first line and trailing to its last. Either may be empty.
+func (e *encoder) writeCommented(opening, trailing string, write func(e *encoder)) {
+	if opening == "" && trailing == "" {
+		write(e)
+		return
+	}
+
+	// The comments belong on lines which have yet to be rendered, so the
+	// text has to be rendered before they can be placed.
+	var sb strings.Builder
+	write(&encoder{
+		w:      &sb,
+		indent: e.indent,
+	})
+	text := sb.String()
+	if opening != "" {
+		i := strings.Index(text, "\n")
+		text = text[:i] + " " + opening + text[i:]
+	}
+	if trailing != "" {
+		text = strings.TrimSuffix(text, "\n") + " " + trailing + "\n"
+	}
+	e.writeString(text)
+}
@@ -0,0 +1,162 @@
+package iscdhcp
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"net"
+	"reflect"
+	"testing"
+)
+
+func TestEncode_indent(t *testing.T) {
+	statements := []Statement{
+		AuthoritativeStatement(true),
+		GroupStatement{
+			Statements: []Statement{
+				HostStatement{
+					Hostname: "serverA.myDomain.tld",
+					Statements: []Statement{
+						HardwareStatement{"ethernet", "0:1:2:3:4:5"},
+					},
+				},
+				ConditionalStatement{
+					Operator:  ConditionIf,
+					Condition: BooleanExpression{Operator: BoolKnown},
+					SubConditionals: []ConditionalStatement{
+						{
+							Operator:   ConditionElse,
+							Statements: []Statement{AuthoritativeStatement(false)},
+						},
+					},
+				},
+			},
+		},
+	}
+
+	testCases := []struct {
+		opts     []EncodeOption
+		expected string
+	}{
+		{
+			opts: nil,
+			expected: "authoritative;\n" +
+				"group {\n" +
+				"    host serverA.myDomain.tld {\n" +
+				"        hardware ethernet 0:1:2:3:4:5;\n" +
+				"    }\n" +
+				"    if known {\n" +
+				"    }\n" +
+				"    else {\n" +
+				"        not authoritative;\n" +
+				"    }\n" +
+				"}\n",
+		},
+		{
+			opts: []EncodeOption{IndentTabs()},
+			expected: "authoritative;\n" +
+				"group {\n" +
+				"\thost serverA.myDomain.tld {\n" +
+				"\t\thardware ethernet 0:1:2:3:4:5;\n" +
+				"\t}\n" +
+				"\tif known {\n" +
+				"\t}\n" +
+				"\telse {\n" +
+				"\t\tnot authoritative;\n" +
+				"\t}\n" +
+				"}\n",
+		},
+		{
+			opts: []EncodeOption{IndentSpaces(2)},
+			expected: "authoritative;\n" +
+				"group {\n" +
+				"  host serverA.myDomain.tld {\n" +
+				"    hardware ethernet 0:1:2:3:4:5;\n" +
+				"  }\n" +
+				"  if known {\n" +
+				"  }\n" +
+				"  else {\n" +
+				"    not authoritative;\n" +
+				"  }\n" +
+				"}\n",
+		},
+	}
+
+	for i, tc := range testCases {
+		buf := &bytes.Buffer{}
+		err := Encode(buf, statements, tc.opts...)
+		if err != nil {
+			t.Fatalf("case %d: unexpected error: %s", i, err)
+		}
+		if buf.String() != tc.expected {
+			t.Errorf("case %d: expected %q, got %q", i, tc.expected, buf.String())
+		}
+	}
+}
+
+func TestEncode_roundTrip(t *testing.T) {
+	expected := []Statement{
+		SharedNetworkStatement{
+			Name: "vlans",
+			Statements: []Statement{
+				SubnetStatement{
+					SubnetNumber: net.ParseIP("1.2.3.0"),
+					Netmask:      net.ParseIP("255.255.255.0"),
+					Statements: []Statement{
+						PoolStatement{
+							Statements: []Statement{
+								RangeStatement{Low: net.ParseIP("1.2.3.10"), High: net.ParseIP("1.2.3.20")},
+							},
+						},
+					},
+				},
+			},
+		},
+		ClassStatement{
+			Name: "foo",
+			Statements: []Statement{
+				MatchStatement{Data: HardwareTerm{}},
+			},
+		},
+		SubclassStatement{ClassName: "foo", Data: HexStringTerm("1:0:1:2:3:4:5")},
+	}
+
+	buf := &bytes.Buffer{}
+	err := Encode(buf, expected, IndentTabs())
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+
+	newStatements, err := Decode(buf)
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	if !reflect.DeepEqual(expected, newStatements) {
+		t.Errorf("expected %#v, got %#v", expected, newStatements)
+	}
+}
+
+type failingWriter struct {
+	err error
+}
+
+func (fw failingWriter) Write(p []byte) (int, error) {
+	return 0, fw.err
+}
+
+func TestEncode_writeError(t *testing.T) {
+	// Enough output to overflow the encoder's internal buffer, so the error
+	// is seen both while writing and while flushing.
+	var statements []Statement
+	for i := 0; i < 1000; i++ {
+		statements = append(statements, HostStatement{Hostname: fmt.Sprintf("host%d", i)})
+	}
+
+	for _, stmts := range [][]Statement{statements, statements[:1]} {
+		expectedErr := errors.New("disk full")
+		err := Encode(failingWriter{expectedErr}, stmts)
+		if err != expectedErr {
+			t.Errorf("expected error %v, got %v", expectedErr, err)
+		}
+	}
+}
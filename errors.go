@@ -0,0 +1,141 @@
+package iscdhcp
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// A ParseError is returned by Decode when config-file text cannot be parsed.
+// It describes the problem and where in the text it was found.
+type ParseError struct {
+	// Position is the location of the offending token.
+	Position
+	// Token is the offending token as it appears in the text, or the empty
+	// string if the end of the text was reached unexpectedly.
+	Token string
+	// Expected lists the tokens which would have been accepted in place of
+	// Token, e.g. `";"` or "IP address". It is only populated for syntax
+	// errors.
+	Expected []string
+	// Msg describes the problem, e.g. "syntax error".
+	Msg string
+}
+
+// ErrIncludeCycle is the Err of an IncludeError returned when a file includes
+// itself, directly or indirectly.
+var ErrIncludeCycle = errors.New("include cycle")
+
+// An IncludeError is returned by DecodeFS and DecodeFile when a file named in
+// an include statement can't be loaded. Errors found while parsing an
+// included file are instead returned as a *ParseError naming that file.
+type IncludeError struct {
+	// Filename is the name of the file containing the include statement, and
+	// Include the name of the file it refers to.
+	Filename string
+	Include  string
+	// Err describes the problem, e.g. ErrIncludeCycle or an *fs.PathError.
+	Err error
+}
+
+func (ie *IncludeError) Error() string {
+	return fmt.Sprintf("%s: include %q: %s", ie.Filename, ie.Include, ie.Err)
+}
+
+// Unwrap returns ie.Err.
+func (ie *IncludeError) Unwrap() error {
+	return ie.Err
+}
+
+// maxExpectedInError is the number of Expected tokens beyond which Error()
+// stops listing them, to keep the message readable.
+const maxExpectedInError = 8
+
+func (pe *ParseError) Error() string {
+	tokenDesc := "end of input"
+	if pe.Token != "" {
+		tokenDesc = fmt.Sprintf("token %q", pe.Token)
+	}
+	s := fmt.Sprintf("%s: %s at %s", pe.Position, pe.Msg, tokenDesc)
+	if len(pe.Expected) != 0 && len(pe.Expected) <= maxExpectedInError {
+		s += ", expected " + strings.Join(pe.Expected, " or ")
+	}
+	return s
+}
+
+// tokenDisplayNames holds human-readable names for the tokens which aren't
+// keywords from stringTokenMap.
+var tokenDisplayNames = map[int][]string{
+	0:            {"end of input"},
+	openBrace:    {`"{"`},
+	closeBrace:   {`"}"`},
+	openParen:    {`"("`},
+	closeParen:   {`")"`},
+	semicolon:    {`";"`},
+	comma:        {`","`},
+	number:       {"number"},
+	ipAddr:       {"IP address"},
+	cidr:         {"CIDR prefix"},
+	ip6Addr:      {"IPv6 address"},
+	ip6CIDR:      {"IPv6 prefix"},
+	prefixLength: {"prefix length"},
+	stringConst:  {"quoted string"},
+	macAddr:      {"MAC address"},
+	hexString:    {"hex string"},
+	word:         {"word"},
+}
+
+func init() {
+	for keyword, tok := range stringTokenMap {
+		tokenDisplayNames[tok] = append(tokenDisplayNames[tok], fmt.Sprintf("%q", keyword))
+	}
+	for _, names := range tokenDisplayNames {
+		sort.Strings(names)
+	}
+}
+
+// expectedTokens returns the display names of all tokens which the parser
+// would accept after the token sequence prefix.
+//
+// The generated parser doesn't expose its state, so this works by feeding
+// prefix followed by each candidate token to a fresh parser, and checking
+// whether it rejects the candidate.
+func expectedTokens(prefix []int) []string {
+	var expected []string
+	for tok, names := range tokenDisplayNames {
+		rl := &replayLexer{
+			tokens: append(prefix[:len(prefix):len(prefix)], tok),
+		}
+		yyNewParser().Parse(rl)
+		if rl.errorAt != len(prefix)+1 {
+			expected = append(expected, names...)
+		}
+	}
+	sort.Strings(expected)
+	return expected
+}
+
+// A replayLexer feeds a fixed sequence of token codes to the parser, and
+// records how many it had handed out when a syntax error was reported.
+type replayLexer struct {
+	tokens  []int
+	lexed   int
+	errorAt int
+}
+
+func (rl *replayLexer) Lex(lval *yySymType) int {
+	rl.lexed++
+	if rl.lexed > len(rl.tokens) {
+		return 0
+	}
+	return rl.tokens[rl.lexed-1]
+}
+
+func (rl *replayLexer) Error(s string) {
+	// Grammar actions may complain about the (empty) values of the
+	// replayed tokens; only syntax errors are of interest.
+	if rl.errorAt == 0 && strings.HasPrefix(s, "syntax error") {
+		rl.errorAt = rl.lexed
+	}
+}
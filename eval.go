@@ -0,0 +1,402 @@
+package iscdhcp
+
+import (
+	"bytes"
+	"encoding/binary"
+	"fmt"
+	"net"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// A ClientRequest describes a DHCP client's request, and what dhcpd knows of
+// the client, for use in evaluating BooleanExpressions without a live dhcpd.
+// Any field may be left unset, in which case the terms which refer to it
+// evaluate to null.
+type ClientRequest struct {
+	// HardwareType is the client's ARP hardware type, e.g. 1 for ethernet.
+	// If it's zero, 1 is assumed.
+	HardwareType    int
+	HardwareAddress net.HardwareAddr
+	// Options holds the value of each option in the request, in wire
+	// format but without the leading code and length, keyed by the name of
+	// the option as written in config text, e.g. "agent.circuit-id".
+	Options map[string][]byte
+	// ConfigOptions holds the value of each option configured for the
+	// client, in the same form as Options.
+	ConfigOptions map[string][]byte
+	// Known is set if the client matches a host declaration, and Static if
+	// it's been given a fixed address.
+	Known  bool
+	Static bool
+	// Packet holds the raw packet, for packet() terms.
+	Packet        []byte
+	LeasedAddress net.IP
+	HostDeclName  string
+	// ClientState is one of the client states listed in dhcp-eval(5).
+	ClientState int
+	// LeaseTime is the remaining duration of the client's lease in seconds.
+	LeaseTime int
+}
+
+// SetOption sets the value of one of the standard options in cr.Options,
+// converting it to wire format. Other options can be set in cr.Options
+// directly, using OptionDefinition.EncodeWire.
+func (cr *ClientRequest) SetOption(os OptionStatement) error {
+	def, found := LookupOption(os.Name)
+	if !found {
+		return fmt.Errorf("unknown option %q", os.Name)
+	}
+	wire, err := def.EncodeWire(os.Values)
+	if err != nil {
+		return err
+	}
+	if cr.Options == nil {
+		cr.Options = make(map[string][]byte)
+	}
+	cr.Options[os.Name] = wire[2:]
+	return nil
+}
+
+// Evaluate computes the value of the expression for the given request, as
+// described in dhcp-eval(5). Comparisons involving a null value are false,
+// unless both values are null, in which case "=" is true. Regular
+// expressions use the syntax of the regexp package, which for the most part
+// matches the POSIX extended syntax used by dhcpd.
+//
+// An error is returned if the expression can't be evaluated, e.g. because
+// a term is of the wrong kind or a regular expression is invalid.
+func (be BooleanExpression) Evaluate(cr ClientRequest) (bool, error) {
+	needTerms := func(boolTerms, dataTerms int) error {
+		if len(be.BoolTerms) != boolTerms || len(be.DataTerms) != dataTerms {
+			return fmt.Errorf("%q takes %d boolean and %d data terms, found %d and %d",
+				boolOpStrings[be.Operator], boolTerms, dataTerms, len(be.BoolTerms), len(be.DataTerms))
+		}
+		return nil
+	}
+
+	switch be.Operator {
+	case BoolAnd, BoolOr:
+		if err := needTerms(2, 0); err != nil {
+			return false, err
+		}
+		left, err := be.BoolTerms[0].Evaluate(cr)
+		if err != nil || left == (be.Operator == BoolOr) {
+			return left, err
+		}
+		return be.BoolTerms[1].Evaluate(cr)
+	case BoolNot:
+		if err := needTerms(1, 0); err != nil {
+			return false, err
+		}
+		result, err := be.BoolTerms[0].Evaluate(cr)
+		return !result, err
+	case BoolStatic:
+		return cr.Static, nil
+	case BoolKnown:
+		return cr.Known, nil
+	case BoolExists:
+		if err := needTerms(0, 1); err != nil {
+			return false, err
+		}
+		pot, ok := be.DataTerms[0].(PacketOptionTerm)
+		if !ok {
+			return false, fmt.Errorf("exists needs an option, not %q", be.DataTerms[0])
+		}
+		_, found := cr.Options[pot.optionName]
+		return found, nil
+	case BoolEqual, BoolInequal:
+		if err := needTerms(0, 2); err != nil {
+			return false, err
+		}
+		equal, null, err := termsEqual(be.DataTerms[0], be.DataTerms[1], cr)
+		if err != nil || null && !equal {
+			return false, err
+		}
+		return equal == (be.Operator == BoolEqual), nil
+	case BoolRegexMatch, BoolRegexIMatch:
+		if err := needTerms(0, 2); err != nil {
+			return false, err
+		}
+		return regexMatch(be.DataTerms[0], be.DataTerms[1], be.Operator == BoolRegexIMatch, cr)
+	}
+	return false, fmt.Errorf("unknown boolean operator %d", be.Operator)
+}
+
+// termsEqual compares two data-terms, or two numeric terms, also reporting
+// whether either of them is null.
+func termsEqual(left, right fmt.Stringer, cr ClientRequest) (equal, null bool, err error) {
+	if isNumericTerm(left) && isNumericTerm(right) {
+		l, lok, err := EvaluateNumber(left, cr)
+		if err != nil {
+			return false, false, err
+		}
+		r, rok, err := EvaluateNumber(right, cr)
+		if err != nil {
+			return false, false, err
+		}
+		return lok == rok && l == r, !lok || !rok, nil
+	}
+	l, lok, err := EvaluateData(left, cr)
+	if err != nil {
+		return false, false, err
+	}
+	r, rok, err := EvaluateData(right, cr)
+	if err != nil {
+		return false, false, err
+	}
+	return lok == rok && bytes.Equal(l, r), !lok || !rok, nil
+}
+
+func regexMatch(data, pattern fmt.Stringer, ignoreCase bool, cr ClientRequest) (bool, error) {
+	expr, ok := pattern.(StringConstTerm)
+	if !ok {
+		return false, fmt.Errorf("a regular expression must be a string, not %q", pattern)
+	}
+	if ignoreCase {
+		expr = "(?i)" + expr
+	}
+	re, err := regexp.Compile(string(expr))
+	if err != nil {
+		return false, fmt.Errorf("invalid regular expression %s: %s", pattern, err)
+	}
+	value, found, err := EvaluateData(data, cr)
+	if err != nil || !found {
+		return false, err
+	}
+	return re.Match(value), nil
+}
+
+func isNumericTerm(term fmt.Stringer) bool {
+	switch term.(type) {
+	case NumberTerm, ExtractIntTerm, ClientStateTerm, LeaseTimeTerm:
+		return true
+	}
+	return false
+}
+
+// EvaluateData computes the value of a data-term for the given request. If
+// the term evaluates to null, e.g. because it refers to an option the
+// request doesn't include, the boolean result is false.
+func EvaluateData(term fmt.Stringer, cr ClientRequest) ([]byte, bool, error) {
+	switch t := term.(type) {
+	case StringConstTerm:
+		return []byte(t), true, nil
+	case HexStringTerm:
+		octets, err := hexStringOctets(string(t))
+		return octets, err == nil, err
+	case NumberTerm:
+		// dhcpd reads a number where data is expected as hexadecimal
+		// octets, so that e.g. "option dhcp-message-type = 1" works
+		octets, err := hexStringOctets(strconv.Itoa(int(t)))
+		return octets, err == nil, err
+	case PacketOptionTerm:
+		value, found := cr.Options[t.optionName]
+		return value, found, nil
+	case ConfigOptionTerm:
+		value, found := cr.ConfigOptions[t.OptionName]
+		return value, found, nil
+	case SubstringTerm:
+		data, offset, length, found, err := evaluateDataAndNumbers(cr, t.Data, t.Offset, t.Length)
+		if !found || err != nil {
+			return nil, false, err
+		}
+		return substring(data, offset, length), true, nil
+	case SuffixTerm:
+		data, length, _, found, err := evaluateDataAndNumbers(cr, t.Data, t.Length, NumberTerm(0))
+		if !found || err != nil {
+			return nil, false, err
+		}
+		if length > len(data) {
+			length = len(data)
+		}
+		return data[len(data)-length:], true, nil
+	case ConcatTerm:
+		var result []byte
+		for _, subTerm := range t {
+			data, found, err := EvaluateData(subTerm, cr)
+			if !found || err != nil {
+				return nil, false, err
+			}
+			result = append(result, data...)
+		}
+		return result, true, nil
+	case HardwareTerm:
+		if cr.HardwareAddress == nil {
+			return nil, false, nil
+		}
+		hwType := cr.HardwareType
+		if hwType == 0 {
+			hwType = 1
+		}
+		return append([]byte{byte(hwType)}, cr.HardwareAddress...), true, nil
+	case PacketTerm:
+		offset, offsetFound, err := EvaluateNumber(t.Offset, cr)
+		if err != nil {
+			return nil, false, err
+		}
+		length, lengthFound, err := EvaluateNumber(t.Length, cr)
+		if !offsetFound || !lengthFound || err != nil || cr.Packet == nil {
+			return nil, false, err
+		}
+		if offset < 0 || length < 0 {
+			return nil, false, fmt.Errorf("negative number in %q", t)
+		}
+		return substring(cr.Packet, offset, length), true, nil
+	case LeasedAddressTerm:
+		if cr.LeasedAddress.To4() == nil {
+			return nil, false, nil
+		}
+		return []byte(cr.LeasedAddress.To4()), true, nil
+	case HostDeclNameTerm:
+		return []byte(cr.HostDeclName), cr.HostDeclName != "", nil
+	case BinaryToASCIITerm:
+		return evaluateBinaryToASCII(t, cr)
+	case EncodeIntTerm:
+		value, found, err := EvaluateNumber(t.Value, cr)
+		if !found || err != nil {
+			return nil, false, err
+		}
+		if t.Width != 8 && t.Width != 16 && t.Width != 32 {
+			return nil, false, fmt.Errorf("invalid width %d in %q", t.Width, t)
+		}
+		var result []byte
+		for shift := t.Width - 8; shift >= 0; shift -= 8 {
+			result = append(result, byte(value>>uint(shift)))
+		}
+		return result, true, nil
+	case PickFirstValueTerm:
+		for _, subTerm := range t {
+			data, found, err := EvaluateData(subTerm, cr)
+			if found || err != nil {
+				return data, found, err
+			}
+		}
+		return nil, false, nil
+	case ReverseTerm:
+		data, width, _, found, err := evaluateDataAndNumbers(cr, t.Data, t.Width, NumberTerm(0))
+		if !found || err != nil {
+			return nil, false, err
+		}
+		if width <= 0 || len(data)%width != 0 {
+			return nil, false, fmt.Errorf("can't reverse %d bytes in chunks of %d in %q", len(data), width, t)
+		}
+		result := make([]byte, 0, len(data))
+		for i := len(data) - width; i >= 0; i -= width {
+			result = append(result, data[i:i+width]...)
+		}
+		return result, true, nil
+	case LcaseTerm:
+		data, found, err := EvaluateData(t.Data, cr)
+		return bytes.ToLower(data), found, err
+	case UcaseTerm:
+		data, found, err := EvaluateData(t.Data, cr)
+		return bytes.ToUpper(data), found, err
+	}
+	if isNumericTerm(term) {
+		return nil, false, fmt.Errorf("%q is numeric, not data", term)
+	}
+	return nil, false, fmt.Errorf("unknown data-term %q", term)
+}
+
+// substring returns up to length bytes of data, starting at offset.
+func substring(data []byte, offset, length int) []byte {
+	if offset > len(data) {
+		offset = len(data)
+	}
+	if length > len(data)-offset {
+		length = len(data) - offset
+	}
+	return data[offset : offset+length]
+}
+
+// evaluateDataAndNumbers evaluates a data-term and two numeric terms, as
+// taken by several functions; the boolean result is false if any of them is
+// null.
+func evaluateDataAndNumbers(cr ClientRequest, data, num1, num2 fmt.Stringer) ([]byte, int, int, bool, error) {
+	d, dataFound, err := EvaluateData(data, cr)
+	if err != nil {
+		return nil, 0, 0, false, err
+	}
+	n1, found1, err := EvaluateNumber(num1, cr)
+	if err != nil {
+		return nil, 0, 0, false, err
+	}
+	n2, found2, err := EvaluateNumber(num2, cr)
+	if err != nil {
+		return nil, 0, 0, false, err
+	}
+	if n1 < 0 || n2 < 0 {
+		return nil, 0, 0, false, fmt.Errorf("negative number with %q", data)
+	}
+	return d, n1, n2, dataFound && found1 && found2, nil
+}
+
+func evaluateBinaryToASCII(t BinaryToASCIITerm, cr ClientRequest) ([]byte, bool, error) {
+	base, baseFound, err := EvaluateNumber(t.Base, cr)
+	if err != nil {
+		return nil, false, err
+	}
+	width, widthFound, err := EvaluateNumber(t.Width, cr)
+	if err != nil {
+		return nil, false, err
+	}
+	separator, separatorFound, err := EvaluateData(t.Separator, cr)
+	if err != nil {
+		return nil, false, err
+	}
+	data, dataFound, err := EvaluateData(t.Data, cr)
+	if !baseFound || !widthFound || !separatorFound || !dataFound || err != nil {
+		return nil, false, err
+	}
+	if base < 2 || base > 16 {
+		return nil, false, fmt.Errorf("invalid base %d in %q", base, t)
+	}
+	if width != 8 && width != 16 && width != 32 {
+		return nil, false, fmt.Errorf("invalid width %d in %q", width, t)
+	}
+
+	size := width / 8
+	chunks := make([]string, 0, len(data)/size)
+	for i := 0; i+size <= len(data); i += size {
+		var n uint64
+		for _, b := range data[i : i+size] {
+			n = n<<8 | uint64(b)
+		}
+		chunks = append(chunks, strconv.FormatUint(n, base))
+	}
+	return []byte(strings.Join(chunks, string(separator))), true, nil
+}
+
+// EvaluateNumber computes the value of a numeric term for the given request.
+// If the term evaluates to null, e.g. because it extracts an integer from an
+// option the request doesn't include, the boolean result is false.
+func EvaluateNumber(term fmt.Stringer, cr ClientRequest) (int, bool, error) {
+	switch t := term.(type) {
+	case NumberTerm:
+		return int(t), true, nil
+	case ClientStateTerm:
+		return cr.ClientState, true, nil
+	case LeaseTimeTerm:
+		return cr.LeaseTime, true, nil
+	case ExtractIntTerm:
+		data, found, err := EvaluateData(t.Data, cr)
+		if !found || err != nil {
+			return 0, false, err
+		}
+		switch {
+		case t.Width == 8 && len(data) >= 1:
+			return int(data[0]), true, nil
+		case t.Width == 16 && len(data) >= 2:
+			return int(binary.BigEndian.Uint16(data)), true, nil
+		case t.Width == 32 && len(data) >= 4:
+			return int(binary.BigEndian.Uint32(data)), true, nil
+		case t.Width != 8 && t.Width != 16 && t.Width != 32:
+			return 0, false, fmt.Errorf("invalid width %d in %q", t.Width, t)
+		}
+		// too short to hold the integer
+		return 0, false, nil
+	}
+	return 0, false, fmt.Errorf("%q isn't a numeric term", term)
+}
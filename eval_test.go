@@ -0,0 +1,107 @@
+package iscdhcp
+
+import (
+	"net"
+	"strings"
+	"testing"
+)
+
+// parseCondition decodes the condition of an "if" statement.
+func parseCondition(t *testing.T, condition string) BooleanExpression {
+	t.Helper()
+	statements, err := Decode(strings.NewReader("if " + condition + " {\n}\n"))
+	if err != nil {
+		t.Fatalf("%q: unexpected error: %s", condition, err)
+	}
+	return statements[0].(ConditionalStatement).Condition
+}
+
+func TestBooleanExpression_Evaluate(t *testing.T) {
+	mac, _ := net.ParseMAC("00:1a:2b:3c:4d:5e")
+	cr := ClientRequest{
+		HardwareAddress: mac,
+		Known:           true,
+		Options: map[string][]byte{
+			"vendor-class-identifier": []byte("PXEClient:Arch:00007:UNDI:003016"),
+			"user-class":              []byte("iPXE"),
+			"agent.circuit-id":        {0, 4, 0, 10, 0, 3},
+		},
+		HostDeclName: "printer",
+		LeaseTime:    3600,
+	}
+	err := cr.SetOption(OptionStatement{Name: "dhcp-message-type", Values: [][]interface{}{{1}}})
+	if err != nil {
+		t.Fatalf("SetOption(): %s", err)
+	}
+
+	testCases := []struct {
+		condition string
+		expected  bool
+	}{
+		{`known`, true},
+		{`static`, false},
+		{`not static and known`, true},
+		{`static or not known`, false},
+		{`exists option user-class`, true},
+		{`exists option domain-name`, false},
+		{`option user-class = "iPXE"`, true},
+		{`option user-class != "iPXE"`, false},
+		{`option user-class = 69:50:58:45`, true},
+		{`option dhcp-message-type = 1`, true},
+		{`extract-int(option dhcp-message-type, 8) = 1`, true},
+		{`substring(option vendor-class-identifier, 0, 9) = "PXEClient"`, true},
+		{`substring(option vendor-class-identifier, 40, 9) = ""`, true},
+		{`suffix(option vendor-class-identifier, 6) = "003016"`, true},
+		{`substring(hardware, 1, 3) = 00:1a:2b`, true},
+		{`hardware = 1:0:1a:2b:3c:4d:5e`, true},
+		{`binary-to-ascii(16, 8, ":", substring(hardware, 1, 6)) = "0:1a:2b:3c:4d:5e"`, true},
+		{`concat("PXE", option user-class) = "PXEiPXE"`, true},
+		{`concat("PXE", option domain-name) = "PXE"`, false},
+		{`option domain-name = option host-name`, true},
+		{`option domain-name = ""`, false},
+		{`option domain-name != ""`, false},
+		{`option domain-name != option host-name`, false},
+		{`lease-time != extract-int(option domain-name, 8)`, false},
+		{`pick-first-value(option host-name, host-decl-name) = "printer"`, true},
+		{`lcase(option user-class) = "ipxe"`, true},
+		{`ucase(option user-class) = "IPXE"`, true},
+		{`reverse(2, option agent.circuit-id) = 0:3:0:a:0:4`, true},
+		{`encode-int(lease-time, 32) = 0:0:e:10`, true},
+		{`lease-time = 3600`, true},
+		{`option vendor-class-identifier ~= "^PXEClient:Arch:0000[79]"`, true},
+		{`option vendor-class-identifier ~= "^pxeclient"`, false},
+		{`option vendor-class-identifier ~~ "^pxeclient"`, true},
+		{`option domain-name ~~ ".*"`, false},
+	}
+	for _, tc := range testCases {
+		be := parseCondition(t, tc.condition)
+		result, err := be.Evaluate(cr)
+		if err != nil {
+			t.Errorf("%q: unexpected error: %s", tc.condition, err)
+			continue
+		}
+		if result != tc.expected {
+			t.Errorf("%q: expected %t, got %t", tc.condition, tc.expected, result)
+		}
+	}
+}
+
+func TestBooleanExpression_EvaluateErrors(t *testing.T) {
+	testCases := []struct {
+		condition string
+		expected  string
+	}{
+		{`option user-class ~= "("`, `invalid regular expression "(": error parsing regexp: missing closing ): ` + "`(`"},
+		{`option user-class ~= option host-name`, `a regular expression must be a string, not "option host-name"`},
+		{`substring(hardware, "a", 1) = "b"`, `"\"a\"" isn't a numeric term`},
+		{`extract-int(hardware, 12) = 1`, `invalid width 12 in "extract-int(hardware, 12)"`},
+		{`lease-time = "b"`, `"lease-time" is numeric, not data`},
+	}
+	for _, tc := range testCases {
+		be := parseCondition(t, tc.condition)
+		_, err := be.Evaluate(ClientRequest{HardwareAddress: net.HardwareAddr{1, 2, 3, 4, 5, 6}})
+		if err == nil || err.Error() != tc.expected {
+			t.Errorf("%q: expected error %q, got %v", tc.condition, tc.expected, err)
+		}
+	}
+}
@@ -0,0 +1,191 @@
+package iscdhcp
+
+import (
+	"net"
+	"strings"
+)
+
+// CheckHosts looks for host declarations which clash with one another,
+// wherever they're declared in a config, and returns a Diagnostic for each
+// clash. It's one of the checks made by Validate. The Diagnostic codes it
+// reports are:
+//
+//	host-duplicate-hardware       two hosts have the same hardware address, on
+//	                              the same network segment
+//	host-duplicate-fixed-address  two hosts reserve the same fixed address
+//	host-duplicate-name           two hosts have the same name (warning)
+//
+// Hardware addresses are compared by value, so "0:1:2:3:4:5" and
+// "00:01:02:03:04:05" are duplicates; invalid ones are left to Validate. A
+// machine attached to several networks may have a host declaration for each,
+// so a repeated hardware address is only reported if either host has no fixed
+// address, or both have one on the same network segment: within the same
+// shared network or, outside of one, the same subnet. Fixed addresses outside
+// every declared subnet are treated as being on the same segment. Each clash
+// is reported at the later of the two declarations.
+func CheckHosts(statements []Statement) []Diagnostic {
+	hc := &hostChecker{
+		hardware: make(map[string][]hostRef),
+		fixed:    make(map[string]hostRef),
+		names:    make(map[string]hostRef),
+	}
+	hc.gatherSegments(statements, nil)
+	hc.check(statements)
+	return hc.diagnostics
+}
+
+// A hostRef identifies the host a hardware address, fixed address or name
+// was first seen in.
+type hostRef struct {
+	statement Statement
+	hostname  string
+	// index counts the hosts seen before this one.
+	index int
+	// segments holds the network segments of the host's fixed addresses.
+	segments map[string]bool
+}
+
+// sharesSegment reports whether hr and other, having the same hardware
+// address, could be confused by dhcpd.
+func (hr hostRef) sharesSegment(other hostRef) bool {
+	if len(hr.segments) == 0 || len(other.segments) == 0 {
+		return true
+	}
+	for segment := range hr.segments {
+		if other.segments[segment] {
+			return true
+		}
+	}
+	return false
+}
+
+func (hr hostRef) String() string {
+	return "host " + hr.hostname + declaredAt(hr.statement)
+}
+
+// A hostChecker accumulates the Diagnostics found by CheckHosts.
+type hostChecker struct {
+	diagnostics []Diagnostic
+	// hardware, fixed and names index the hosts seen so far by normalised
+	// hardware address, fixed address and name. Every host with a hardware
+	// address is kept, since it may only clash with some of the others.
+	hardware map[string][]hostRef
+	fixed    map[string]hostRef
+	names    map[string]hostRef
+	count    int
+	// subnets holds the subnets declared anywhere in the config, and
+	// segments the network segment each is on.
+	subnets  []SubnetStatement
+	segments []string
+}
+
+// gatherSegments finds the subnets in statements, which are found within the
+// declarations in enclosing, outermost first.
+func (hc *hostChecker) gatherSegments(statements []Statement, enclosing []Statement) {
+	for _, statement := range flattenIncludes(statements) {
+		inner := Unwrap(statement)
+		if ss, ok := inner.(SubnetStatement); ok {
+			_, segment := enclosingNetwork(enclosing)
+			if segment == "" {
+				segment = "subnet " + ss.SubnetNumber.String()
+			}
+			hc.subnets = append(hc.subnets, ss)
+			hc.segments = append(hc.segments, segment)
+		}
+		if bs, ok := inner.(blockStatement); ok {
+			nested := append(enclosing[:len(enclosing):len(enclosing)], inner)
+			bs.mapBlocks(func(block []Statement) []Statement {
+				hc.gatherSegments(block, nested)
+				return block
+			})
+		}
+	}
+}
+
+// hostSegments returns the network segments of the fixed addresses among a
+// host's statements, with "" standing for addresses outside every subnet.
+func (hc *hostChecker) hostSegments(statements []Statement) map[string]bool {
+	segments := make(map[string]bool)
+	for _, statement := range flattenIncludes(statements) {
+		fas, ok := Unwrap(statement).(FixedAddressStatement)
+		if !ok {
+			continue
+		}
+		for _, ip := range fas {
+			segment := ""
+			for i, ss := range hc.subnets {
+				if subnetContains(ss, ip) {
+					segment = hc.segments[i]
+					break
+				}
+			}
+			segments[segment] = true
+		}
+	}
+	return segments
+}
+
+func (hc *hostChecker) check(statements []Statement) {
+	for _, statement := range flattenIncludes(statements) {
+		inner := Unwrap(statement)
+		if hs, ok := inner.(HostStatement); ok {
+			hc.checkHost(statement, hs)
+			continue
+		}
+		if bs, ok := inner.(blockStatement); ok {
+			bs.mapBlocks(func(block []Statement) []Statement {
+				hc.check(block)
+				return block
+			})
+		}
+	}
+}
+
+func (hc *hostChecker) checkHost(statement Statement, hs HostStatement) {
+	ref := hostRef{
+		statement: statement,
+		hostname:  hs.Hostname,
+		index:     hc.count,
+		segments:  hc.hostSegments(hs.Statements),
+	}
+	hc.count++
+	if earlier, found := hc.names[hs.Hostname]; found {
+		hc.diagnostics = append(hc.diagnostics, newDiagnostic(statement, SeverityWarning, "host-duplicate-name",
+			"host %s has the same name as %s", hs.Hostname, earlier))
+	} else {
+		hc.names[hs.Hostname] = ref
+	}
+
+	for _, child := range flattenIncludes(hs.Statements) {
+		switch st := Unwrap(child).(type) {
+		case HardwareStatement:
+			octets, err := hexStringOctets(st.HardwareAddress)
+			if err != nil {
+				continue
+			}
+			key := strings.ToLower(st.HardwareType) + " " + net.HardwareAddr(octets).String()
+			refs := hc.hardware[key]
+			if len(refs) != 0 && refs[len(refs)-1].index == ref.index {
+				continue
+			}
+			for _, earlier := range refs {
+				if ref.sharesSegment(earlier) {
+					hc.diagnostics = append(hc.diagnostics, newDiagnostic(child, SeverityError, "host-duplicate-hardware",
+						"hardware address %s of host %s is also that of %s", st.HardwareAddress, hs.Hostname, earlier))
+					break
+				}
+			}
+			hc.hardware[key] = append(refs, ref)
+		case FixedAddressStatement:
+			for _, ip := range st {
+				key := ip.String()
+				if earlier, found := hc.fixed[key]; found && earlier.index != ref.index {
+					hc.diagnostics = append(hc.diagnostics, newDiagnostic(child, SeverityError, "host-duplicate-fixed-address",
+						"fixed address %s of host %s is also reserved for %s", ip, hs.Hostname, earlier))
+				} else if !found {
+					hc.fixed[key] = ref
+				}
+			}
+		}
+	}
+}
@@ -0,0 +1,109 @@
+package iscdhcp
+
+import "testing"
+
+func TestCheckHosts(t *testing.T) {
+	testDiagnostics(t, CheckHosts, []diagnosticTestCase{
+		{
+			config: `host alpha {
+    hardware ethernet 0:1:2:3:4:5;
+    hardware ethernet 00:01:02:03:04:05;
+    fixed-address 10.0.0.5, 10.0.0.5;
+}
+host beta {
+    hardware ethernet 0:1:2:3:4:6;
+    fixed-address 10.0.0.6;
+}
+`,
+		},
+		{
+			config: `host alpha {
+    hardware ethernet 0:1:2:3:4:5;
+}
+subnet 10.0.0.0 netmask 255.255.255.0 {
+    group {
+        host beta {
+            hardware ethernet 00:01:02:03:04:05;
+        }
+    }
+}
+`,
+			expected: []string{
+				`7:13: error: hardware address 00:01:02:03:04:05 of host beta is also that of host alpha declared at 1:1 [host-duplicate-hardware]`,
+			},
+		},
+		{
+			config: `subnet 10.0.0.0 netmask 255.255.255.0 {
+}
+subnet 10.1.0.0 netmask 255.255.255.0 {
+}
+host laptop-wired {
+    hardware ethernet 0:1:2:3:4:5;
+    fixed-address 10.0.0.5;
+}
+host laptop-lab {
+    hardware ethernet 0:1:2:3:4:5;
+    fixed-address 10.1.0.5;
+}
+`,
+		},
+		{
+			config: `shared-network "campus" {
+    subnet 10.0.0.0 netmask 255.255.255.0 {
+    }
+    subnet 10.1.0.0 netmask 255.255.255.0 {
+    }
+}
+host laptop-wired {
+    hardware ethernet 0:1:2:3:4:5;
+    fixed-address 10.0.0.5;
+}
+host laptop-lab {
+    hardware ethernet 0:1:2:3:4:5;
+    fixed-address 10.1.0.5;
+}
+`,
+			expected: []string{
+				`12:5: error: hardware address 0:1:2:3:4:5 of host laptop-lab is also that of host laptop-wired declared at 7:1 [host-duplicate-hardware]`,
+			},
+		},
+		{
+			config: `host alpha {
+    fixed-address 10.0.0.5;
+}
+host beta {
+    fixed-address 10.0.0.6, 10.0.0.5;
+}
+`,
+			expected: []string{
+				`5:5: error: fixed address 10.0.0.5 of host beta is also reserved for host alpha declared at 1:1 [host-duplicate-fixed-address]`,
+			},
+		},
+		{
+			config: `host alpha {
+    fixed-address 10.0.0.5;
+}
+host alpha {
+    fixed-address 10.0.0.7;
+}
+`,
+			expected: []string{
+				`4:1: warning: host alpha has the same name as host alpha declared at 1:1 [host-duplicate-name]`,
+			},
+		},
+		{
+			config: `host alpha {
+    fixed-address 10.0.0.5;
+}
+include "included.conf";
+`,
+			included: `host alpha {
+    fixed-address 10.0.0.7;
+}
+`,
+			expected: []string{
+				`included.conf:1:1: warning: host alpha has the same name as host alpha declared at dhcpd.conf:1:1 [host-duplicate-name]`,
+			},
+		},
+	})
+}
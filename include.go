@@ -0,0 +1,182 @@
+package iscdhcp
+
+import (
+	"io/fs"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+)
+
+// DecodeFS reads the named file from fsys and decodes it as Decode does,
+// along with any files it includes, directly or indirectly.
+//
+// Each IncludeStatement found is returned with the Statements of the file it
+// names attached, unless the InlineIncludes option is given. Absolute include
+// paths are looked up from the root of fsys, as are relative ones, since
+// dhcpd resolves those against its working directory rather than against the
+// including file.
+//
+// A file which can't be parsed yields a *ParseError naming that file; one
+// which can't be opened, or which would include itself, an *IncludeError
+// naming the file containing the include statement.
+func DecodeFS(fsys fs.FS, name string, opts ...DecodeOption) ([]Statement, error) {
+	il := &includeLoader{
+		fsys: fsys,
+		dir:  ".",
+		opts: opts,
+	}
+	return il.load(il.resolve(name), name)
+}
+
+// DecodeFile reads the named file from the operating system's filesystem and
+// decodes it, along with any files it includes, as DecodeFS does. Relative
+// include paths are resolved against the current working directory.
+func DecodeFile(name string, opts ...DecodeOption) ([]Statement, error) {
+	absName, err := filepath.Abs(name)
+	if err != nil {
+		return nil, err
+	}
+	cwd, err := os.Getwd()
+	if err != nil {
+		return nil, err
+	}
+	volume := filepath.VolumeName(absName)
+	il := &includeLoader{
+		fsys: os.DirFS(volume + string(filepath.Separator)),
+		dir:  rootRelative(strings.TrimPrefix(cwd, volume)),
+		opts: opts,
+	}
+	return il.load(rootRelative(strings.TrimPrefix(absName, volume)), name)
+}
+
+// rootRelative converts an absolute operating-system path, without any
+// volume name, to a path relative to the root of an fs.FS.
+func rootRelative(name string) string {
+	name = strings.TrimPrefix(filepath.ToSlash(name), "/")
+	if name == "" {
+		return "."
+	}
+	return name
+}
+
+// An includeLoader follows the include statements found by DecodeFS.
+type includeLoader struct {
+	fsys fs.FS
+	// dir is the directory relative include paths are resolved against.
+	dir  string
+	opts []DecodeOption
+	// inline is set by the InlineIncludes option.
+	inline bool
+	// open holds the path of each file being loaded, outermost first.
+	open []string
+	// options is shared by every file loaded, so that an option defined in
+	// one can be used in the files it includes.
+	options *optionRegistry
+}
+
+// resolve returns the path within il.fsys of the file an include statement
+// names.
+func (il *includeLoader) resolve(name string) string {
+	if path.IsAbs(name) {
+		return rootRelative(path.Clean(name))
+	}
+	return path.Join(il.dir, name)
+}
+
+// load decodes the file at fsPath, reporting it as displayName in any error.
+func (il *includeLoader) load(fsPath, displayName string) ([]Statement, error) {
+	fd, err := il.fsys.Open(fsPath)
+	if err != nil {
+		return nil, err
+	}
+	defer fd.Close()
+
+	l := newLexer(fd)
+	for _, opt := range il.opts {
+		opt(l)
+	}
+	l.pos.Filename = displayName
+	il.inline = l.inlineIncludes
+	if il.options == nil {
+		il.options = l.options
+	}
+	l.options = il.options
+	l.include = func(name string) ([]Statement, error) {
+		return il.include(displayName, name)
+	}
+
+	il.open = append(il.open, fsPath)
+	defer func() {
+		il.open = il.open[:len(il.open)-1]
+	}()
+	statements, err := l.decode()
+	if err != nil {
+		return nil, err
+	}
+	if il.inline {
+		statements = inlineIncludes(statements)
+	}
+	return statements, nil
+}
+
+// includeStatement is called by the parser on finding an include statement.
+// When decoding through an includeLoader the file it names is loaded
+// straight away, so that the options defined there apply to the statements
+// which follow.
+func includeStatement(yylex yyLexer, name string) Statement {
+	is := IncludeStatement{Filename: name}
+	l, ok := yylex.(*lexer)
+	if !ok || l.include == nil || l.hasErrored {
+		return is
+	}
+	var err error
+	is.Statements, err = l.include(name)
+	if err != nil {
+		l.err = err
+		l.hasErrored = true
+	}
+	return is
+}
+
+// inlineIncludes replaces the include statements found in a block of
+// Statements, including those nested within other Statements, with the
+// Statements of the files they name.
+func inlineIncludes(statements []Statement) []Statement {
+	var expanded []Statement
+	for _, statement := range statements {
+		ss, wrapped := statement.(*SourceStatement)
+		inner := Unwrap(statement)
+
+		switch st := inner.(type) {
+		case IncludeStatement:
+			expanded = append(expanded, st.Statements...)
+			continue
+		case blockStatement:
+			inner = st.mapBlocks(inlineIncludes)
+		}
+
+		if wrapped {
+			ss.Statement = inner
+			inner = ss
+		}
+		expanded = append(expanded, inner)
+	}
+	return expanded
+}
+
+// include loads the file named by an include statement found in the named
+// file.
+func (il *includeLoader) include(filename, name string) ([]Statement, error) {
+	fsPath := il.resolve(name)
+	for _, open := range il.open {
+		if open == fsPath {
+			return nil, &IncludeError{Filename: filename, Include: name, Err: ErrIncludeCycle}
+		}
+	}
+	statements, err := il.load(fsPath, name)
+	if pathErr, ok := err.(*fs.PathError); ok {
+		return nil, &IncludeError{Filename: filename, Include: name, Err: pathErr}
+	}
+	return statements, err
+}
@@ -0,0 +1,169 @@
+package iscdhcp
+
+import (
+	"errors"
+	"net"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+	"testing/fstest"
+)
+
+func TestDecodeFS(t *testing.T) {
+	fsys := fstest.MapFS{
+		"etc/dhcpd.conf": {Data: []byte(`authoritative;
+subnet 10.0.0.0 netmask 255.255.255.0 {
+    include "/etc/hosts.conf";
+}
+`)},
+		"etc/hosts.conf": {Data: []byte(`host printer {
+    hardware ethernet 0:1:2:3:4:5;
+}
+include "more.conf";
+`)},
+		"more.conf": {Data: []byte("host scanner {\n}\n")},
+	}
+	printer := HostStatement{
+		Hostname:   "printer",
+		Statements: []Statement{HardwareStatement{HardwareType: "ethernet", HardwareAddress: "0:1:2:3:4:5"}},
+	}
+	scanner := HostStatement{Hostname: "scanner"}
+
+	testCases := []struct {
+		opts     []DecodeOption
+		expected []Statement
+	}{
+		{
+			expected: []Statement{
+				AuthoritativeStatement(true),
+				SubnetStatement{
+					SubnetNumber: net.ParseIP("10.0.0.0"),
+					Netmask:      net.ParseIP("255.255.255.0"),
+					Statements: []Statement{
+						IncludeStatement{
+							Filename: "/etc/hosts.conf",
+							Statements: []Statement{
+								printer,
+								IncludeStatement{
+									Filename:   "more.conf",
+									Statements: []Statement{scanner},
+								},
+							},
+						},
+					},
+				},
+			},
+		},
+		{
+			opts: []DecodeOption{InlineIncludes()},
+			expected: []Statement{
+				AuthoritativeStatement(true),
+				SubnetStatement{
+					SubnetNumber: net.ParseIP("10.0.0.0"),
+					Netmask:      net.ParseIP("255.255.255.0"),
+					Statements:   []Statement{printer, scanner},
+				},
+			},
+		},
+	}
+
+	for _, tc := range testCases {
+		statements, err := DecodeFS(fsys, "etc/dhcpd.conf", tc.opts...)
+		if err != nil {
+			t.Fatalf("DecodeFS(): %s", err)
+		}
+		if !reflect.DeepEqual(tc.expected, statements) {
+			t.Errorf("expected %#v, got %#v", tc.expected, statements)
+		}
+	}
+}
+
+func TestDecodeFS_optionDefinitions(t *testing.T) {
+	fsys := fstest.MapFS{
+		"dhcpd.conf": {Data: []byte(`option space pxelinux;
+option pxelinux.magic code 208 = string;
+include "pxe.conf";
+`)},
+		"pxe.conf": {Data: []byte("option pxelinux.magic f1:00:74:7e;\n")},
+	}
+	statements, err := DecodeFS(fsys, "dhcpd.conf", InlineIncludes())
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	expected := OptionStatement{Name: "pxelinux.magic", Values: [][]interface{}{{HexStringTerm("f1:00:74:7e")}}}
+	if len(statements) != 3 || !reflect.DeepEqual(statements[2], expected) {
+		t.Errorf("expected the included option to use the definition, got %#v", statements)
+	}
+}
+
+func TestDecodeFS_includedOptionDefinitions(t *testing.T) {
+	fsys := fstest.MapFS{
+		"dhcpd.conf": {Data: []byte(`include "pxe-defs.conf";
+group {
+    option pxelinux.magic f1:00:74:7e;
+}
+`)},
+		"pxe-defs.conf": {Data: []byte(`option space pxelinux;
+option pxelinux.magic code 208 = string;
+`)},
+	}
+	statements, err := DecodeFS(fsys, "dhcpd.conf")
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	expected := GroupStatement{Statements: []Statement{
+		OptionStatement{Name: "pxelinux.magic", Values: [][]interface{}{{HexStringTerm("f1:00:74:7e")}}},
+	}}
+	if len(statements) != 2 || !reflect.DeepEqual(statements[1], expected) {
+		t.Errorf("expected the option to use the included definition, got %#v", statements)
+	}
+}
+
+func TestDecodeFS_errors(t *testing.T) {
+	fsys := fstest.MapFS{
+		"a.conf":      {Data: []byte("include \"b.conf\";\n")},
+		"b.conf":      {Data: []byte("group {\n    include \"a.conf\";\n}\n")},
+		"broken.conf": {Data: []byte("include \"bad.conf\";\n")},
+		"bad.conf":    {Data: []byte("authoritative\n")},
+		"lost.conf":   {Data: []byte("include \"missing.conf\";\n")},
+	}
+
+	_, err := DecodeFS(fsys, "a.conf")
+	var ie *IncludeError
+	if !errors.As(err, &ie) || ie.Filename != "b.conf" || ie.Include != "a.conf" || ie.Err != ErrIncludeCycle {
+		t.Errorf("expected include cycle in b.conf, got %v", err)
+	}
+
+	_, err = DecodeFS(fsys, "broken.conf")
+	var pe *ParseError
+	if !errors.As(err, &pe) || pe.Filename != "bad.conf" || pe.Line != 2 {
+		t.Errorf("expected parse error at bad.conf:2, got %v", err)
+	}
+
+	_, err = DecodeFS(fsys, "lost.conf")
+	if !errors.As(err, &ie) || ie.Filename != "lost.conf" || ie.Include != "missing.conf" {
+		t.Errorf("expected missing include in lost.conf, got %v", err)
+	}
+}
+
+func TestDecodeFile(t *testing.T) {
+	dir := t.TempDir()
+	hostsName := filepath.Join(dir, "hosts.conf")
+	confName := filepath.Join(dir, "dhcpd.conf")
+	if err := os.WriteFile(hostsName, []byte("host printer {\n}\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(confName, []byte("include \""+filepath.ToSlash(hostsName)+"\";\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	statements, err := DecodeFile(confName, InlineIncludes())
+	if err != nil {
+		t.Fatalf("DecodeFile(): %s", err)
+	}
+	expected := []Statement{HostStatement{Hostname: "printer"}}
+	if !reflect.DeepEqual(expected, statements) {
+		t.Errorf("expected %#v, got %#v", expected, statements)
+	}
+}
@@ -0,0 +1,914 @@
+package iscdhcp
+
+import (
+	"bufio"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"net"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// A LeaseFile holds the contents of a dhcpd.leases file.
+// See dhcpd.leases(5)
+type LeaseFile struct {
+	// AuthoringByteOrder is the argument of the "authoring-byte-order"
+	// statement, e.g. "little-endian", if there is one.
+	AuthoringByteOrder string
+	// ServerDUID is the argument of the "server-duid" statement, if there is
+	// one.
+	ServerDUID []byte
+	// FailoverPeers holds the state of each failover peer, Leases each IPv4
+	// lease and IAs each IPv6 identity association, in the order they were
+	// found.
+	FailoverPeers []FailoverPeerState
+	Leases        []Lease
+	IAs           []IA
+	// Extra holds any other statements, as they appeared in the file.
+	Extra []string
+
+	// order records which of the fields above each statement DecodeLeases
+	// found was decoded into, so that EncodeLeases can write them out in
+	// the same order.
+	order []leaseEntry
+}
+
+// A leaseEntry identifies the LeaseFile field a top-level statement from a
+// dhcpd.leases file is held in.
+type leaseEntry int
+
+const (
+	entryAuthoringByteOrder leaseEntry = iota
+	entryServerDUID
+	entryFailoverPeer
+	entryLease
+	entryIA
+	entryExtra
+	numLeaseEntries
+)
+
+// A Lease represents a lease declaration in a dhcpd.leases file, recording
+// the state of an IPv4 address. A file may contain many declarations for one
+// address; the last of them is current.
+type Lease struct {
+	IP     net.IP
+	Starts LeaseTime
+	Ends   LeaseTime
+	// TSTP, TSFP and ATSFP are recorded by servers taking part in failover.
+	TSTP  LeaseTime
+	TSFP  LeaseTime
+	ATSFP LeaseTime
+	// CLTT is the client's last transaction time.
+	CLTT LeaseTime
+	// BindingState is e.g. "active" or "free"; NextBindingState and
+	// RewindBindingState give the states the lease moves to when it expires
+	// and when a failover peer rewinds it, respectively.
+	BindingState       string
+	NextBindingState   string
+	RewindBindingState string
+	// HardwareType is e.g. "ethernet", and HardwareAddress the client's
+	// address as written in the file.
+	HardwareType    string
+	HardwareAddress string
+	UID             []byte
+	ClientHostname  string
+	// Variables holds the values of any "set" statements, e.g. the names
+	// recorded for DDNS updates.
+	Variables []LeaseVariable
+	// Extra holds any other statements within the declaration, as they
+	// appeared in the file.
+	Extra []string
+}
+
+// A LeaseVariable represents a "set" statement in a dhcpd.leases file. Value
+// is a StringConstTerm or HexStringTerm.
+type LeaseVariable struct {
+	Name  string
+	Value fmt.Stringer
+}
+
+// An IA represents an "ia-na", "ia-ta" or "ia-pd" declaration in a
+// dhcpd.leases file, recording the IPv6 addresses or prefixes leased to an
+// identity association.
+type IA struct {
+	// Type is one of "ia-na", "ia-ta" or "ia-pd".
+	Type string
+	// ID is the identity association's IAID followed by the client's DUID.
+	ID   []byte
+	CLTT LeaseTime
+	// Addresses holds an entry for each "iaaddr" or "iaprefix" declaration.
+	Addresses []IAAddress
+	// Extra holds any other statements within the declaration, as they
+	// appeared in the file.
+	Extra []string
+}
+
+// An IAAddress represents an "iaaddr" declaration within an ia-na or ia-ta,
+// in which case Address is set, or an "iaprefix" declaration within an
+// ia-pd, in which case Prefix is set.
+type IAAddress struct {
+	Address       net.IP
+	Prefix        *net.IPNet
+	BindingState  string
+	PreferredLife int
+	MaxLife       int
+	Ends          LeaseTime
+	Variables     []LeaseVariable
+	Extra         []string
+}
+
+// A FailoverPeerState represents a "failover peer ... state" declaration in
+// a dhcpd.leases file, recording the last known state of a failover
+// relationship.
+type FailoverPeerState struct {
+	Name             string
+	MyState          string
+	MyStateTime      LeaseTime
+	PartnerState     string
+	PartnerStateTime LeaseTime
+	// MCLT is the maximum client lead time, in seconds.
+	MCLT  int
+	Extra []string
+}
+
+// A LeaseTime is a time recorded in a dhcpd.leases file. If Never is set,
+// the time is the special value "never". A LeaseTime with neither a Time nor
+// Never set wasn't present in the file.
+type LeaseTime struct {
+	time.Time
+	Never bool
+}
+
+const leaseTimeLayout = "2006/01/02 15:04:05"
+
+func (lt LeaseTime) String() string {
+	if lt.Never {
+		return "never"
+	}
+	t := lt.UTC()
+	return strconv.Itoa(int(t.Weekday())) + " " + t.Format(leaseTimeLayout)
+}
+
+func (lt LeaseTime) isSet() bool {
+	return lt.Never || !lt.IsZero()
+}
+
+// DecodeLeases analyzes the contents of a dhcpd.leases file. Of the
+// DecodeOptions, only Filename has any effect.
+//
+// If the file can't be parsed, the error returned is a *ParseError.
+func DecodeLeases(dataStream io.Reader, opts ...DecodeOption) (*LeaseFile, error) {
+	p := newLeaseParser(dataStream, opts)
+	lf := &LeaseFile{}
+	for {
+		n, err := p.readNode()
+		if err != nil {
+			return nil, err
+		}
+		if n == nil {
+			return lf, nil
+		}
+		if err := lf.add(n); err != nil {
+			return nil, err
+		}
+	}
+}
+
+// add interprets a top-level statement from a dhcpd.leases file.
+func (lf *LeaseFile) add(n *leaseNode) error {
+	var entry leaseEntry
+	switch n.keyword(0) {
+	case "lease":
+		lease, err := decodeLease(n)
+		if err != nil {
+			return err
+		}
+		lf.Leases = append(lf.Leases, lease)
+		entry = entryLease
+	case "ia-na", "ia-ta", "ia-pd":
+		ia, err := decodeIA(n)
+		if err != nil {
+			return err
+		}
+		lf.IAs = append(lf.IAs, ia)
+		entry = entryIA
+	case "failover":
+		fps, err := decodeFailoverPeerState(n)
+		if err != nil {
+			return err
+		}
+		lf.FailoverPeers = append(lf.FailoverPeers, fps)
+		entry = entryFailoverPeer
+	case "server-duid":
+		if len(n.tokens) != 2 || n.block {
+			return n.malformed()
+		}
+		duid, err := n.tokens[1].octets()
+		if err != nil {
+			return err
+		}
+		lf.ServerDUID = duid
+		entry = entryServerDUID
+	case "authoring-byte-order":
+		if len(n.tokens) != 2 || n.block {
+			return n.malformed()
+		}
+		lf.AuthoringByteOrder = n.tokens[1].text()
+		entry = entryAuthoringByteOrder
+	default:
+		lf.Extra = append(lf.Extra, n.String())
+		entry = entryExtra
+	}
+	lf.order = append(lf.order, entry)
+	return nil
+}
+
+func decodeLease(n *leaseNode) (Lease, error) {
+	var lease Lease
+	if len(n.tokens) != 2 || !n.block {
+		return lease, n.malformed()
+	}
+	lease.IP = net.ParseIP(n.tokens[1].text())
+	if lease.IP == nil {
+		return lease, n.tokens[1].errorf("invalid IP address")
+	}
+
+	var err error
+	for _, c := range n.children {
+		switch kw := c.keyword(0); kw {
+		case "starts":
+			lease.Starts, err = c.leaseTime(1)
+		case "ends":
+			lease.Ends, err = c.leaseTime(1)
+		case "tstp":
+			lease.TSTP, err = c.leaseTime(1)
+		case "tsfp":
+			lease.TSFP, err = c.leaseTime(1)
+		case "atsfp":
+			lease.ATSFP, err = c.leaseTime(1)
+		case "cltt":
+			lease.CLTT, err = c.leaseTime(1)
+		case "binding", "next", "rewind":
+			var state string
+			state, err = c.bindingState()
+			switch kw {
+			case "binding":
+				lease.BindingState = state
+			case "next":
+				lease.NextBindingState = state
+			case "rewind":
+				lease.RewindBindingState = state
+			}
+		case "hardware":
+			if len(c.tokens) != 3 || c.block {
+				return lease, c.malformed()
+			}
+			lease.HardwareType = c.tokens[1].text()
+			lease.HardwareAddress = c.tokens[2].text()
+		case "uid":
+			if len(c.tokens) != 2 || c.block {
+				return lease, c.malformed()
+			}
+			lease.UID, err = c.tokens[1].octets()
+		case "client-hostname":
+			if len(c.tokens) != 2 || c.block || c.tokens[1].typ != tokenTypeString {
+				return lease, c.malformed()
+			}
+			lease.ClientHostname, err = c.tokens[1].str()
+		case "set":
+			var v LeaseVariable
+			v, err = c.variable()
+			lease.Variables = append(lease.Variables, v)
+		default:
+			lease.Extra = append(lease.Extra, c.String())
+		}
+		if err != nil {
+			return lease, err
+		}
+	}
+	return lease, nil
+}
+
+func decodeIA(n *leaseNode) (IA, error) {
+	ia := IA{Type: n.keyword(0)}
+	if len(n.tokens) != 2 || !n.block {
+		return ia, n.malformed()
+	}
+	var err error
+	if ia.ID, err = n.tokens[1].octets(); err != nil {
+		return ia, err
+	}
+
+	for _, c := range n.children {
+		switch c.keyword(0) {
+		case "cltt":
+			ia.CLTT, err = c.leaseTime(1)
+		case "iaaddr", "iaprefix":
+			var addr IAAddress
+			addr, err = decodeIAAddress(c)
+			ia.Addresses = append(ia.Addresses, addr)
+		default:
+			ia.Extra = append(ia.Extra, c.String())
+		}
+		if err != nil {
+			return ia, err
+		}
+	}
+	return ia, nil
+}
+
+func decodeIAAddress(n *leaseNode) (IAAddress, error) {
+	var addr IAAddress
+	if len(n.tokens) != 2 || !n.block {
+		return addr, n.malformed()
+	}
+	if n.keyword(0) == "iaaddr" {
+		addr.Address = net.ParseIP(n.tokens[1].text())
+		if addr.Address == nil {
+			return addr, n.tokens[1].errorf("invalid IPv6 address")
+		}
+	} else {
+		_, prefix, err := net.ParseCIDR(n.tokens[1].text())
+		if err != nil {
+			return addr, n.tokens[1].errorf("invalid IPv6 prefix")
+		}
+		addr.Prefix = prefix
+	}
+
+	var err error
+	for _, c := range n.children {
+		switch c.keyword(0) {
+		case "binding":
+			addr.BindingState, err = c.bindingState()
+		case "preferred-life":
+			addr.PreferredLife, err = c.number()
+		case "max-life":
+			addr.MaxLife, err = c.number()
+		case "ends":
+			addr.Ends, err = c.leaseTime(1)
+		case "set":
+			var v LeaseVariable
+			v, err = c.variable()
+			addr.Variables = append(addr.Variables, v)
+		default:
+			addr.Extra = append(addr.Extra, c.String())
+		}
+		if err != nil {
+			return addr, err
+		}
+	}
+	return addr, nil
+}
+
+func decodeFailoverPeerState(n *leaseNode) (FailoverPeerState, error) {
+	var fps FailoverPeerState
+	if len(n.tokens) != 4 || !n.block || n.keyword(1) != "peer" || n.keyword(3) != "state" ||
+		n.tokens[2].typ != tokenTypeString {
+		return fps, n.malformed()
+	}
+	var err error
+	if fps.Name, err = n.tokens[2].str(); err != nil {
+		return fps, err
+	}
+
+	for _, c := range n.children {
+		switch kw := c.keyword(0); kw {
+		case "my", "partner":
+			// e.g. "my state normal at 3 2026/10/01 12:00:00"
+			if len(c.tokens) < 5 || c.block || c.keyword(1) != "state" || c.keyword(3) != "at" {
+				return fps, c.malformed()
+			}
+			var t LeaseTime
+			if t, err = c.leaseTime(4); err != nil {
+				return fps, err
+			}
+			if kw == "my" {
+				fps.MyState, fps.MyStateTime = c.tokens[2].text(), t
+			} else {
+				fps.PartnerState, fps.PartnerStateTime = c.tokens[2].text(), t
+			}
+		case "mclt":
+			if fps.MCLT, err = c.number(); err != nil {
+				return fps, err
+			}
+		default:
+			fps.Extra = append(fps.Extra, c.String())
+		}
+	}
+	return fps, nil
+}
+
+// A LeaseScanner reads the lease declarations in a dhcpd.leases file one at a
+// time, so that files too large to hold in memory can be processed. Other
+// declarations, such as IPv6 identity associations, are skipped; use
+// DecodeLeases to read those.
+//
+// Example usage:
+//
+//	ls := NewLeaseScanner(fd)
+//	for ls.Next() {
+//		lease := ls.Lease()
+//		...
+//	}
+//	if err := ls.Err(); err != nil {
+//		...
+//	}
+type LeaseScanner struct {
+	p     *leaseParser
+	lease Lease
+	err   error
+}
+
+// NewLeaseScanner returns a LeaseScanner reading from dataStream. Of the
+// DecodeOptions, only Filename has any effect.
+func NewLeaseScanner(dataStream io.Reader, opts ...DecodeOption) *LeaseScanner {
+	return &LeaseScanner{p: newLeaseParser(dataStream, opts)}
+}
+
+// Next advances to the next lease declaration, which is then available from
+// Lease. It returns false at the end of the file, or on finding an error,
+// which is then available from Err.
+func (ls *LeaseScanner) Next() bool {
+	if ls.err != nil {
+		return false
+	}
+	for {
+		n, err := ls.p.readNode()
+		if err != nil {
+			ls.err = err
+			return false
+		}
+		if n == nil {
+			return false
+		}
+		if n.keyword(0) != "lease" {
+			continue
+		}
+		ls.lease, ls.err = decodeLease(n)
+		return ls.err == nil
+	}
+}
+
+// Lease returns the lease declaration found by the last call to Next.
+func (ls *LeaseScanner) Lease() Lease {
+	return ls.lease
+}
+
+// Err returns the error which caused Next to return false, or nil if it
+// reached the end of the file. If the file can't be parsed, the error is a
+// *ParseError.
+func (ls *LeaseScanner) Err() error {
+	return ls.err
+}
+
+// LatestLeases reads the lease declarations in a dhcpd.leases file, keeping
+// only the last declaration for each IP address, as dhcpd does when loading
+// the file at startup. The leases are returned in the order their addresses
+// were first seen. Only one lease per address is held in memory at a time.
+func LatestLeases(dataStream io.Reader, opts ...DecodeOption) ([]Lease, error) {
+	var leases []Lease
+	indexByIP := make(map[string]int)
+	ls := NewLeaseScanner(dataStream, opts...)
+	for ls.Next() {
+		lease := ls.Lease()
+		key := string(lease.IP.To16())
+		if i, found := indexByIP[key]; found {
+			leases[i] = lease
+			continue
+		}
+		indexByIP[key] = len(leases)
+		leases = append(leases, lease)
+	}
+	if err := ls.Err(); err != nil {
+		return nil, err
+	}
+	return leases, nil
+}
+
+// EncodeLeases writes lf to dataStream in the form used by dhcpd.leases. It
+// is the counterpart to DecodeLeases. Nested statements are indented by two
+// spaces per level, as dhcpd does, unless an EncodeOption says otherwise.
+// Statements decoded by DecodeLeases are written in the order they were read,
+// and any others after them, grouped by the LeaseFile field holding them.
+func EncodeLeases(dataStream io.Writer, lf *LeaseFile, opts ...EncodeOption) error {
+	bw := bufio.NewWriter(dataStream)
+	e := &encoder{
+		w:      bw,
+		indent: "  ",
+	}
+	for _, opt := range opts {
+		opt(e)
+	}
+
+	// Statements are written in the order DecodeLeases found them in, and
+	// any added since after those, grouped by kind.
+	var written [numLeaseEntries]int
+	writeNext := func(entry leaseEntry) bool {
+		if !e.writeLeaseEntry(lf, entry, written[entry]) {
+			return false
+		}
+		written[entry]++
+		return true
+	}
+	for _, entry := range lf.order {
+		writeNext(entry)
+	}
+	for entry := leaseEntry(0); entry < numLeaseEntries; entry++ {
+		for writeNext(entry) {
+		}
+	}
+	if e.err != nil {
+		return e.err
+	}
+	return bw.Flush()
+}
+
+// writeLeaseEntry writes the i'th statement held in lf's field for entry,
+// returning false if there isn't one.
+func (e *encoder) writeLeaseEntry(lf *LeaseFile, entry leaseEntry, i int) bool {
+	switch entry {
+	case entryAuthoringByteOrder:
+		if i != 0 || lf.AuthoringByteOrder == "" {
+			return false
+		}
+		e.writeString("authoring-byte-order " + lf.AuthoringByteOrder + ";\n")
+	case entryServerDUID:
+		if i != 0 || lf.ServerDUID == nil {
+			return false
+		}
+		e.writeString("server-duid " + quoteString(string(lf.ServerDUID)) + ";\n")
+	case entryFailoverPeer:
+		if i >= len(lf.FailoverPeers) {
+			return false
+		}
+		e.writeFailoverPeerState(lf.FailoverPeers[i])
+	case entryLease:
+		if i >= len(lf.Leases) {
+			return false
+		}
+		e.writeLease(lf.Leases[i])
+	case entryIA:
+		if i >= len(lf.IAs) {
+			return false
+		}
+		e.writeIA(lf.IAs[i])
+	default:
+		if i >= len(lf.Extra) {
+			return false
+		}
+		e.writeString(lf.Extra[i] + "\n")
+	}
+	return true
+}
+
+func (e *encoder) writeLeaseTime(prefix, name string, lt LeaseTime) {
+	if lt.isSet() {
+		e.writeString(prefix + name + " " + lt.String() + ";\n")
+	}
+}
+
+func (e *encoder) writeLeaseString(prefix, name, value string) {
+	if value != "" {
+		e.writeString(prefix + name + " " + value + ";\n")
+	}
+}
+
+func (e *encoder) writeLeaseVariables(prefix string, variables []LeaseVariable) {
+	for _, v := range variables {
+		e.writeString(prefix + "set " + v.Name + " = " + v.Value.String() + ";\n")
+	}
+}
+
+func (e *encoder) writeLeaseExtra(prefix string, extra []string) {
+	for _, s := range extra {
+		e.writeString(prefix + s + "\n")
+	}
+}
+
+func (e *encoder) writeLease(lease Lease) {
+	p := e.indent
+	e.writeString("lease " + lease.IP.String() + " {\n")
+	e.writeLeaseTime(p, "starts", lease.Starts)
+	e.writeLeaseTime(p, "ends", lease.Ends)
+	e.writeLeaseTime(p, "tstp", lease.TSTP)
+	e.writeLeaseTime(p, "tsfp", lease.TSFP)
+	e.writeLeaseTime(p, "atsfp", lease.ATSFP)
+	e.writeLeaseTime(p, "cltt", lease.CLTT)
+	e.writeLeaseString(p, "binding state", lease.BindingState)
+	e.writeLeaseString(p, "next binding state", lease.NextBindingState)
+	e.writeLeaseString(p, "rewind binding state", lease.RewindBindingState)
+	if lease.HardwareType != "" {
+		e.writeString(p + "hardware " + lease.HardwareType + " " + lease.HardwareAddress + ";\n")
+	}
+	if lease.UID != nil {
+		e.writeString(p + "uid " + quoteString(string(lease.UID)) + ";\n")
+	}
+	e.writeLeaseVariables(p, lease.Variables)
+	if lease.ClientHostname != "" {
+		e.writeString(p + "client-hostname " + quoteString(lease.ClientHostname) + ";\n")
+	}
+	e.writeLeaseExtra(p, lease.Extra)
+	e.writeString("}\n")
+}
+
+func (e *encoder) writeIA(ia IA) {
+	p := e.indent
+	e.writeString(ia.Type + " " + quoteString(string(ia.ID)) + " {\n")
+	e.writeLeaseTime(p, "cltt", ia.CLTT)
+	for _, addr := range ia.Addresses {
+		if addr.Prefix != nil {
+			e.writeString(p + "iaprefix " + addr.Prefix.String() + " {\n")
+		} else {
+			e.writeString(p + "iaaddr " + addr.Address.String() + " {\n")
+		}
+		pp := p + e.indent
+		e.writeLeaseString(pp, "binding state", addr.BindingState)
+		if addr.PreferredLife != 0 {
+			e.writeString(pp + "preferred-life " + strconv.Itoa(addr.PreferredLife) + ";\n")
+		}
+		if addr.MaxLife != 0 {
+			e.writeString(pp + "max-life " + strconv.Itoa(addr.MaxLife) + ";\n")
+		}
+		e.writeLeaseTime(pp, "ends", addr.Ends)
+		e.writeLeaseVariables(pp, addr.Variables)
+		e.writeLeaseExtra(pp, addr.Extra)
+		e.writeString(p + "}\n")
+	}
+	e.writeLeaseExtra(p, ia.Extra)
+	e.writeString("}\n")
+}
+
+func (e *encoder) writeFailoverPeerState(fps FailoverPeerState) {
+	p := e.indent
+	e.writeString("failover peer " + quoteString(fps.Name) + " state {\n")
+	if fps.MyState != "" {
+		e.writeString(p + "my state " + fps.MyState + " at " + fps.MyStateTime.String() + ";\n")
+	}
+	if fps.PartnerState != "" {
+		e.writeString(p + "partner state " + fps.PartnerState + " at " + fps.PartnerStateTime.String() + ";\n")
+	}
+	if fps.MCLT != 0 {
+		e.writeString(p + "mclt " + strconv.Itoa(fps.MCLT) + ";\n")
+	}
+	e.writeLeaseExtra(p, fps.Extra)
+	e.writeString("}\n")
+}
+
+// A leaseToken is a token read from a dhcpd.leases file, along with its
+// position.
+type leaseToken struct {
+	token
+	start Position
+}
+
+func (lt leaseToken) text() string {
+	return string(lt.token.data)
+}
+
+func (lt leaseToken) errorf(format string, a ...interface{}) error {
+	return &ParseError{
+		Position: lt.start,
+		Token:    lt.text(),
+		Msg:      fmt.Sprintf(format, a...),
+	}
+}
+
+// str returns the value of a quoted-string token.
+func (lt leaseToken) str() (string, error) {
+	s, err := unquoteString(strings.TrimSuffix(strings.TrimPrefix(lt.text(), "\""), "\""))
+	if err != nil {
+		return "", lt.errorf("%s", err)
+	}
+	return s, nil
+}
+
+// octets returns the value of a token which is either a quoted string or a
+// list of colon-separated hexadecimal octets.
+func (lt leaseToken) octets() ([]byte, error) {
+	if lt.typ == tokenTypeString {
+		s, err := lt.str()
+		return []byte(s), err
+	}
+	var b []byte
+	for _, octet := range strings.Split(lt.text(), ":") {
+		if len(octet) == 1 {
+			octet = "0" + octet
+		}
+		v, err := hex.DecodeString(octet)
+		if err != nil || len(v) != 1 {
+			return nil, lt.errorf("invalid hex string")
+		}
+		b = append(b, v[0])
+	}
+	return b, nil
+}
+
+// A leaseNode is a statement read from a dhcpd.leases file: a list of tokens
+// which is either terminated by a semicolon, or followed by a block of
+// further statements.
+type leaseNode struct {
+	tokens   []leaseToken
+	block    bool
+	children []*leaseNode
+}
+
+// keyword returns the i'th token of the statement in lower case, or the
+// empty string if there are too few tokens.
+func (n *leaseNode) keyword(i int) string {
+	if i >= len(n.tokens) {
+		return ""
+	}
+	return strings.ToLower(n.tokens[i].text())
+}
+
+func (n *leaseNode) malformed() error {
+	return n.tokens[0].errorf("malformed %q statement", n.tokens[0].text())
+}
+
+// String returns the statement as it appeared in the file, though with its
+// tokens separated by single spaces.
+func (n *leaseNode) String() string {
+	words := make([]string, len(n.tokens))
+	for i, tok := range n.tokens {
+		words[i] = tok.text()
+	}
+	s := strings.Join(words, " ")
+	if !n.block {
+		return s + ";"
+	}
+	s += " {"
+	for _, c := range n.children {
+		s += " " + c.String()
+	}
+	return s + " }"
+}
+
+// leaseTime interprets the tokens of the statement from the i'th onward as a
+// time, in any of the forms dhcpd writes: "never"; "epoch" followed by a
+// number of seconds; or a day of the week, date and time of day in UTC.
+func (n *leaseNode) leaseTime(i int) (LeaseTime, error) {
+	var lt LeaseTime
+	if n.block {
+		return lt, n.malformed()
+	}
+	args := n.tokens[i:]
+	switch {
+	case len(args) == 1 && n.keyword(i) == "never":
+		lt.Never = true
+	case len(args) == 2 && n.keyword(i) == "epoch":
+		secs, err := strconv.ParseInt(args[1].text(), 10, 64)
+		if err != nil {
+			return lt, args[1].errorf("invalid time")
+		}
+		lt.Time = time.Unix(secs, 0).UTC()
+	case len(args) == 3:
+		t, err := time.Parse(leaseTimeLayout, args[1].text()+" "+args[2].text())
+		if err != nil {
+			return lt, args[1].errorf("invalid time")
+		}
+		lt.Time = t
+	default:
+		return lt, n.malformed()
+	}
+	return lt, nil
+}
+
+// bindingState interprets a "binding state", "next binding state" or
+// "rewind binding state" statement.
+func (n *leaseNode) bindingState() (string, error) {
+	i := 0
+	if n.keyword(0) != "binding" {
+		i = 1
+	}
+	if len(n.tokens) != i+3 || n.block || n.keyword(i) != "binding" || n.keyword(i+1) != "state" {
+		return "", n.malformed()
+	}
+	return n.keyword(i + 2), nil
+}
+
+// number interprets a statement with a single numeric argument.
+func (n *leaseNode) number() (int, error) {
+	if len(n.tokens) != 2 || n.block {
+		return 0, n.malformed()
+	}
+	v, err := strconv.Atoi(n.tokens[1].text())
+	if err != nil {
+		return 0, n.tokens[1].errorf("invalid number")
+	}
+	return v, nil
+}
+
+// variable interprets a "set" statement.
+func (n *leaseNode) variable() (LeaseVariable, error) {
+	var v LeaseVariable
+	if len(n.tokens) != 4 || n.block || n.tokens[2].text() != "=" {
+		return v, n.malformed()
+	}
+	v.Name = n.tokens[1].text()
+	if n.tokens[3].typ == tokenTypeString {
+		s, err := n.tokens[3].str()
+		if err != nil {
+			return v, err
+		}
+		v.Value = StringConstTerm(s)
+	} else {
+		v.Value = HexStringTerm(n.tokens[3].text())
+	}
+	return v, nil
+}
+
+// A leaseParser reads statements from a dhcpd.leases file, using the same
+// lexer as Decode but without the yacc-generated parser; the file's
+// structure is simple enough not to need it.
+type leaseParser struct {
+	l   *lexer
+	tok leaseToken
+	// started is set once the first token has been read.
+	started bool
+}
+
+func newLeaseParser(dataStream io.Reader, opts []DecodeOption) *leaseParser {
+	l := newLexer(dataStream)
+	if named, ok := dataStream.(interface{ Name() string }); ok {
+		l.pos.Filename = named.Name()
+	}
+	for _, opt := range opts {
+		opt(l)
+	}
+	return &leaseParser{l: l}
+}
+
+// next reads the next token which isn't whitespace or a comment into p.tok.
+// At the end of the file, p.tok.data is empty.
+func (p *leaseParser) next() error {
+	for {
+		tok, err := p.l.nextToken()
+		if err != nil && err != io.EOF {
+			return err
+		}
+		if len(tok.data) == 0 {
+			p.tok = leaseToken{start: p.l.pos}
+			return nil
+		}
+		if tok.typ == tokenTypeWhiteSpace || tok.typ == tokenTypeComment {
+			continue
+		}
+		p.tok = leaseToken{token: tok, start: p.l.tokenStart}
+		return nil
+	}
+}
+
+// readNode reads the next top-level statement, returning nil at the end of
+// the file.
+func (p *leaseParser) readNode() (*leaseNode, error) {
+	if !p.started {
+		p.started = true
+		if err := p.next(); err != nil {
+			return nil, err
+		}
+	}
+	if len(p.tok.data) == 0 {
+		return nil, nil
+	}
+	return p.readStatement()
+}
+
+func (p *leaseParser) readStatement() (*leaseNode, error) {
+	n := &leaseNode{}
+	for {
+		switch {
+		case len(p.tok.data) == 0:
+			return nil, p.tok.errorf("syntax error")
+		case p.tok.typ == tokenTypeBlockEnd:
+			return nil, p.tok.errorf("syntax error")
+		case p.tok.typ == tokenTypeSemicolon:
+			if len(n.tokens) == 0 {
+				return nil, p.tok.errorf("syntax error")
+			}
+			return n, p.next()
+		case p.tok.typ == tokenTypeBlockStart:
+			if len(n.tokens) == 0 {
+				return nil, p.tok.errorf("syntax error")
+			}
+			n.block = true
+			if err := p.next(); err != nil {
+				return nil, err
+			}
+			for p.tok.typ != tokenTypeBlockEnd {
+				child, err := p.readStatement()
+				if err != nil {
+					return nil, err
+				}
+				n.children = append(n.children, child)
+			}
+			return n, p.next()
+		default:
+			n.tokens = append(n.tokens, p.tok)
+			if err := p.next(); err != nil {
+				return nil, err
+			}
+		}
+	}
+}
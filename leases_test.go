@@ -0,0 +1,271 @@
+package iscdhcp
+
+import (
+	"errors"
+	"net"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+const testLeaseFile = `# The format of this file is documented in the dhcpd.leases(5) manual page.
+# This lease file was written by isc-dhcp-4.4.3
+
+# authoring-byte-order entry is generated, DO NOT DELETE
+authoring-byte-order little-endian;
+
+server-duid "\000\001\000\001*\203\3226\000\014)\000\000\001";
+
+failover peer "dhcp-failover" state {
+  my state normal at 4 2026/10/01 11:00:00;
+  partner state normal at 4 2026/10/01 11:00:05;
+  mclt 3600;
+}
+
+lease 10.0.0.5 {
+  starts 4 2026/10/01 12:00:00;
+  ends 4 2026/10/01 13:00:00;
+  tstp 4 2026/10/01 13:00:00;
+  cltt 4 2026/10/01 12:00:00;
+  binding state active;
+  next binding state free;
+  rewind binding state free;
+  hardware ethernet 00:11:22:33:44:55;
+  uid "\001\000\021\"3DU";
+  set vendor-class-identifier = "MSFT 5.0";
+  client-hostname "laptop";
+}
+lease 10.0.0.6 {
+  starts epoch 1790856000; # Thu Oct 01 12:00:00 2026
+  ends never;
+  binding state backup;
+  uid 01:00:11:22:33:44:66;
+  on expiry { set x = "y"; }
+}
+ia-na "\001\000\000\000\000\001\000\001" {
+  cltt 4 2026/10/01 12:00:00;
+  iaaddr 2001:db8::100 {
+    binding state active;
+    preferred-life 375;
+    max-life 600;
+    ends 4 2026/10/01 12:10:00;
+  }
+}
+ia-pd "\002\000\000\000" {
+  iaprefix 2001:db8:0:100::/56 {
+    binding state active;
+    max-life 600;
+  }
+}
+`
+
+func TestDecodeLeases(t *testing.T) {
+	lf, err := DecodeLeases(strings.NewReader(testLeaseFile))
+	if err != nil {
+		t.Fatalf("DecodeLeases(): %s", err)
+	}
+
+	at := func(s string) LeaseTime {
+		tm, err := time.Parse(leaseTimeLayout, s)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return LeaseTime{Time: tm}
+	}
+	_, prefix, _ := net.ParseCIDR("2001:db8:0:100::/56")
+	expected := &LeaseFile{
+		AuthoringByteOrder: "little-endian",
+		ServerDUID:         []byte("\x00\x01\x00\x01*\x83\xd26\x00\x0c)\x00\x00\x01"),
+		FailoverPeers: []FailoverPeerState{{
+			Name:             "dhcp-failover",
+			MyState:          "normal",
+			MyStateTime:      at("2026/10/01 11:00:00"),
+			PartnerState:     "normal",
+			PartnerStateTime: at("2026/10/01 11:00:05"),
+			MCLT:             3600,
+		}},
+		Leases: []Lease{
+			{
+				IP:                 net.ParseIP("10.0.0.5"),
+				Starts:             at("2026/10/01 12:00:00"),
+				Ends:               at("2026/10/01 13:00:00"),
+				TSTP:               at("2026/10/01 13:00:00"),
+				CLTT:               at("2026/10/01 12:00:00"),
+				BindingState:       "active",
+				NextBindingState:   "free",
+				RewindBindingState: "free",
+				HardwareType:       "ethernet",
+				HardwareAddress:    "00:11:22:33:44:55",
+				UID:                []byte("\x01\x00\x11\"3DU"),
+				ClientHostname:     "laptop",
+				Variables: []LeaseVariable{
+					{Name: "vendor-class-identifier", Value: StringConstTerm("MSFT 5.0")},
+				},
+			},
+			{
+				IP:           net.ParseIP("10.0.0.6"),
+				Starts:       at("2026/10/01 12:00:00"),
+				Ends:         LeaseTime{Never: true},
+				BindingState: "backup",
+				UID:          []byte{1, 0, 0x11, 0x22, 0x33, 0x44, 0x66},
+				Extra:        []string{`on expiry { set x = "y"; }`},
+			},
+		},
+		IAs: []IA{
+			{
+				Type: "ia-na",
+				ID:   []byte{1, 0, 0, 0, 0, 1, 0, 1},
+				CLTT: at("2026/10/01 12:00:00"),
+				Addresses: []IAAddress{{
+					Address:       net.ParseIP("2001:db8::100"),
+					BindingState:  "active",
+					PreferredLife: 375,
+					MaxLife:       600,
+					Ends:          at("2026/10/01 12:10:00"),
+				}},
+			},
+			{
+				Type: "ia-pd",
+				ID:   []byte{2, 0, 0, 0},
+				Addresses: []IAAddress{{
+					Prefix:       prefix,
+					BindingState: "active",
+					MaxLife:      600,
+				}},
+			},
+		},
+		order: []leaseEntry{
+			entryAuthoringByteOrder, entryServerDUID, entryFailoverPeer,
+			entryLease, entryLease, entryIA, entryIA,
+		},
+	}
+	if !reflect.DeepEqual(expected, lf) {
+		t.Errorf("expected %#v, got %#v", expected, lf)
+	}
+}
+
+func TestEncodeLeases_roundTrip(t *testing.T) {
+	lf, err := DecodeLeases(strings.NewReader(testLeaseFile))
+	if err != nil {
+		t.Fatalf("DecodeLeases(): %s", err)
+	}
+	var sb strings.Builder
+	if err := EncodeLeases(&sb, lf); err != nil {
+		t.Fatalf("EncodeLeases(): %s", err)
+	}
+	if !strings.Contains(sb.String(), "  uid \"\\001\\000\\021\\\"3DU\";\n") {
+		t.Errorf("expected escaped uid in output:\n%s", sb.String())
+	}
+
+	newLF, err := DecodeLeases(strings.NewReader(sb.String()))
+	if err != nil {
+		t.Fatalf("DecodeLeases(): %s\n%s", err, sb.String())
+	}
+	if !reflect.DeepEqual(lf, newLF) {
+		t.Errorf("actual != expected: %#v != %#v", newLF, lf)
+	}
+}
+
+func TestEncodeLeases_order(t *testing.T) {
+	data := `lease 10.0.0.5 {
+  binding state active;
+}
+failover peer "dhcp-failover" state {
+  mclt 3600;
+}
+ia-pd "\002\000\000\000" {
+}
+lease 10.0.0.6 {
+  binding state free;
+}
+server-duid "duid";
+`
+	lf, err := DecodeLeases(strings.NewReader(data))
+	if err != nil {
+		t.Fatalf("DecodeLeases(): %s", err)
+	}
+	lf.Leases = append(lf.Leases, Lease{IP: net.ParseIP("10.0.0.7")})
+	var sb strings.Builder
+	if err := EncodeLeases(&sb, lf); err != nil {
+		t.Fatalf("EncodeLeases(): %s", err)
+	}
+	expected := data + "lease 10.0.0.7 {\n}\n"
+	if sb.String() != expected {
+		t.Errorf("expected:\n%s\ngot:\n%s", expected, sb.String())
+	}
+}
+
+func TestDecodeLeases_errors(t *testing.T) {
+	testCases := []struct {
+		data     string
+		line     int
+		expected string
+	}{
+		{"lease 10.0.0.5 {\n  starts 4 2026/13/01 12:00:00;\n}\n", 2, "invalid time"},
+		{"lease 10.0.0.5 {\n  binding active;\n}\n", 2, `malformed "binding" statement`},
+		{"lease 10.0.0.500 {\n}\n", 1, "invalid IP address"},
+		{"lease 10.0.0.5 {\n  ends never;\n", 3, "syntax error"},
+	}
+	for _, tc := range testCases {
+		_, err := DecodeLeases(strings.NewReader(tc.data))
+		var pe *ParseError
+		if !errors.As(err, &pe) || pe.Line != tc.line || pe.Msg != tc.expected {
+			t.Errorf("%q: expected %q at line %d, got %v", tc.data, tc.expected, tc.line, err)
+		}
+	}
+}
+
+func TestLeaseScanner(t *testing.T) {
+	data := testLeaseFile + `lease 10.0.0.5 {
+  binding state free;
+}
+lease 10.0.0.7 {
+  binding state free;
+}
+`
+	var ips []string
+	ls := NewLeaseScanner(strings.NewReader(data))
+	for ls.Next() {
+		ips = append(ips, ls.Lease().IP.String())
+	}
+	if err := ls.Err(); err != nil {
+		t.Fatalf("Err(): %s", err)
+	}
+	expectedIPs := []string{"10.0.0.5", "10.0.0.6", "10.0.0.5", "10.0.0.7"}
+	if !reflect.DeepEqual(expectedIPs, ips) {
+		t.Errorf("expected %v, got %v", expectedIPs, ips)
+	}
+
+	leases, err := LatestLeases(strings.NewReader(data))
+	if err != nil {
+		t.Fatalf("LatestLeases(): %s", err)
+	}
+	var states []string
+	for _, lease := range leases {
+		states = append(states, lease.IP.String()+" "+lease.BindingState)
+	}
+	expectedStates := []string{"10.0.0.5 free", "10.0.0.6 backup", "10.0.0.7 free"}
+	if !reflect.DeepEqual(expectedStates, states) {
+		t.Errorf("expected %v, got %v", expectedStates, states)
+	}
+}
+
+func TestLeaseScanner_error(t *testing.T) {
+	data := "lease 10.0.0.5 {\n}\nlease 10.0.0.6 {\n  ends soon;\n}\nlease 10.0.0.7 {\n}\n"
+	ls := NewLeaseScanner(strings.NewReader(data), Filename("dhcpd.leases"))
+	var count int
+	for ls.Next() {
+		count++
+	}
+	var pe *ParseError
+	if !errors.As(ls.Err(), &pe) || pe.Filename != "dhcpd.leases" || pe.Line != 4 {
+		t.Errorf("expected error at dhcpd.leases:4, got %v", ls.Err())
+	}
+	if count != 1 {
+		t.Errorf("expected 1 lease before the error, got %d", count)
+	}
+	if ls.Next() {
+		t.Error("expected Next() to keep returning false after an error")
+	}
+}
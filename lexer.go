@@ -1,9 +1,12 @@
 package iscdhcp
 
 import (
+	"bufio"
 	"fmt"
 	"io"
+	"net"
 	"regexp"
+	"strconv"
 	"strings"
 )
 
@@ -18,23 +21,75 @@ func (t token) String() string {
 
 var cidrRegexp = regexp.MustCompile(`^\d{1,3}\.\d{1,3}\.\d{1,3}\.\d{1,3}\/\d{1,2}$`)
 var ipAddrRegexp = regexp.MustCompile(`^\d{1,3}\.\d{1,3}\.\d{1,3}\.\d{1,3}$`)
+var numberRegexp = regexp.MustCompile(`^\d+$`)
 var macAddrRegexp = regexp.MustCompile(`^[a-fA-F0-9]{1,2}:[a-fA-F0-9]{1,2}:[a-fA-F0-9]{1,2}:[a-fA-F0-9]{1,2}:[a-fA-F0-9]{1,2}:[a-fA-F0-9]{1,2}$`)
+var hexStringRegexp = regexp.MustCompile(`^[a-fA-F0-9]{1,2}(:[a-fA-F0-9]{1,2})+$`)
+
+// IPv6 addresses take too many forms to validate with a regexp, so anything
+// matching these is also checked with net.ParseIP or net.ParseCIDR.
+var ip6AddrRegexp = regexp.MustCompile(`^[0-9a-f]*:[0-9a-f:.]*$`)
+var ip6CIDRRegexp = regexp.MustCompile(`^[0-9a-f]*:[0-9a-f:.]*\/\d{1,3}$`)
+var prefixLengthRegexp = regexp.MustCompile(`^\/\d{1,3}$`)
 
 var stringTokenMap = map[string]int{
 	// declarations
-	"group":   groupTok,
-	"host":    hostTok,
-	"subnet":  subnetTok,
-	"netmask": netmaskTok,
+	"class":          classTok,
+	"group":          groupTok,
+	"host":           hostTok,
+	"pool":           poolTok,
+	"range":          rangeTok,
+	"shared-network": sharedNetworkTok,
+	"subclass":       subclassTok,
+	"subnet":         subnetTok,
+	"netmask":        netmaskTok,
+	"prefix6":        prefix6Tok,
+	"range6":         range6Tok,
+	"subnet6":        subnet6Tok,
+	"temporary":      temporaryTok,
 	// parameters
-	"authoritative":       authoritativeTok,
-	"domain-name-servers": optDomainNameServersTok,
-	"ethernet":            ethernetTok,
-	"fixed-address":       fixedAddrTok,
-	"hardware":            hardwareTok,
-	"include":             includeTok,
-	"option":              optionTok,
-	"use-host-decl-names": useHostDeclNamesTok,
+	"adaptive-lease-time-threshold": adaptiveLeaseThresholdTok,
+	"always-broadcast":              alwaysBroadcastTok,
+	"always-reply-rfc1048":          alwaysReplyRFC1048Tok,
+	"authoritative":                 authoritativeTok,
+	"boot-unknown-clients":          bootUnknownClientsTok,
+	"code":                          codeTok,
+	"db-time-format":                dbTimeFormatTok,
+	"ddns-domainname":               ddnsDomainNameTok,
+	"ddns-hostname":                 ddnsHostNameTok,
+	"ddns-rev-domainname":           ddnsRevDomainNameTok,
+	"ddns-update-style":             ddnsUpdateStyleTok,
+	"ddns-updates":                  ddnsUpdatesTok,
+	"default-lease-time":            defaultLeaseTimeTok,
+	"delayed-ack":                   delayedAckTok,
+	"do-forward-updates":            doForwardUpdatesTok,
+	"dynamic-bootp":                 dynamicBootpTok,
+	"dynamic-bootp-lease-cutoff":    dynamicBootpLeaseCutoffTok,
+	"ethernet":                      ethernetTok,
+	"failover":                      failoverTok,
+	"fixed-address":                 fixedAddrTok,
+	"fixed-address6":                fixedAddr6Tok,
+	"fixed-prefix6":                 fixedPrefix6Tok,
+	"hardware":                      hardwareTok,
+	"include":                       includeTok,
+	"lease":                         leaseTok,
+	"limit":                         limitTok,
+	"match":                         matchTok,
+	"max-ack-delay":                 maxAckDelayTok,
+	"max-lease-time":                maxLeaseTimeTok,
+	"members":                       membersTok,
+	"min-lease-time":                minLeaseTimeTok,
+	"of":                            ofTok,
+	"option":                        optionTok,
+	"peer":                          peerTok,
+	"space":                         spaceTok,
+	"spawn":                         spawnTok,
+	"use-host-decl-names":           useHostDeclNamesTok,
+	"vendor-option-space":           vendorOptionSpaceTok,
+	"with":                          withTok,
+	// access control
+	"allow":  AccessAllow,
+	"deny":   AccessDeny,
+	"ignore": AccessIgnore,
 	// parameter states
 	"on":    stateTok,
 	"off":   stateTok,
@@ -52,6 +107,23 @@ var stringTokenMap = map[string]int{
 	"!=": BoolInequal,
 	"~=": BoolRegexMatch,
 	"~~": BoolRegexIMatch,
+	// data expressions
+	"binary-to-ascii":  binaryToASCIITok,
+	"client-state":     clientStateTok,
+	"concat":           concatTok,
+	"config-option":    configOptionTok,
+	"encode-int":       encodeIntTok,
+	"extract-int":      extractIntTok,
+	"host-decl-name":   hostDeclNameTok,
+	"lcase":            lcaseTok,
+	"lease-time":       leaseTimeTok,
+	"leased-address":   leasedAddressTok,
+	"packet":           packetTok,
+	"pick-first-value": pickFirstValueTok,
+	"reverse":          reverseTok,
+	"substring":        substringTok,
+	"suffix":           suffixTok,
+	"ucase":            ucaseTok,
 	// boolean simple operators
 	"exists": BoolExists,
 	"known":  BoolKnown,
@@ -67,35 +139,261 @@ const (
 	tokenTypeBlockEnd
 	tokenTypeSemicolon
 	tokenTypeComma
+	tokenTypeParenOpen
+	tokenTypeParenClose
 )
 
 func newLexer(r io.Reader) *lexer {
+	br, ok := r.(io.ByteReader)
+	if !ok {
+		br = bufio.NewReader(r)
+	}
 	t := &lexer{
-		dataStream: r,
+		dataStream: br,
 		scanner:    &scanner{},
+		pos:        Position{Line: 1, Column: 1},
+		options:    newOptionRegistry(),
 	}
 	t.scanner.init()
 	return t
 }
 
 type lexer struct {
-	dataStream      io.Reader
+	dataStream      io.ByteReader
 	scanner         *scanner
 	currentToken    token
 	wipToken        token
 	dirtyHackReturn []Statement
 	hasErrored      bool
 	err             error
+
+	// pos is the position of the next byte to be read from dataStream,
+	// wipStart the position of the first byte of wipToken, and tokenStart
+	// the position of the first byte of the token last returned by
+	// nextToken.
+	pos        Position
+	wipStart   Position
+	tokenStart Position
+	// currentStart is the position of l.currentToken, and lexed holds the
+	// code of every token returned to the parser, for use in error-message
+	// generation.
+	currentStart Position
+	lexed        []int
+
+	// positions is set by the Positions option. When it is set, lastEnd
+	// and prevEnd hold the positions immediately after the last two tokens
+	// returned to the parser, and parser is consulted to learn which of
+	// them ends the statement being reduced.
+	positions bool
+	parser    yyParser
+	lastEnd   Position
+	prevEnd   Position
+
+	// comments is set by the Comments option. pendingComments holds the
+	// comments skipped since the last token was returned to the parser,
+	// with "" marking a blank line between two of them, and pendingLine
+	// and pendingLast the lines the first and last of them were on. Once
+	// it's known which token they belong to they're moved to
+	// leadingComments, keyed by the offset of the token they precede, or
+	// trailingComments or openingComments, keyed by the offset immediately
+	// after the semicolon or brace they follow. A comment following the
+	// closing brace of a conditional's block which is followed by "elsif"
+	// or "else" is moved to branchComments, keyed by the offset of that
+	// keyword.
+	comments         bool
+	pendingComments  []string
+	pendingLine      int
+	pendingLast      int
+	leadingComments  map[int][]string
+	trailingComments map[int]string
+	openingComments  map[int]string
+	branchComments   map[int]string
+	sourceStatements []*SourceStatement
+
+	// inlineIncludes is set by the InlineIncludes option.
+	inlineIncludes bool
+	// include is set when decoding through an includeLoader, and loads the
+	// file an include statement names.
+	include func(name string) ([]Statement, error)
+
+	// options holds the option spaces and definitions declared so far.
+	options *optionRegistry
 }
 
 func (l *lexer) Error(s string) {
 	// This method is called by yyParser.Parse() when the yacc-generated
-	// parser hits a snag. It doesn't give us much context.
-	l.err = fmt.Errorf("error at (or just before) token %q: %s", l.currentToken.data, s)
+	// parser hits a snag, and by grammar actions which find a value they
+	// can't make sense of. Only the first error is reported; anything after
+	// it is likely a consequence.
+	if l.hasErrored {
+		return
+	}
+	pe := &ParseError{
+		Position: l.currentStart,
+		Token:    string(l.currentToken.data),
+		Msg:      s,
+	}
+	if strings.HasPrefix(s, "syntax error") {
+		// The token which caused a syntax error is the last one lexed;
+		// work out what could have been accepted in its place.
+		prefix := l.lexed
+		if len(prefix) != 0 {
+			prefix = prefix[:len(prefix)-1]
+		}
+		pe.Msg = "syntax error"
+		pe.Expected = expectedTokens(prefix)
+	}
+	l.err = pe
 	l.hasErrored = true
 }
 
+// advance updates l.pos to account for having read b.
+func (l *lexer) advance(b byte) {
+	l.pos.Offset++
+	if b == '\n' {
+		l.pos.Line++
+		l.pos.Column = 1
+	} else {
+		l.pos.Column++
+	}
+}
+
 func (l *lexer) Lex(lval *yySymType) int {
+	code := l.lex(lval)
+	if len(l.pendingComments) != 0 {
+		l.placeComments(code)
+	}
+	l.lexed = append(l.lexed, code)
+	lval.pos = l.currentStart
+	l.prevEnd = l.lastEnd
+	l.lastEnd = l.currentStart.after(l.currentToken.data)
+	return code
+}
+
+// statementEnd returns the position immediately after the statement the
+// parser has just recognized.
+func (l *lexer) statementEnd() Position {
+	// If the parser needed to look beyond the end of the statement to
+	// recognize it, the last token lexed doesn't belong to the statement.
+	if l.parser != nil && l.parser.Lookahead() >= 0 {
+		return l.prevEnd
+	}
+	return l.lastEnd
+}
+
+// sourceStatement is called by the parser as each Statement is recognized,
+// wrapping it in a SourceStatement if the Positions option is in effect.
+func sourceStatement(yylex yyLexer, statement Statement, start Position) Statement {
+	l, ok := yylex.(*lexer)
+	if !ok || !l.positions {
+		return statement
+	}
+	ss := &SourceStatement{
+		Statement: statement,
+		Start:     start,
+		End:       l.statementEnd(),
+	}
+	if l.comments {
+		ss.LeadingComments = l.leadingComments[start.Offset]
+		delete(l.leadingComments, start.Offset)
+		// The statements within this one's block have already claimed
+		// their opening comments, so any left within it is its own.
+		for offset, comment := range l.openingComments {
+			if offset > start.Offset && offset <= ss.End.Offset {
+				ss.OpeningComment = comment
+				delete(l.openingComments, offset)
+			}
+		}
+		l.sourceStatements = append(l.sourceStatements, ss)
+	}
+	return ss
+}
+
+// placeComments decides which token the pending comments belong to, given
+// that code is the token about to be returned to the parser.
+func (l *lexer) placeComments(code int) {
+	comments := l.pendingComments
+	l.pendingComments = nil
+
+	// A comment on the same line as the end of the previous statement is
+	// trailing it, and one on the same line as an opening brace stays with
+	// that. Statements end with either a semicolon or a closing brace,
+	// except that a conditional continues after its closing brace if it's
+	// followed by "elsif" or "else".
+	var prev int
+	if len(l.lexed) != 0 {
+		prev = l.lexed[len(l.lexed)-1]
+	}
+	if l.pendingLine == l.lastEnd.Line {
+		switch {
+		case prev == openBrace:
+			l.openingComments[l.lastEnd.Offset] = comments[0]
+			comments = comments[1:]
+		case prev == closeBrace && (code == ConditionElsif || code == ConditionElse):
+			l.branchComments[l.currentStart.Offset] = comments[0]
+			comments = comments[1:]
+		case prev == semicolon || prev == closeBrace:
+			l.trailingComments[l.lastEnd.Offset] = comments[0]
+			comments = comments[1:]
+		}
+	}
+	if len(comments) != 0 {
+		l.leadingComments[l.currentStart.Offset] = comments
+	}
+}
+
+// danglingComments is called by the parser on reaching a token which can't
+// begin a statement, returning any comments preceding that token as
+// CommentStatements.
+func danglingComments(yylex yyLexer, pos Position) []Statement {
+	l, ok := yylex.(*lexer)
+	if !ok || !l.comments {
+		return nil
+	}
+	var statements []Statement
+	for _, comment := range l.leadingComments[pos.Offset] {
+		statements = append(statements, CommentStatement(comment))
+	}
+	delete(l.leadingComments, pos.Offset)
+	return statements
+}
+
+// openingComment is called by the parser with the position of a block's
+// opening brace, returning any comment following it on the same line.
+func openingComment(yylex yyLexer, pos Position) string {
+	l, ok := yylex.(*lexer)
+	if !ok || !l.comments {
+		return ""
+	}
+	offset := pos.Offset + len("{")
+	comment := l.openingComments[offset]
+	delete(l.openingComments, offset)
+	return comment
+}
+
+// commentsBeforeBranch is called by the parser on reaching an "elsif" or "else" at
+// pos, returning any comment following the closing brace before it on the
+// same line, and the comments on the lines in between.
+func commentsBeforeBranch(yylex yyLexer, pos Position) (trailing string, leading []string) {
+	l, ok := yylex.(*lexer)
+	if !ok || !l.comments {
+		return "", nil
+	}
+	trailing, leading = l.branchComments[pos.Offset], l.leadingComments[pos.Offset]
+	delete(l.branchComments, pos.Offset)
+	delete(l.leadingComments, pos.Offset)
+	return trailing, leading
+}
+
+// attachTrailingComments is called once parsing is complete, by which point
+// the tokens following every statement have been lexed.
+func (l *lexer) attachTrailingComments() {
+	for _, ss := range l.sourceStatements {
+		ss.TrailingComment = l.trailingComments[ss.End.Offset]
+	}
+}
+
+func (l *lexer) lex(lval *yySymType) int {
 	for {
 		tok, err := l.nextToken()
 		if err != nil && err != io.EOF {
@@ -104,11 +402,27 @@ func (l *lexer) Lex(lval *yySymType) int {
 			return 0
 		}
 		if len(tok.data) == 0 {
+			l.currentToken = token{}
+			l.currentStart = l.pos
 			return 0
 		}
+		if tok.typ == tokenTypeComment && l.comments {
+			if len(l.pendingComments) == 0 {
+				l.pendingLine = l.tokenStart.Line
+			} else if l.tokenStart.Line > l.pendingLast+1 {
+				l.pendingComments = append(l.pendingComments, "")
+			}
+			l.pendingLast = l.tokenStart.Line
+			l.pendingComments = append(l.pendingComments, strings.TrimRight(string(tok.data), " \t\r"))
+			continue
+		}
+		if tok.typ == tokenTypeWhiteSpace || tok.typ == tokenTypeComment {
+			continue
+		}
 
 		// assign l.currentToken, for use in error-message generation
 		l.currentToken = tok
+		l.currentStart = l.tokenStart
 
 		txt := string(tok.data)
 		cmpTxt := strings.ToLower(txt)
@@ -117,18 +431,24 @@ func (l *lexer) Lex(lval *yySymType) int {
 		// Quick-check for types the scanner/tokenizer identify at a lower
 		// level.
 		switch tok.typ {
-		case tokenTypeWhiteSpace:
-			continue
 		case tokenTypeSemicolon:
 			return semicolon
 		case tokenTypeComma:
 			return comma
+		case tokenTypeParenOpen:
+			return openParen
+		case tokenTypeParenClose:
+			return closeParen
 		case tokenTypeBlockStart:
 			return openBrace
 		case tokenTypeBlockEnd:
 			return closeBrace
 		case tokenTypeString:
-			lval.str = strings.TrimPrefix(strings.TrimSuffix(txt, "\""), "\"")
+			str, err := unquoteString(strings.TrimPrefix(strings.TrimSuffix(txt, "\""), "\""))
+			if err != nil {
+				l.Error(err.Error())
+			}
+			lval.str = str
 			return stringConst
 		}
 
@@ -142,14 +462,32 @@ func (l *lexer) Lex(lval *yySymType) int {
 		if macAddrRegexp.MatchString(cmpTxt) {
 			lval.str = txt
 			return macAddr
+		} else if hexStringRegexp.MatchString(cmpTxt) {
+			lval.str = txt
+			return hexString
 		} else if cidrRegexp.MatchString(cmpTxt) {
 			lval.str = txt
 			return cidr
 		} else if ipAddrRegexp.MatchString(cmpTxt) {
 			lval.str = txt
 			return ipAddr
-		} else if len(tok.data) == 1 {
-			return int(tok.data[0])
+		} else if ip6AddrRegexp.MatchString(cmpTxt) && net.ParseIP(cmpTxt) != nil {
+			lval.str = txt
+			return ip6Addr
+		} else if ip6CIDRRegexp.MatchString(cmpTxt) && isCIDR(cmpTxt) {
+			lval.str = txt
+			return ip6CIDR
+		} else if prefixLengthRegexp.MatchString(cmpTxt) {
+			lval.str = txt
+			lval.num, _ = strconv.Atoi(cmpTxt[1:])
+			return prefixLength
+		} else if numberRegexp.MatchString(cmpTxt) {
+			num, err := strconv.Atoi(cmpTxt)
+			if err == nil {
+				lval.str = txt
+				lval.num = num
+				return number
+			}
 		}
 
 		// Everything else is just a "WORD"
@@ -158,28 +496,39 @@ func (l *lexer) Lex(lval *yySymType) int {
 	}
 }
 
+func isCIDR(s string) bool {
+	_, _, err := net.ParseCIDR(s)
+	return err == nil
+}
+
 func (l *lexer) nextToken() (token, error) {
 	if l.scanner == nil {
 		return token{}, contextError("call on uninitialized lexer")
 	}
 
 	var retToken token
-	workbuf := make([]byte, 1)
+	var retStart Position
 	var readErr error
 
 	for {
-		var bytesRead int
-		bytesRead, readErr = l.dataStream.Read(workbuf)
-		if bytesRead != 1 && readErr == io.EOF {
-			// If we got an error or io.EOF, we may've still received data that
-			// we need to process. If we didn't receive data though, return
-			// immediately.
-			return token{}, readErr
+		var b byte
+		b, readErr = l.dataStream.ReadByte()
+		if readErr == io.EOF {
+			// Hand back whatever token we were still working on.
+			retToken, retStart = l.wipToken, l.wipStart
+			l.wipToken = token{}
+			l.tokenStart = retStart
+			return retToken, readErr
+		}
+
+		if readErr != nil {
+			break
 		}
 
 		// Ask the scanner whether whether the byte we received is the boundary
 		// of a new token, and if so what type.
-		b := workbuf[0]
+		bytePos := l.pos
+		l.advance(b)
 		code, err := l.scanner.step(b)
 		if err != nil {
 			return token{}, err
@@ -197,85 +546,113 @@ func (l *lexer) nextToken() (token, error) {
 			// that token, but save the first byte of this new identifier in
 			// l.wipToken
 			if len(l.wipToken.data) != 0 {
-				retToken = l.wipToken
+				retToken, retStart = l.wipToken, l.wipStart
 			}
 			l.wipToken = token{
 				typ:  tokenTypeIdentifier,
 				data: []byte{b},
 			}
+			l.wipStart = bytePos
 		case codeIdentifierEnd:
 			fallthrough
 		case codeWhitespace:
 			if l.wipToken.typ != tokenTypeWhiteSpace {
 				if len(l.wipToken.data) != 0 {
-					retToken = l.wipToken
+					retToken, retStart = l.wipToken, l.wipStart
 					l.wipToken = token{
 						typ:  tokenTypeWhiteSpace,
 						data: []byte{b},
 					}
+					l.wipStart = bytePos
 				} else {
 					l.wipToken = token{
 						typ:  tokenTypeWhiteSpace,
 						data: []byte{b},
 					}
+					l.wipStart = bytePos
 				}
 			} else {
 				l.wipToken.data = append(l.wipToken.data, b)
 			}
 		case codeBlockBegin:
 			if len(l.wipToken.data) != 0 {
-				retToken = l.wipToken
+				retToken, retStart = l.wipToken, l.wipStart
 			}
 			l.wipToken = token{
 				typ:  tokenTypeBlockStart,
 				data: []byte{b},
 			}
+			l.wipStart = bytePos
 		case codeBlockEnd:
 			if len(l.wipToken.data) != 0 {
-				retToken = l.wipToken
+				retToken, retStart = l.wipToken, l.wipStart
 			}
 			l.wipToken = token{
 				typ:  tokenTypeBlockEnd,
 				data: []byte{b},
 			}
+			l.wipStart = bytePos
 		case codeSemicolon:
 			if len(l.wipToken.data) != 0 {
-				retToken = l.wipToken
+				retToken, retStart = l.wipToken, l.wipStart
 			}
 			l.wipToken = token{
 				typ:  tokenTypeSemicolon,
 				data: []byte{b},
 			}
+			l.wipStart = bytePos
 		case codeComma:
 			if len(l.wipToken.data) != 0 {
-				retToken = l.wipToken
+				retToken, retStart = l.wipToken, l.wipStart
 			}
 			l.wipToken = token{
 				typ:  tokenTypeComma,
 				data: []byte{b},
 			}
+			l.wipStart = bytePos
+		case codeParenOpen:
+			if len(l.wipToken.data) != 0 {
+				retToken, retStart = l.wipToken, l.wipStart
+			}
+			l.wipToken = token{
+				typ:  tokenTypeParenOpen,
+				data: []byte{b},
+			}
+			l.wipStart = bytePos
+		case codeParenClose:
+			if len(l.wipToken.data) != 0 {
+				retToken, retStart = l.wipToken, l.wipStart
+			}
+			l.wipToken = token{
+				typ:  tokenTypeParenClose,
+				data: []byte{b},
+			}
+			l.wipStart = bytePos
 		case codeCommentBegin:
 			if len(l.wipToken.data) != 0 {
-				retToken = l.wipToken
+				retToken, retStart = l.wipToken, l.wipStart
 			}
 			l.wipToken = token{
 				typ:  tokenTypeComment,
 				data: []byte{b},
 			}
+			l.wipStart = bytePos
 		case codeCommentEnd:
-			retToken = l.wipToken
+			retToken, retStart = l.wipToken, l.wipStart
 			l.wipToken = token{
 				typ:  tokenTypeWhiteSpace,
 				data: []byte{b},
 			}
+			l.wipStart = bytePos
 		case codeStringBegin:
 			if len(l.wipToken.data) != 0 {
-				retToken = l.wipToken
+				retToken, retStart = l.wipToken, l.wipStart
 			}
 			l.wipToken = token{
 				typ:  tokenTypeString,
 				data: []byte{b},
 			}
+			l.wipStart = bytePos
 		case codeStringEnd:
 			// This code is somewhat unique, since we receive it *on* the
 			// boundary of a token whose length isn't exactly 1, rather than
@@ -300,16 +677,10 @@ func (l *lexer) nextToken() (token, error) {
 		}
 	}
 
-	if readErr != nil && readErr != io.EOF {
-		return token{}, contextErrorf("dataStream.Read(): %s", readErr.Error())
-	}
-
-	// If we dropped off the end of the data stream, we won't have
-	// affirmatively assigned a value to retToken, but t.currentToken should
-	// contain something.
-	if len(retToken.data) == 0 {
-		retToken = l.wipToken
+	if readErr != nil {
+		return token{}, contextErrorf("dataStream.ReadByte(): %s", readErr.Error())
 	}
 
-	return retToken, readErr
+	l.tokenStart = retStart
+	return retToken, nil
 }
@@ -2,6 +2,7 @@ package iscdhcp
 
 import (
 	"bytes"
+	"fmt"
 	"io"
 	"reflect"
 	"testing"
@@ -99,3 +100,181 @@ func TestLexer_nextToken(t *testing.T) {
 		}
 	}
 }
+
+func TestLexer_nextTokenDelimiters(t *testing.T) {
+	// parentheses and commas delimit identifiers and strings alike
+	data := `concat("a",substring(hardware,1, 6))}`
+
+	l := newLexer(bytes.NewReader([]byte(data)))
+	var tokens []token
+	for {
+		tok, err := l.nextToken()
+		if err != nil && err != io.EOF {
+			t.Fatalf("unexpected error: %s", err)
+		}
+		if len(tok.data) != 0 {
+			tokens = append(tokens, tok)
+		}
+		if err == io.EOF {
+			break
+		}
+	}
+
+	expected := []token{
+		{[]byte("concat"), tokenTypeIdentifier},
+		{[]byte("("), tokenTypeParenOpen},
+		{[]byte("\"a\""), tokenTypeString},
+		{[]byte(","), tokenTypeComma},
+		{[]byte("substring"), tokenTypeIdentifier},
+		{[]byte("("), tokenTypeParenOpen},
+		{[]byte("hardware"), tokenTypeIdentifier},
+		{[]byte(","), tokenTypeComma},
+		{[]byte("1"), tokenTypeIdentifier},
+		{[]byte(","), tokenTypeComma},
+		{[]byte(" "), tokenTypeWhiteSpace},
+		{[]byte("6"), tokenTypeIdentifier},
+		{[]byte(")"), tokenTypeParenClose},
+		{[]byte(")"), tokenTypeParenClose},
+		{[]byte("}"), tokenTypeBlockEnd},
+	}
+
+	if !reflect.DeepEqual(tokens, expected) {
+		t.Errorf("expected %v, got %v", expected, tokens)
+	}
+}
+
+func TestLexer_nextTokenStringEscapes(t *testing.T) {
+	// an escaped quote doesn't end a string
+	data := `uid "\001\"3DU\\";`
+
+	l := newLexer(bytes.NewReader([]byte(data)))
+	var tokens []token
+	for {
+		tok, err := l.nextToken()
+		if err != nil && err != io.EOF {
+			t.Fatalf("unexpected error: %s", err)
+		}
+		if len(tok.data) != 0 && tok.typ != tokenTypeWhiteSpace {
+			tokens = append(tokens, tok)
+		}
+		if err == io.EOF {
+			break
+		}
+	}
+
+	expected := []token{
+		{[]byte("uid"), tokenTypeIdentifier},
+		{[]byte(`"\001\"3DU\\"`), tokenTypeString},
+		{[]byte(";"), tokenTypeSemicolon},
+	}
+	if !reflect.DeepEqual(expected, tokens) {
+		t.Errorf("expected %v, got %v", expected, tokens)
+	}
+}
+
+// syntheticConfig returns a config of roughly the given size in bytes,
+// made up of subnets full of host declarations.
+func syntheticConfig(size int) []byte {
+	var buf bytes.Buffer
+	for i := 0; buf.Len() < size; i++ {
+		fmt.Fprintf(&buf, "subnet 10.%d.%d.0 netmask 255.255.255.0 {\n", i/256%256, i%256)
+		buf.WriteString("    option domain-name-servers 10.0.0.1, 10.0.0.2;\n")
+		buf.WriteString("    default-lease-time 600; # ten minutes\n")
+		for j := 1; j < 50; j++ {
+			fmt.Fprintf(&buf, "    host host-%d-%d {\n", i, j)
+			fmt.Fprintf(&buf, "        hardware ethernet 0:1:2:%x:%x:%x;\n", i/256%256, i%256, j)
+			fmt.Fprintf(&buf, "        fixed-address 10.%d.%d.%d;\n", i/256%256, i%256, j)
+			buf.WriteString("    }\n")
+		}
+		buf.WriteString("    if option vendor-class-identifier = \"PXEClient\" {\n")
+		buf.WriteString("        max-lease-time 60;\n")
+		buf.WriteString("    }\n")
+		buf.WriteString("}\n")
+	}
+	return buf.Bytes()
+}
+
+func BenchmarkLexer_nextToken(b *testing.B) {
+	data := syntheticConfig(5 << 20)
+	b.SetBytes(int64(len(data)))
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		l := newLexer(bytes.NewReader(data))
+		for {
+			_, err := l.nextToken()
+			if err == io.EOF {
+				break
+			}
+			if err != nil {
+				b.Fatalf("unexpected error: %s", err)
+			}
+		}
+	}
+}
+
+func BenchmarkDecode(b *testing.B) {
+	data := syntheticConfig(5 << 20)
+	b.SetBytes(int64(len(data)))
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		if _, err := Decode(bytes.NewReader(data)); err != nil {
+			b.Fatalf("unexpected error: %s", err)
+		}
+	}
+}
+
+func TestScanTable_deterministic(t *testing.T) {
+	// When more than one transition matches a byte the table must always
+	// choose the same one, however many times it's built.
+	for i := 0; i < 20; i++ {
+		if !reflect.DeepEqual(buildScanTable(), scanTable) {
+			t.Fatalf("build %d differs from scanTable", i)
+		}
+	}
+}
+
+// tokenize returns every token nextToken finds in data, or an error.
+func tokenize(data []byte) ([]token, error) {
+	l := newLexer(bytes.NewReader(data))
+	var tokens []token
+	for {
+		tok, err := l.nextToken()
+		if err != nil && err != io.EOF {
+			return nil, err
+		}
+		if len(tok.data) != 0 {
+			tokens = append(tokens, tok)
+		}
+		if err == io.EOF {
+			return tokens, nil
+		}
+	}
+}
+
+func FuzzLexer_nextToken(f *testing.F) {
+	f.Add([]byte(`host foo { hardware ethernet 0:1:2:3:4:5; } # comment`))
+	f.Add([]byte(`if option foo = "a\"b" and (exists bar) { range6 2001:db8::/64 temporary; }`))
+	f.Add([]byte("prefix6 2001:db8:: 2001:db8:ff:: /56;\n\"unterminated"))
+	f.Fuzz(func(t *testing.T, data []byte) {
+		first, err := tokenize(data)
+		if err != nil {
+			t.Skip()
+		}
+		second, err := tokenize(data)
+		if err != nil {
+			t.Fatalf("second run failed where the first succeeded: %s", err)
+		}
+		if !reflect.DeepEqual(first, second) {
+			t.Fatalf("tokenization differs between runs: %v != %v", first, second)
+		}
+
+		// Every byte of the input belongs to exactly one token.
+		var joined []byte
+		for _, tok := range first {
+			joined = append(joined, tok.data...)
+		}
+		if !bytes.Equal(joined, data) {
+			t.Fatalf("tokens %v don't add up to the input %q", first, data)
+		}
+	})
+}
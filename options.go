@@ -0,0 +1,701 @@
+package iscdhcp
+
+import (
+	"fmt"
+	"net"
+	"strconv"
+	"strings"
+)
+
+// An OptionDefinition describes a DHCP option: the option space it belongs
+// to, its name and code within that space, and the type of its value.
+// See "DEFINING NEW OPTIONS" in dhcp-options(5)
+type OptionDefinition struct {
+	Space string
+	Name  string
+	Code  int
+	Type  OptionType
+}
+
+// An OptionType describes the type of an option's value. Fields holds one
+// entry for a simple type, or one per field of a record type. If Array is
+// set, the value is a list of any number of elements of that type.
+type OptionType struct {
+	Array  bool
+	Fields []OptionField
+}
+
+// String returns the type in the form used by dhcp-options(5), e.g.
+// "array of ip-address" or "{ ip-address, unsigned integer 8 }".
+func (ot OptionType) String() string {
+	var s string
+	if ot.Array {
+		s = "array of "
+	}
+	if len(ot.Fields) == 1 {
+		return s + ot.Fields[0].String()
+	}
+	fields := make([]string, len(ot.Fields))
+	for i, field := range ot.Fields {
+		fields[i] = field.String()
+	}
+	return s + "{ " + strings.Join(fields, ", ") + " }"
+}
+
+// An OptionFieldKind is the kind of an OptionField. Each kind's value is
+// held in an OptionStatement as a particular Go type, given below.
+type OptionFieldKind int
+
+// Kinds of OptionField
+const (
+	// OptionFieldIPAddress is an IPv4 address, held as a net.IP.
+	OptionFieldIPAddress OptionFieldKind = iota
+	// OptionFieldIP6Address is an IPv6 address, held as a net.IP.
+	OptionFieldIP6Address
+	// OptionFieldText is an NVT ASCII string, held as a string.
+	OptionFieldText
+	// OptionFieldString is arbitrary data, given either as a quoted string
+	// or as colon-separated hexadecimal octets, and held as a
+	// StringConstTerm or HexStringTerm accordingly.
+	OptionFieldString
+	// OptionFieldBoolean is a flag, held as a bool.
+	OptionFieldBoolean
+	// OptionFieldInteger is an integer of the OptionField's Width and
+	// signedness, held as an int.
+	OptionFieldInteger
+	// OptionFieldDomainName is a single domain name, held as a string.
+	OptionFieldDomainName
+	// OptionFieldDomainList is a comma-separated list of domain names,
+	// held as a []string. It may only be the sole field of a type which
+	// isn't an array.
+	OptionFieldDomainList
+	// OptionFieldDestinationDescriptor is an IPv4 network, as used by
+	// classless static routes, held as a *net.IPNet.
+	OptionFieldDestinationDescriptor
+	// OptionFieldEncapsulation is the options of the OptionField's Space,
+	// encapsulated within this one. It may only be the last field of a
+	// type which isn't an array. A value given for it directly is held as
+	// for an OptionFieldString.
+	OptionFieldEncapsulation
+)
+
+// An OptionField is a simple type, or a field within a record type.
+type OptionField struct {
+	Kind OptionFieldKind
+	// Width is the size in bits of an OptionFieldInteger: 8, 16 or 32.
+	Width int
+	// Signed is set for a signed OptionFieldInteger.
+	Signed bool
+	// Compressed is set for an OptionFieldDomainList whose wire format uses
+	// DNS name compression.
+	Compressed bool
+	// Space is the option space encapsulated by an
+	// OptionFieldEncapsulation.
+	Space string
+}
+
+var optionFieldKindStrings = map[OptionFieldKind]string{
+	OptionFieldIPAddress:             "ip-address",
+	OptionFieldIP6Address:            "ip6-address",
+	OptionFieldText:                  "text",
+	OptionFieldString:                "string",
+	OptionFieldBoolean:               "boolean",
+	OptionFieldDomainName:            "domain-name",
+	OptionFieldDomainList:            "domain-list",
+	OptionFieldDestinationDescriptor: "destination-descriptor",
+}
+
+func (of OptionField) String() string {
+	switch {
+	case of.Kind == OptionFieldInteger && of.Signed:
+		return "signed integer " + strconv.Itoa(of.Width)
+	case of.Kind == OptionFieldInteger:
+		return "unsigned integer " + strconv.Itoa(of.Width)
+	case of.Kind == OptionFieldDomainList && of.Compressed:
+		return "domain-list compressed"
+	case of.Kind == OptionFieldEncapsulation:
+		return "encapsulate " + of.Space
+	}
+	return optionFieldKindStrings[of.Kind]
+}
+
+// parseOptionType interprets the words of a type in the form used by
+// dhcp-options(5), with braces and commas as words of their own.
+func parseOptionType(words []string) (OptionType, error) {
+	var ot OptionType
+	if len(words) >= 2 && words[0] == "array" && words[1] == "of" {
+		ot.Array = true
+		words = words[2:]
+	}
+
+	record := len(words) != 0 && words[0] == "{"
+	if record {
+		if words[len(words)-1] != "}" {
+			return ot, fmt.Errorf("unterminated record type")
+		}
+		words = words[1 : len(words)-1]
+	}
+	for len(words) != 0 {
+		field, rest, err := parseOptionField(words)
+		if err != nil {
+			return ot, err
+		}
+		ot.Fields = append(ot.Fields, field)
+		words = rest
+		if len(words) != 0 {
+			if !record || words[0] != "," {
+				return ot, fmt.Errorf("unexpected %q in type", words[0])
+			}
+			words = words[1:]
+		}
+	}
+
+	switch {
+	case len(ot.Fields) == 0:
+		return ot, fmt.Errorf("missing type")
+	case len(ot.Fields) == 1 && record:
+		return ot, fmt.Errorf("record type needs more than one field")
+	}
+	for i, field := range ot.Fields {
+		if field.Kind == OptionFieldDomainList && (ot.Array || len(ot.Fields) != 1) {
+			return ot, fmt.Errorf("domain-list can't be used within an array or record")
+		}
+		if field.Kind == OptionFieldEncapsulation && (ot.Array || i != len(ot.Fields)-1) {
+			return ot, fmt.Errorf("encapsulate can only be the last field of a type which isn't an array")
+		}
+	}
+	return ot, nil
+}
+
+// parseOptionField interprets the first field of a type, returning it and
+// the words which follow it.
+func parseOptionField(words []string) (OptionField, []string, error) {
+	if words[0] == "encapsulate" {
+		if len(words) < 2 || !isOptionName(words[1]) {
+			return OptionField{}, nil, fmt.Errorf("encapsulate needs an option space name")
+		}
+		return OptionField{Kind: OptionFieldEncapsulation, Space: words[1]}, words[2:], nil
+	}
+	for kind, s := range optionFieldKindStrings {
+		if words[0] == s {
+			field := OptionField{Kind: kind}
+			words = words[1:]
+			if kind == OptionFieldDomainList && len(words) != 0 && words[0] == "compressed" {
+				field.Compressed = true
+				words = words[1:]
+			}
+			return field, words, nil
+		}
+	}
+
+	// integers are "[signed|unsigned] integer <width>"
+	field := OptionField{Kind: OptionFieldInteger, Signed: true}
+	switch words[0] {
+	case "unsigned":
+		field.Signed = false
+		fallthrough
+	case "signed":
+		words = words[1:]
+	}
+	if len(words) < 2 || words[0] != "integer" {
+		return field, nil, fmt.Errorf("unknown type %q", strings.Join(words, " "))
+	}
+	width, err := strconv.Atoi(words[1])
+	if err != nil || (width != 8 && width != 16 && width != 32) {
+		return field, nil, fmt.Errorf("invalid integer width %q", words[1])
+	}
+	field.Width = width
+	return field, words[2:], nil
+}
+
+// optionTypeWords splits the text of a type into the words expected by
+// parseOptionType.
+func optionTypeWords(s string) []string {
+	for _, punct := range []string{"{", "}", ","} {
+		s = strings.Replace(s, punct, " "+punct+" ", -1)
+	}
+	return strings.Fields(s)
+}
+
+// standardOptions lists the options of the "dhcp" option space which dhcpd
+// knows without being told, with their types as given in dhcp-options(5).
+var standardOptions = []struct {
+	name string
+	code int
+	typ  string
+}{
+	{"subnet-mask", 1, "ip-address"},
+	{"time-offset", 2, "signed integer 32"},
+	{"routers", 3, "array of ip-address"},
+	{"time-servers", 4, "array of ip-address"},
+	{"ien116-name-servers", 5, "array of ip-address"},
+	{"domain-name-servers", 6, "array of ip-address"},
+	{"log-servers", 7, "array of ip-address"},
+	{"cookie-servers", 8, "array of ip-address"},
+	{"lpr-servers", 9, "array of ip-address"},
+	{"impress-servers", 10, "array of ip-address"},
+	{"resource-location-servers", 11, "array of ip-address"},
+	{"host-name", 12, "text"},
+	{"boot-size", 13, "unsigned integer 16"},
+	{"merit-dump", 14, "text"},
+	{"domain-name", 15, "text"},
+	{"swap-server", 16, "ip-address"},
+	{"root-path", 17, "text"},
+	{"extensions-path", 18, "text"},
+	{"ip-forwarding", 19, "boolean"},
+	{"non-local-source-routing", 20, "boolean"},
+	{"policy-filter", 21, "array of { ip-address, ip-address }"},
+	{"max-dgram-reassembly", 22, "unsigned integer 16"},
+	{"default-ip-ttl", 23, "unsigned integer 8"},
+	{"path-mtu-aging-timeout", 24, "unsigned integer 32"},
+	{"path-mtu-plateau-table", 25, "array of unsigned integer 16"},
+	{"interface-mtu", 26, "unsigned integer 16"},
+	{"all-subnets-local", 27, "boolean"},
+	{"broadcast-address", 28, "ip-address"},
+	{"perform-mask-discovery", 29, "boolean"},
+	{"mask-supplier", 30, "boolean"},
+	{"router-discovery", 31, "boolean"},
+	{"router-solicitation-address", 32, "ip-address"},
+	{"static-routes", 33, "array of { ip-address, ip-address }"},
+	{"trailer-encapsulation", 34, "boolean"},
+	{"arp-cache-timeout", 35, "unsigned integer 32"},
+	{"ieee802-3-encapsulation", 36, "boolean"},
+	{"default-tcp-ttl", 37, "unsigned integer 8"},
+	{"tcp-keepalive-interval", 38, "unsigned integer 32"},
+	{"tcp-keepalive-garbage", 39, "boolean"},
+	{"nis-domain", 40, "text"},
+	{"nis-servers", 41, "array of ip-address"},
+	{"ntp-servers", 42, "array of ip-address"},
+	{"vendor-encapsulated-options", 43, "string"},
+	{"netbios-name-servers", 44, "array of ip-address"},
+	{"netbios-dd-server", 45, "array of ip-address"},
+	{"netbios-node-type", 46, "unsigned integer 8"},
+	{"netbios-scope", 47, "text"},
+	{"font-servers", 48, "array of ip-address"},
+	{"x-display-manager", 49, "array of ip-address"},
+	{"dhcp-requested-address", 50, "ip-address"},
+	{"dhcp-lease-time", 51, "unsigned integer 32"},
+	{"dhcp-option-overload", 52, "unsigned integer 8"},
+	{"dhcp-message-type", 53, "unsigned integer 8"},
+	{"dhcp-server-identifier", 54, "ip-address"},
+	{"dhcp-parameter-request-list", 55, "array of unsigned integer 8"},
+	{"dhcp-message", 56, "text"},
+	{"dhcp-max-message-size", 57, "unsigned integer 16"},
+	{"dhcp-renewal-time", 58, "unsigned integer 32"},
+	{"dhcp-rebinding-time", 59, "unsigned integer 32"},
+	{"vendor-class-identifier", 60, "string"},
+	{"dhcp-client-identifier", 61, "string"},
+	{"nwip-domain", 62, "string"},
+	{"nisplus-domain", 64, "text"},
+	{"nisplus-servers", 65, "array of ip-address"},
+	{"tftp-server-name", 66, "text"},
+	{"bootfile-name", 67, "text"},
+	{"mobile-ip-home-agent", 68, "array of ip-address"},
+	{"smtp-server", 69, "array of ip-address"},
+	{"pop-server", 70, "array of ip-address"},
+	{"nntp-server", 71, "array of ip-address"},
+	{"www-server", 72, "array of ip-address"},
+	{"finger-server", 73, "array of ip-address"},
+	{"irc-server", 74, "array of ip-address"},
+	{"streettalk-server", 75, "array of ip-address"},
+	{"streettalk-directory-assistance-server", 76, "array of ip-address"},
+	{"user-class", 77, "text"},
+	{"nds-servers", 85, "array of ip-address"},
+	{"nds-tree-name", 86, "text"},
+	{"nds-context", 87, "text"},
+	{"bcms-controller-names", 88, "domain-list"},
+	{"bcms-controller-address", 89, "array of ip-address"},
+	{"client-last-transaction-time", 91, "unsigned integer 32"},
+	{"associated-ip", 92, "array of ip-address"},
+	{"uap-servers", 98, "text"},
+	{"pcode", 100, "text"},
+	{"tcode", 101, "text"},
+	{"default-url", 114, "text"},
+	{"auto-config", 116, "unsigned integer 8"},
+	{"name-service-search", 117, "array of unsigned integer 16"},
+	{"subnet-selection", 118, "ip-address"},
+	{"domain-search", 119, "domain-list compressed"},
+	{"classless-static-routes", 121, "array of { destination-descriptor, ip-address }"},
+	{"vivco", 124, "string"},
+	{"vivso", 125, "string"},
+}
+
+// standardOptionsByName indexes the definitions in standardOptions.
+var standardOptionsByName = func() map[string]OptionDefinition {
+	byName := make(map[string]OptionDefinition)
+	for _, so := range standardOptions {
+		ot, err := parseOptionType(optionTypeWords(so.typ))
+		if err != nil {
+			panic(fmt.Sprintf("standard option %q: %s", so.name, err))
+		}
+		byName[so.name] = OptionDefinition{
+			Space: "dhcp",
+			Name:  so.name,
+			Code:  so.code,
+			Type:  ot,
+		}
+	}
+	return byName
+}()
+
+// LookupOption returns the definition of the named standard DHCPv4 option.
+func LookupOption(name string) (OptionDefinition, bool) {
+	def, found := standardOptionsByName[name]
+	return def, found
+}
+
+// QualifiedName returns the name by which the option is referred to in
+// config text: its Name, prefixed with its Space and a dot unless that's the
+// "dhcp" space.
+func (od OptionDefinition) QualifiedName() string {
+	if od.Space == "" || od.Space == "dhcp" {
+		return od.Name
+	}
+	return od.Space + "." + od.Name
+}
+
+// isOptionName reports whether s is usable as the name of an option or an
+// option space.
+func isOptionName(s string) bool {
+	return s != "" && !strings.ContainsAny(s, "{},;\"\\")
+}
+
+// An optionRegistry holds the option spaces and option definitions declared
+// in the text being decoded, which add to or replace the standard ones.
+type optionRegistry struct {
+	spaces map[string]OptionSpaceStatement
+	defs   map[string]OptionDefinition
+}
+
+func newOptionRegistry() *optionRegistry {
+	return &optionRegistry{
+		spaces: make(map[string]OptionSpaceStatement),
+		defs:   make(map[string]OptionDefinition),
+	}
+}
+
+// lookup returns the definition of the option with the given qualified name.
+func (or *optionRegistry) lookup(name string) (OptionDefinition, bool) {
+	name = strings.TrimPrefix(name, "dhcp.")
+	if def, found := or.defs[name]; found {
+		return def, true
+	}
+	return LookupOption(name)
+}
+
+// defineSpace registers an option space.
+func (or *optionRegistry) defineSpace(oss OptionSpaceStatement) {
+	or.spaces[oss.Name] = oss
+}
+
+// define registers an option definition, whose space must already be known.
+func (or *optionRegistry) define(def OptionDefinition) error {
+	space, found := or.spaces[def.Space]
+	if !found && def.Space != "dhcp" {
+		return fmt.Errorf("no option space named %q", def.Space)
+	}
+	// codes 0 and 255 are the pad and end options, where codes are a byte
+	maxCode := 254
+	if space.CodeWidth > 1 {
+		maxCode = 1<<uint(8*space.CodeWidth) - 1
+	}
+	if def.Code < 1 || def.Code > maxCode {
+		return fmt.Errorf("code %d out of range for option space %q", def.Code, def.Space)
+	}
+	for _, field := range def.Type.Fields {
+		if _, found := or.spaces[field.Space]; field.Kind == OptionFieldEncapsulation && !found {
+			return fmt.Errorf("no option space named %q", field.Space)
+		}
+	}
+	or.defs[def.QualifiedName()] = def
+	return nil
+}
+
+// An OptionStatement represents an option parameter, which sets the value
+// of a DHCP option.
+//
+// Values holds one entry per element of the option's value if its type is
+// an array, or a single entry otherwise. Each entry holds one value per
+// field of the type, of the Go type given by the field's OptionFieldKind.
+// For example, a "routers" option is written:
+//
+//	OptionStatement{
+//		Name:   "routers",
+//		Values: [][]interface{}{{net.ParseIP("10.0.0.1")}, {net.ParseIP("10.0.0.2")}},
+//	}
+//
+// See dhcp-options(5)
+type OptionStatement struct {
+	Name   string
+	Values [][]interface{}
+}
+
+// IndentedString implements the method of the same name in the Statement interface
+func (os OptionStatement) IndentedString(prefix string) string {
+	elements := make([]string, len(os.Values))
+	for i, fields := range os.Values {
+		fieldStrings := make([]string, len(fields))
+		for j, field := range fields {
+			fieldStrings[j] = optionFieldString(field)
+		}
+		elements[i] = strings.Join(fieldStrings, " ")
+	}
+	return prefix + "option " + os.Name + " " + strings.Join(elements, ", ") + ";\n"
+}
+
+func optionFieldString(field interface{}) string {
+	switch v := field.(type) {
+	case string:
+		return quoteString(v)
+	case []string:
+		quoted := make([]string, len(v))
+		for i, s := range v {
+			quoted[i] = quoteString(s)
+		}
+		return strings.Join(quoted, ", ")
+	case bool:
+		if v {
+			return "on"
+		}
+		return "off"
+	case int:
+		return strconv.Itoa(v)
+	case fmt.Stringer:
+		return v.String()
+	}
+	return fmt.Sprint(field)
+}
+
+// An optionToken is a token found in the value of an option parameter.
+type optionToken struct {
+	code int
+	str  string
+}
+
+// decodeOptionValues interprets the tokens of an option's value according
+// to its type.
+func decodeOptionValues(ot OptionType, tokens []optionToken) ([][]interface{}, error) {
+	if ot.Fields[0].Kind == OptionFieldDomainList {
+		var names []string
+		for i, tok := range tokens {
+			if i%2 == 1 {
+				if tok.code != comma {
+					return nil, fmt.Errorf("expected \",\" but found %q", tok.str)
+				}
+				continue
+			}
+			if tok.code != stringConst {
+				return nil, fmt.Errorf("expected domain name but found %q", tok.str)
+			}
+			names = append(names, tok.str)
+		}
+		if len(names) == 0 || len(tokens)%2 == 0 {
+			return nil, fmt.Errorf("expected domain name")
+		}
+		return [][]interface{}{{names}}, nil
+	}
+
+	// split the tokens into array elements
+	var elements [][]optionToken
+	var element []optionToken
+	for _, tok := range tokens {
+		if tok.code == comma {
+			elements = append(elements, element)
+			element = nil
+			continue
+		}
+		element = append(element, tok)
+	}
+	elements = append(elements, element)
+	if !ot.Array && len(elements) > 1 {
+		return nil, fmt.Errorf("expected a single value")
+	}
+
+	values := make([][]interface{}, len(elements))
+	for i, element := range elements {
+		if len(element) != len(ot.Fields) {
+			return nil, fmt.Errorf("expected %d field(s) in value %d but found %d", len(ot.Fields), i+1, len(element))
+		}
+		values[i] = make([]interface{}, len(element))
+		for j, tok := range element {
+			v, err := decodeOptionField(ot.Fields[j], tok)
+			if err != nil {
+				return nil, err
+			}
+			values[i][j] = v
+		}
+	}
+	return values, nil
+}
+
+func decodeOptionField(of OptionField, tok optionToken) (interface{}, error) {
+	switch of.Kind {
+	case OptionFieldIPAddress:
+		if tok.code == ipAddr {
+			if ip := net.ParseIP(tok.str); ip != nil {
+				return ip, nil
+			}
+		}
+	case OptionFieldIP6Address:
+		if tok.code == ip6Addr || tok.code == hexString {
+			if ip := net.ParseIP(tok.str); ip != nil {
+				return ip, nil
+			}
+		}
+	case OptionFieldText, OptionFieldDomainName:
+		if tok.code == stringConst {
+			return tok.str, nil
+		}
+	case OptionFieldString, OptionFieldEncapsulation:
+		switch tok.code {
+		case stringConst:
+			return StringConstTerm(tok.str), nil
+		case hexString, macAddr:
+			return HexStringTerm(tok.str), nil
+		}
+	case OptionFieldBoolean:
+		if tok.code == stateTok {
+			s := strings.ToLower(tok.str)
+			return s == "on" || s == "true", nil
+		}
+	case OptionFieldInteger:
+		if tok.code == number || tok.code == word {
+			v, err := strconv.ParseInt(tok.str, 10, 64)
+			if err != nil {
+				break
+			}
+			min, max := int64(0), int64(1)<<uint(of.Width)-1
+			if of.Signed {
+				min, max = -int64(1)<<uint(of.Width-1), int64(1)<<uint(of.Width-1)-1
+			}
+			if v < min || v > max {
+				return nil, fmt.Errorf("%s out of range for %s", tok.str, of)
+			}
+			return int(v), nil
+		}
+	case OptionFieldDestinationDescriptor:
+		if tok.code == cidr {
+			if _, ipNet, err := net.ParseCIDR(tok.str); err == nil {
+				return ipNet, nil
+			}
+		}
+	}
+	return nil, fmt.Errorf("expected %s but found %q", of, tok.str)
+}
+
+// optionStatement is called by the parser on finding an option parameter,
+// converting its value according to the option's definition.
+func optionStatement(yylex yyLexer, name string, tokens []optionToken) Statement {
+	// yylex may not be a *lexer when expectedTokens() is replaying tokens
+	// after an error, and then the option's value doesn't matter
+	l, ok := yylex.(*lexer)
+	if !ok {
+		return nil
+	}
+	def, found := l.options.lookup(name)
+	if !found {
+		yylex.Error(fmt.Sprintf("unknown option %q", name))
+		return nil
+	}
+	values, err := decodeOptionValues(def.Type, tokens)
+	if err != nil {
+		yylex.Error(fmt.Sprintf("option %q: %s", name, err))
+		return nil
+	}
+	return OptionStatement{
+		Name:   name,
+		Values: values,
+	}
+}
+
+// An OptionSpaceStatement declares an option space, within which options can
+// then be defined. CodeWidth and LengthWidth give the size in bytes of the
+// code and length of each option in the space's wire format, and HashSize
+// the size of the table dhcpd stores them in; each is left out of the
+// statement if it's zero. See "DEFINING NEW OPTIONS" in dhcp-options(5)
+type OptionSpaceStatement struct {
+	Name        string
+	CodeWidth   int
+	LengthWidth int
+	HashSize    int
+}
+
+// IndentedString implements the method of the same name in the Statement interface
+func (oss OptionSpaceStatement) IndentedString(prefix string) string {
+	s := prefix + "option space " + oss.Name
+	if oss.CodeWidth != 0 {
+		s += " code width " + strconv.Itoa(oss.CodeWidth)
+	}
+	if oss.LengthWidth != 0 {
+		s += " length width " + strconv.Itoa(oss.LengthWidth)
+	}
+	if oss.HashSize != 0 {
+		s += " hash size " + strconv.Itoa(oss.HashSize)
+	}
+	return s + ";\n"
+}
+
+// An OptionDefinitionStatement defines a new option, or redefines a standard
+// one, e.g. "option pxelinux.magic code 208 = string;". Options in a space
+// other than "dhcp" can only be defined after an OptionSpaceStatement
+// declaring that space.
+type OptionDefinitionStatement struct {
+	OptionDefinition
+}
+
+// IndentedString implements the method of the same name in the Statement interface
+func (ods OptionDefinitionStatement) IndentedString(prefix string) string {
+	return fmt.Sprintf("%soption %s code %d = %s;\n", prefix, ods.QualifiedName(), ods.Code, ods.Type)
+}
+
+// A VendorOptionSpaceStatement names the option space whose options are sent
+// to the client encapsulated in the vendor-encapsulated-options option.
+type VendorOptionSpaceStatement string
+
+// IndentedString implements the method of the same name in the Statement interface
+func (voss VendorOptionSpaceStatement) IndentedString(prefix string) string {
+	return prefix + "vendor-option-space " + string(voss) + ";\n"
+}
+
+// optionSpaceParam is called by the parser on finding one of the optional
+// parameters of an option space declaration, e.g. "code width 2", and
+// returns the declaration with it applied.
+func optionSpaceParam(yylex yyLexer, oss OptionSpaceStatement, name1, name2 string, value int) OptionSpaceStatement {
+	param := strings.ToLower(name1 + " " + name2)
+	switch {
+	case param == "code width" && (value == 1 || value == 2 || value == 4):
+		oss.CodeWidth = value
+	case param == "length width" && (value == 1 || value == 2):
+		oss.LengthWidth = value
+	case param == "hash size" && value > 0:
+		oss.HashSize = value
+	case param == "code width" || param == "length width" || param == "hash size":
+		yylex.Error(fmt.Sprintf("invalid %s %d", param, value))
+	default:
+		yylex.Error(fmt.Sprintf("unknown option space parameter %q", param))
+	}
+	return oss
+}
+
+// optionDefinitionStatement is called by the parser on finding an option
+// definition, and registers it for the options which follow.
+func optionDefinitionStatement(yylex yyLexer, name string, code int, typeWords []string) Statement {
+	def := OptionDefinition{
+		Space: "dhcp",
+		Name:  name,
+		Code:  code,
+	}
+	if i := strings.Index(name, "."); i != -1 {
+		def.Space, def.Name = name[:i], name[i+1:]
+	}
+	var err error
+	def.Type, err = parseOptionType(typeWords)
+	if l, ok := yylex.(*lexer); ok && err == nil {
+		err = l.options.define(def)
+	}
+	if err != nil {
+		yylex.Error(fmt.Sprintf("option %q: %s", name, err))
+		return nil
+	}
+	return OptionDefinitionStatement{def}
+}
@@ -0,0 +1,204 @@
+package iscdhcp
+
+import (
+	"bytes"
+	"net"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestOptionStatement_roundtrip(t *testing.T) {
+	_, route1, _ := net.ParseCIDR("10.0.0.0/8")
+	_, route2, _ := net.ParseCIDR("0.0.0.0/0")
+	statements := []Statement{
+		OptionStatement{Name: "subnet-mask", Values: [][]interface{}{{net.ParseIP("255.255.255.0")}}},
+		OptionStatement{Name: "routers", Values: [][]interface{}{{net.ParseIP("10.0.0.1")}, {net.ParseIP("10.0.0.2")}}},
+		OptionStatement{Name: "time-offset", Values: [][]interface{}{{-18000}}},
+		OptionStatement{Name: "domain-name", Values: [][]interface{}{{"example.com"}}},
+		OptionStatement{Name: "ip-forwarding", Values: [][]interface{}{{false}}},
+		OptionStatement{Name: "interface-mtu", Values: [][]interface{}{{9000}}},
+		OptionStatement{Name: "tftp-server-name", Values: [][]interface{}{{"tftp.example.com"}}},
+		OptionStatement{Name: "bootfile-name", Values: [][]interface{}{{"pxelinux.0"}}},
+		OptionStatement{Name: "dhcp-client-identifier", Values: [][]interface{}{{HexStringTerm("1:0:c0:ff:ee:0:1")}}},
+		OptionStatement{Name: "vendor-class-identifier", Values: [][]interface{}{{StringConstTerm("PXEClient")}}},
+		OptionStatement{Name: "domain-search", Values: [][]interface{}{{[]string{"example.com", "example.net"}}}},
+		OptionStatement{Name: "dhcp-parameter-request-list", Values: [][]interface{}{{1}, {3}, {6}}},
+		OptionStatement{
+			Name: "static-routes",
+			Values: [][]interface{}{
+				{net.ParseIP("10.1.0.0"), net.ParseIP("10.0.0.1")},
+				{net.ParseIP("10.2.0.0"), net.ParseIP("10.0.0.2")},
+			},
+		},
+		OptionStatement{
+			Name: "classless-static-routes",
+			Values: [][]interface{}{
+				{route1, net.ParseIP("10.0.0.1")},
+				{route2, net.ParseIP("10.0.0.254")},
+			},
+		},
+	}
+
+	for _, statement := range statements {
+		text := statement.IndentedString("")
+		newStatements, err := Decode(strings.NewReader(text))
+		if err != nil {
+			t.Fatalf("%q: unexpected error: %s", text, err)
+		}
+		if len(newStatements) != 1 {
+			t.Fatalf("expected exactly 1 statement, got %d", len(newStatements))
+		}
+		if !reflect.DeepEqual(newStatements[0], statement) {
+			t.Errorf("actual != expected: %#v != %#v", newStatements[0], statement)
+		}
+	}
+}
+
+func TestOptionStatement_decodeInvalid(t *testing.T) {
+	testCases := []struct {
+		data     string
+		expected string
+	}{
+		{`option no-such-option 1;`, `unknown option "no-such-option"`},
+		{`option subnet-mask 255.255.255.0, 255.255.0.0;`, `option "subnet-mask": expected a single value`},
+		{`option routers "gateway";`, `option "routers": expected ip-address but found "gateway"`},
+		{`option default-ip-ttl 256;`, `option "default-ip-ttl": 256 out of range for unsigned integer 8`},
+		{`option static-routes 10.1.0.0;`, `option "static-routes": expected 2 field(s) in value 1 but found 1`},
+		{`option domain-search "a.com" "b.com";`, `option "domain-search": expected "," but found "b.com"`},
+	}
+	for _, tc := range testCases {
+		_, err := Decode(strings.NewReader(tc.data))
+		pe, ok := err.(*ParseError)
+		if !ok || pe.Msg != tc.expected {
+			t.Errorf("%q: expected %q, got %v", tc.data, tc.expected, err)
+		}
+	}
+}
+
+func TestOptionType_String(t *testing.T) {
+	// every standard option's type should survive being written out and
+	// parsed again
+	for _, so := range standardOptions {
+		def, found := LookupOption(so.name)
+		if !found {
+			t.Fatalf("LookupOption(%q) failed", so.name)
+		}
+		if def.Type.String() != so.typ {
+			t.Errorf("%s: expected %q, got %q", so.name, so.typ, def.Type.String())
+		}
+	}
+}
+
+func TestOptionDefinitions_roundtrip(t *testing.T) {
+	statements := []Statement{
+		OptionSpaceStatement{Name: "pxelinux"},
+		OptionSpaceStatement{Name: "vivso-acme", CodeWidth: 2, LengthWidth: 2, HashSize: 17},
+		OptionDefinitionStatement{OptionDefinition{
+			Space: "pxelinux",
+			Name:  "magic",
+			Code:  208,
+			Type:  OptionType{Fields: []OptionField{{Kind: OptionFieldString}}},
+		}},
+		OptionDefinitionStatement{OptionDefinition{
+			Space: "dhcp",
+			Name:  "foo",
+			Code:  224,
+			Type:  OptionType{Array: true, Fields: []OptionField{{Kind: OptionFieldIPAddress}}},
+		}},
+		OptionDefinitionStatement{OptionDefinition{
+			Space: "vivso-acme",
+			Name:  "gateway",
+			Code:  1000,
+			Type: OptionType{Fields: []OptionField{
+				{Kind: OptionFieldIPAddress},
+				{Kind: OptionFieldInteger, Width: 8},
+			}},
+		}},
+		OptionDefinitionStatement{OptionDefinition{
+			Space: "dhcp",
+			Name:  "acme-vendor",
+			Code:  250,
+			Type: OptionType{Fields: []OptionField{
+				{Kind: OptionFieldInteger, Width: 32},
+				{Kind: OptionFieldEncapsulation, Space: "vivso-acme"},
+			}},
+		}},
+		VendorOptionSpaceStatement("pxelinux"),
+		OptionStatement{Name: "pxelinux.magic", Values: [][]interface{}{{HexStringTerm("f1:00:74:7e")}}},
+		OptionStatement{Name: "foo", Values: [][]interface{}{{net.ParseIP("10.0.0.1")}, {net.ParseIP("10.0.0.2")}}},
+		OptionStatement{Name: "vivso-acme.gateway", Values: [][]interface{}{{net.ParseIP("10.0.0.1"), 7}}},
+	}
+
+	// definitions only apply to the Decode call they're found in, so the
+	// statements have to be decoded together
+	buf := &bytes.Buffer{}
+	if err := Encode(buf, statements); err != nil {
+		t.Fatalf("Encode(): %s", err)
+	}
+	newStatements, err := Decode(buf)
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	if !reflect.DeepEqual(newStatements, statements) {
+		t.Errorf("actual != expected: %#v != %#v", newStatements, statements)
+	}
+}
+
+func TestOptionDefinitions_text(t *testing.T) {
+	data := `option space pxelinux code width 1 length width 1;
+option pxelinux.reboottime code 211 = unsigned integer 32;
+option routes code 249 = array of { ip-address, unsigned integer 8 };
+option pxelinux.reboottime 30;
+option routes 10.0.0.1 24, 10.0.1.1 8;
+`
+	expected := []string{
+		"option space pxelinux code width 1 length width 1;\n",
+		"option pxelinux.reboottime code 211 = unsigned integer 32;\n",
+		"option routes code 249 = array of { ip-address, unsigned integer 8 };\n",
+		"option pxelinux.reboottime 30;\n",
+		"option routes 10.0.0.1 24, 10.0.1.1 8;\n",
+	}
+	statements, err := Decode(strings.NewReader(data))
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	if len(statements) != len(expected) {
+		t.Fatalf("expected %d statements, got %d", len(expected), len(statements))
+	}
+	for i, statement := range statements {
+		if statement.IndentedString("") != expected[i] {
+			t.Errorf("expected %q, got %q", expected[i], statement.IndentedString(""))
+		}
+	}
+
+	// the definitions mustn't leak into other Decode calls
+	_, err = Decode(strings.NewReader("option pxelinux.reboottime 30;\n"))
+	if pe, ok := err.(*ParseError); !ok || pe.Msg != `unknown option "pxelinux.reboottime"` {
+		t.Errorf("expected unknown option error, got %v", err)
+	}
+}
+
+func TestOptionDefinitions_decodeInvalid(t *testing.T) {
+	testCases := []struct {
+		data     string
+		expected string
+	}{
+		{`option pxelinux.magic code 208 = string;`, `option "pxelinux.magic": no option space named "pxelinux"`},
+		{`option foo code 255 = string;`, `option "foo": code 255 out of range for option space "dhcp"`},
+		{`option foo code 224 = array of domain-list;`, `option "foo": domain-list can't be used within an array or record`},
+		{`option foo code 224 = integer 12;`, `option "foo": invalid integer width "12"`},
+		{`option foo code 224 = { ip-address };`, `option "foo": record type needs more than one field`},
+		{`option foo code 224 = encapsulate nowhere;`, `option "foo": no option space named "nowhere"`},
+		{`option space foo code width 3;`, `invalid code width 3`},
+		{`option space foo hash width 3;`, `unknown option space parameter "hash width"`},
+		{"option foo code 224 = ip-address;\noption foo \"bar\";", `option "foo": expected ip-address but found "bar"`},
+	}
+	for _, tc := range testCases {
+		_, err := Decode(strings.NewReader(tc.data))
+		pe, ok := err.(*ParseError)
+		if !ok || pe.Msg != tc.expected {
+			t.Errorf("%q: expected %q, got %v", tc.data, tc.expected, err)
+		}
+	}
+}
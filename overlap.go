@@ -0,0 +1,227 @@
+package iscdhcp
+
+import (
+	"encoding/binary"
+	"net"
+	"sort"
+)
+
+// CheckAddressSpace looks for conflicts between the IPv4 subnets and ranges
+// declared anywhere in a config, including within groups, shared networks,
+// pools and included files, and returns a Diagnostic for each one. It's one
+// of the checks made by Validate. The Diagnostic codes it reports are:
+//
+//	subnet-overlap             two subnets share addresses
+//	range-overlap              two ranges share addresses
+//	range-outside-subnet       a range isn't wholly within the subnet, or one of
+//	                           the subnets of the shared network, declaring it
+//	range-network-address      a range includes its subnet's network address
+//	                           (warning)
+//	range-broadcast-address    a range includes its subnet's broadcast address
+//	                           (warning)
+//
+// Each overlap is reported once, at whichever of the two declarations comes
+// later in the config.
+func CheckAddressSpace(statements []Statement) []Diagnostic {
+	ac := &addressChecker{}
+	ac.collect(statements, nil)
+
+	var found []indexedDiagnostic
+	for _, overlap := range overlaps(ac.subnets) {
+		earlier, later := overlap[0], overlap[1]
+		found = append(found, later.diagnostic(SeverityError, "subnet-overlap",
+			"subnet %s overlaps subnet %s%s", later.desc, earlier.desc, declaredAt(earlier.statement)))
+	}
+	for _, overlap := range overlaps(ac.ranges) {
+		earlier, later := overlap[0], overlap[1]
+		found = append(found, later.diagnostic(SeverityError, "range-overlap",
+			"range %s overlaps range %s%s", later.desc, earlier.desc, declaredAt(earlier.statement)))
+	}
+	for _, r := range ac.ranges {
+		found = append(found, checkRange(r)...)
+	}
+
+	sort.SliceStable(found, func(i, j int) bool {
+		return found[i].index < found[j].index
+	})
+	diagnostics := make([]Diagnostic, len(found))
+	for i, f := range found {
+		diagnostics[i] = f.Diagnostic
+	}
+	return diagnostics
+}
+
+// An addressBlock is a subnet or range found by an addressChecker, spanning
+// the addresses from first to last inclusive.
+type addressBlock struct {
+	statement   Statement
+	index       int
+	desc        string
+	first, last uint32
+	// network holds the subnets a range must be within: its own, or those
+	// of the shared network declaring it.
+	network     []SubnetStatement
+	networkDesc string
+}
+
+func (ab addressBlock) diagnostic(severity Severity, code, format string, args ...interface{}) indexedDiagnostic {
+	return indexedDiagnostic{
+		Diagnostic: newDiagnostic(ab.statement, severity, code, format, args...),
+		index:      ab.index,
+	}
+}
+
+// An indexedDiagnostic is a Diagnostic along with the index, in the order
+// they were found, of the statement it concerns.
+type indexedDiagnostic struct {
+	Diagnostic
+	index int
+}
+
+// An addressChecker gathers the subnets and ranges checked by
+// CheckAddressSpace.
+type addressChecker struct {
+	subnets []addressBlock
+	ranges  []addressBlock
+	count   int
+}
+
+func (ac *addressChecker) collect(statements []Statement, enclosing []Statement) {
+	for _, statement := range flattenIncludes(statements) {
+		inner := Unwrap(statement)
+		ac.count++
+		switch st := inner.(type) {
+		case SubnetStatement:
+			// a non-contiguous netmask is reported by Validate, and can't be
+			// treated as a span of addresses
+			number, mask := st.SubnetNumber.To4(), net.IPMask(st.Netmask.To4())
+			if _, bits := mask.Size(); number != nil && bits != 0 {
+				first := ipToUint32(number.Mask(mask))
+				ac.subnets = append(ac.subnets, addressBlock{
+					statement: statement,
+					index:     ac.count,
+					desc:      st.SubnetNumber.String() + " netmask " + st.Netmask.String(),
+					first:     first,
+					last:      first | ^binary.BigEndian.Uint32(mask),
+				})
+			}
+		case RangeStatement:
+			low, high := st.Low.To4(), st.High.To4()
+			if high == nil {
+				high = low
+			}
+			if low == nil {
+				break
+			}
+			r := addressBlock{
+				statement: statement,
+				index:     ac.count,
+				desc:      st.Low.String(),
+				first:     ipToUint32(low),
+				last:      ipToUint32(high),
+			}
+			if st.High != nil {
+				r.desc += " " + st.High.String()
+			}
+			if r.first > r.last {
+				r.first, r.last = r.last, r.first
+			}
+			r.network, r.networkDesc = enclosingNetwork(enclosing)
+			ac.ranges = append(ac.ranges, r)
+		}
+
+		if bs, ok := inner.(blockStatement); ok {
+			nested := append(enclosing[:len(enclosing):len(enclosing)], inner)
+			bs.mapBlocks(func(block []Statement) []Statement {
+				ac.collect(block, nested)
+				return block
+			})
+		}
+	}
+}
+
+// enclosingNetwork returns the subnets of the innermost subnet or shared
+// network among enclosing, and a description of it.
+func enclosingNetwork(enclosing []Statement) ([]SubnetStatement, string) {
+	for i := len(enclosing) - 1; i >= 0; i-- {
+		switch st := enclosing[i].(type) {
+		case SubnetStatement:
+			return []SubnetStatement{st}, "subnet " + st.SubnetNumber.String()
+		case SharedNetworkStatement:
+			return subnetsWithin(st.Statements), "shared-network " + quoteString(st.Name)
+		}
+	}
+	return nil, ""
+}
+
+// checkRange checks that a range lies within its subnet, and doesn't include
+// the subnet's network or broadcast address.
+func checkRange(r addressBlock) []indexedDiagnostic {
+	if r.network == nil {
+		return []indexedDiagnostic{r.diagnostic(SeverityError, "range-outside-subnet",
+			"range %s isn't declared within a subnet", r.desc)}
+	}
+	for _, ss := range r.network {
+		number, mask := ss.SubnetNumber.To4(), net.IPMask(ss.Netmask.To4())
+		if _, bits := mask.Size(); number == nil || bits == 0 {
+			continue
+		}
+		network := ipToUint32(number.Mask(mask))
+		broadcast := network | ^binary.BigEndian.Uint32(mask)
+		if r.first < network || r.last > broadcast {
+			continue
+		}
+
+		var found []indexedDiagnostic
+		if r.first == network {
+			found = append(found, r.diagnostic(SeverityWarning, "range-network-address",
+				"range %s includes the network address of subnet %s", r.desc, ss.SubnetNumber))
+		}
+		if r.last == broadcast {
+			found = append(found, r.diagnostic(SeverityWarning, "range-broadcast-address",
+				"range %s includes the broadcast address %s of subnet %s", r.desc, uint32ToIP(broadcast), ss.SubnetNumber))
+		}
+		return found
+	}
+	return []indexedDiagnostic{r.diagnostic(SeverityError, "range-outside-subnet",
+		"range %s isn't within its %s", r.desc, r.networkDesc)}
+}
+
+// overlaps returns each pair of blocks which share addresses, the one found
+// first in the config first.
+func overlaps(blocks []addressBlock) [][2]addressBlock {
+	sorted := append([]addressBlock(nil), blocks...)
+	sort.SliceStable(sorted, func(i, j int) bool {
+		return sorted[i].first < sorted[j].first
+	})
+
+	var pairs [][2]addressBlock
+	var open []addressBlock
+	for _, b := range sorted {
+		// drop the blocks which end before this one starts
+		stillOpen := open[:0]
+		for _, o := range open {
+			if o.last >= b.first {
+				stillOpen = append(stillOpen, o)
+			}
+		}
+		open = stillOpen
+		for _, o := range open {
+			if o.index < b.index {
+				pairs = append(pairs, [2]addressBlock{o, b})
+			} else {
+				pairs = append(pairs, [2]addressBlock{b, o})
+			}
+		}
+		open = append(open, b)
+	}
+	return pairs
+}
+
+func ipToUint32(ip net.IP) uint32 {
+	return binary.BigEndian.Uint32(ip.To4())
+}
+
+func uint32ToIP(n uint32) net.IP {
+	return net.IPv4(byte(n>>24), byte(n>>16), byte(n>>8), byte(n))
+}
@@ -0,0 +1,97 @@
+package iscdhcp
+
+import "testing"
+
+func TestCheckAddressSpace(t *testing.T) {
+	testDiagnostics(t, CheckAddressSpace, []diagnosticTestCase{
+		{
+			config: `subnet 10.0.0.0 netmask 255.255.255.0 {
+    range 10.0.0.10 10.0.0.99;
+    pool {
+        range 10.0.0.100 10.0.0.199;
+    }
+}
+subnet 10.0.1.0 netmask 255.255.255.0 {
+    range 10.0.1.10;
+}
+`,
+		},
+		{
+			config: `subnet 10.0.0.0 netmask 255.255.255.0 {
+}
+subnet 10.0.0.128 netmask 255.255.255.128 {
+}
+`,
+			expected: []string{
+				`3:1: error: subnet 10.0.0.128 netmask 255.255.255.128 overlaps subnet 10.0.0.0 netmask 255.255.255.0 declared at 1:1 [subnet-overlap]`,
+			},
+		},
+		{
+			config: `subnet 10.0.0.0 netmask 255.255.255.0 {
+}
+include "included.conf";
+`,
+			included: `subnet 10.0.0.128 netmask 255.255.255.128 {
+}
+`,
+			expected: []string{
+				`included.conf:1:1: error: subnet 10.0.0.128 netmask 255.255.255.128 overlaps subnet 10.0.0.0 netmask 255.255.255.0 declared at dhcpd.conf:1:1 [subnet-overlap]`,
+			},
+		},
+		{
+			config: `subnet 10.0.0.0 netmask 255.255.255.0 {
+    range 10.0.0.10 10.0.0.50;
+    pool {
+        range 10.0.0.40 10.0.0.60;
+    }
+}
+`,
+			expected: []string{
+				`4:9: error: range 10.0.0.40 10.0.0.60 overlaps range 10.0.0.10 10.0.0.50 declared at 2:5 [range-overlap]`,
+			},
+		},
+		{
+			config: `subnet 10.0.0.128 netmask 255.255.255.128 {
+    range 10.0.0.200 10.0.0.100;
+}
+shared-network "campus" {
+    group {
+        subnet 10.1.0.0 netmask 255.255.255.0 {
+        }
+    }
+    pool {
+        range 10.2.0.1;
+    }
+}
+range 10.9.0.1 10.9.0.10;
+`,
+			expected: []string{
+				`2:5: error: range 10.0.0.200 10.0.0.100 isn't within its subnet 10.0.0.128 [range-outside-subnet]`,
+				`10:9: error: range 10.2.0.1 isn't within its shared-network "campus" [range-outside-subnet]`,
+				`13:1: error: range 10.9.0.1 10.9.0.10 isn't declared within a subnet [range-outside-subnet]`,
+			},
+		},
+		{
+			config: `subnet 10.0.0.0 netmask 255.255.255.0 {
+    range 10.0.0.0 10.0.0.50;
+}
+`,
+			expected: []string{
+				`2:5: warning: range 10.0.0.0 10.0.0.50 includes the network address of subnet 10.0.0.0 [range-network-address]`,
+			},
+		},
+		{
+			config: `shared-network "campus" {
+    subnet 10.1.0.0 netmask 255.255.255.0 {
+    }
+    pool {
+        range 10.1.0.200 10.1.0.255;
+    }
+}
+`,
+			expected: []string{
+				`5:9: warning: range 10.1.0.200 10.1.0.255 includes the broadcast address 10.1.0.255 of subnet 10.1.0.0 [range-broadcast-address]`,
+			},
+		},
+	})
+}
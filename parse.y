@@ -9,7 +9,7 @@ import (
 
 %}
 
-%token openBrace closeBrace quote semicolon comma
+%token openBrace closeBrace openParen closeParen quote semicolon comma
 
 // conditional + boolean logic stuff
 %token ConditionIf ConditionElsif ConditionElse
@@ -25,16 +25,33 @@ import (
 %token BoolEqual BoolInequal BoolRegexMatch BoolRegexIMatch
 %token BoolExists BoolKnown BoolStatic
 
+// access control
+%token AccessAllow AccessDeny AccessIgnore
+
+// data expressions, see dhcp-eval(5)
+%token binaryToASCIITok clientStateTok concatTok configOptionTok encodeIntTok
+%token extractIntTok hostDeclNameTok lcaseTok leaseTimeTok leasedAddressTok
+%token packetTok pickFirstValueTok reverseTok substringTok suffixTok ucaseTok
+
 // simple types
-%token number ipAddr cidr stringConst macAddr
+%token number ipAddr cidr stringConst macAddr hexString
+%token ip6Addr ip6CIDR prefixLength
 
 // reserved words
 %token stateTok authoritativeTok
-%token groupTok hostTok subnetTok netmaskTok optionTok includeTok
+%token groupTok hostTok sharedNetworkTok subnetTok netmaskTok optionTok includeTok
+%token poolTok rangeTok dynamicBootpTok
+%token subnet6Tok range6Tok prefix6Tok temporaryTok fixedAddr6Tok fixedPrefix6Tok
+%token classTok subclassTok matchTok spawnTok withTok leaseTok limitTok
 %token hardwareTok ethernetTok fixedAddrTok
-%token useHostDeclNamesTok
-%token optDomainNameServersTok
-
+%token membersTok ofTok failoverTok peerTok
+%token useHostDeclNamesTok codeTok spaceTok vendorOptionSpaceTok
+%token adaptiveLeaseThresholdTok alwaysBroadcastTok alwaysReplyRFC1048Tok
+%token bootUnknownClientsTok dbTimeFormatTok
+%token ddnsDomainNameTok ddnsHostNameTok ddnsRevDomainNameTok
+%token ddnsUpdateStyleTok ddnsUpdatesTok
+%token defaultLeaseTimeTok delayedAckTok doForwardUpdatesTok
+%token dynamicBootpLeaseCutoffTok maxAckDelayTok maxLeaseTimeTok minLeaseTimeTok
 // everything else
 %token word
 
@@ -46,52 +63,103 @@ import (
     strList []string
     ipList []net.IP
     ip net.IP
+    ipNet *net.IPNet
     statement Statement
     statementList []Statement
     dataTerm fmt.Stringer
+    dataTermList []fmt.Stringer
     boolExpr BooleanExpression
     subConditionals []ConditionalStatement
+    optionTokens []optionToken
+    // pos is the position of the first token of a symbol
+    pos Position
 }
 
 %%
 // Primitives
-config: statements
+config:
+    // a file may be empty, or hold only comments
     {
-        l := yylex.(*lexer)
-        l.dirtyHackReturn = $1.statementList
+        if l, ok := yylex.(*lexer); ok {
+            l.dirtyHackReturn = danglingComments(yylex, l.currentStart)
+        }
+    }
+    | statements
+    {
+        // yylex may not be a *lexer when expectedTokens() is replaying
+        // tokens after an error
+        if l, ok := yylex.(*lexer); ok {
+            l.dirtyHackReturn = append($1.statementList, danglingComments(yylex, l.currentStart)...)
+        }
     };
 
 statements:
     statement
     {
-        $$.statementList = []Statement{$1.statement}
+        $$.statementList = []Statement{sourceStatement(yylex, $1.statement, $1.pos)}
     }
     | statements statement
     {
-        $$.statementList = append($$.statementList, $2.statement)
+        $$.statementList = append($$.statementList, sourceStatement(yylex, $2.statement, $2.pos))
     };
 
 statement:
     // Statements can be either declarations...
-    groupdecl
+    classDecl
+    | groupdecl
     | hostdecl
     | includedecl
+    | pooldecl
+    | prefix6Decl
+    | rangedecl
+    | range6Decl
+    | sharedNetworkDecl
+    | subclassDecl
     | subnetdecl
+    | subnet6Decl
     | conditionalDecl
 
     // or parameters
+    | adaptiveLeaseThresholdParam
+    | allowDenyParam
+    | alwaysBroadcastParam
+    | alwaysReplyRFC1048Param
     | authoritativeParam
+    | bootUnknownClientsParam
+    | dbTimeFormatParam
+    | ddnsDomainNameParam
+    | ddnsHostNameParam
+    | ddnsRevDomainNameParam
+    | ddnsUpdateStyleParam
+    | ddnsUpdatesParam
+    | defaultLeaseTimeParam
+    | delayedAckParam
+    | doForwardUpdatesParam
+    | dynamicBootpLeaseCutoffParam
+    | failoverPeerParam
     | hardwareparam
     | fixedaddressparam
+    | fixedAddress6Param
+    | fixedPrefix6Param
+    | leaseLimitParam
+    | matchParam
+    | maxAckDelayParam
+    | maxLeaseTimeParam
+    | minLeaseTimeParam
     | optionparam
+    | spawnWithParam
     | useHostDeclNamesParam
+    | vendorOptionSpaceParam
     ;
 
 block:
       openBrace closeBrace // empty block
+    {
+        $$.statementList = danglingComments(yylex, $2.pos)
+    }
     | openBrace statements closeBrace
     {
-        $$.statementList = $2.statementList
+        $$.statementList = append($2.statementList, danglingComments(yylex, $3.pos)...)
     };
 
 // Conditionals
@@ -103,28 +171,50 @@ conditionalDecl:
             Condition:       $2.boolExpr,
             Statements:      $3.statementList,
             SubConditionals: $4.subConditionals,
+            OpeningComment:  openingComment(yylex, $3.pos),
+        }
+        // the comment trailing each block is only found on reaching the
+        // elsif/else following it
+        for i, comment := range $4.strList {
+            if i == 0 {
+                cs.TrailingComment = comment
+            } else {
+                cs.SubConditionals[i-1].TrailingComment = comment
+            }
         }
         $$.statement = cs
     }
 
 subConditionList:
     // empty list is one possibility
+    {
+        $$.subConditionals = nil
+        $$.strList = nil
+    }
     | subConditionList ConditionElsif booleanExpr block
     {
         cs := ConditionalStatement {
-            Operator:   ConditionElsif,
-            Condition:  $3.boolExpr,
-            Statements: $3.statementList,
+            Operator:       ConditionElsif,
+            Condition:      $3.boolExpr,
+            Statements:     $4.statementList,
+            OpeningComment: openingComment(yylex, $4.pos),
         }
+        var trailing string
+        trailing, cs.LeadingComments = commentsBeforeBranch(yylex, $2.pos)
         $$.subConditionals = append($$.subConditionals, cs)
+        $$.strList = append($$.strList, trailing)
     }
     | subConditionList ConditionElse block
     {
         cs := ConditionalStatement {
-            Operator:   ConditionElse,
-            Statements: $2.statementList,
+            Operator:       ConditionElse,
+            Statements:     $3.statementList,
+            OpeningComment: openingComment(yylex, $3.pos),
         }
+        var trailing string
+        trailing, cs.LeadingComments = commentsBeforeBranch(yylex, $2.pos)
         $$.subConditionals = append($$.subConditionals, cs)
+        $$.strList = append($$.strList, trailing)
     };
 
 booleanExpr:
@@ -149,6 +239,10 @@ booleanExpr:
             BoolTerms: []BooleanExpression{$2.boolExpr},
         }
     }
+    | openParen booleanExpr closeParen
+    {
+        $$.boolExpr = $2.boolExpr
+    }
     | BoolStatic
     {
         $$.boolExpr = BooleanExpression {
@@ -202,22 +296,169 @@ dataTerm:
     {
         $$.dataTerm = StringConstTerm($1.str)
     }
-    | optionTok word
+    | hexData
+    {
+        $$.dataTerm = HexStringTerm($1.str)
+    }
+    | optionTok optionName
     {
         $$.dataTerm = PacketOptionTerm{
             optionName: $2.str,
         }
+    }
+    | configOptionTok optionName
+    {
+        $$.dataTerm = ConfigOptionTerm{
+            OptionName: $2.str,
+        }
+    }
+    | substringTok openParen dataTerm comma dataTerm comma dataTerm closeParen
+    {
+        $$.dataTerm = SubstringTerm{
+            Data:   $3.dataTerm,
+            Offset: $5.dataTerm,
+            Length: $7.dataTerm,
+        }
+    }
+    | suffixTok openParen dataTerm comma dataTerm closeParen
+    {
+        $$.dataTerm = SuffixTerm{
+            Data:   $3.dataTerm,
+            Length: $5.dataTerm,
+        }
+    }
+    | concatTok openParen dataTermList closeParen
+    {
+        $$.dataTerm = ConcatTerm($3.dataTermList)
+    }
+    | hardwareTok
+    {
+        $$.dataTerm = HardwareTerm{}
+    }
+    | packetTok openParen dataTerm comma dataTerm closeParen
+    {
+        $$.dataTerm = PacketTerm{
+            Offset: $3.dataTerm,
+            Length: $5.dataTerm,
+        }
+    }
+    | leasedAddressTok
+    {
+        $$.dataTerm = LeasedAddressTerm{}
+    }
+    | hostDeclNameTok
+    {
+        $$.dataTerm = HostDeclNameTerm{}
+    }
+    | binaryToASCIITok openParen dataTerm comma dataTerm comma dataTerm comma dataTerm closeParen
+    {
+        $$.dataTerm = BinaryToASCIITerm{
+            Base:      $3.dataTerm,
+            Width:     $5.dataTerm,
+            Separator: $7.dataTerm,
+            Data:      $9.dataTerm,
+        }
+    }
+    | encodeIntTok openParen dataTerm comma number closeParen
+    {
+        $$.dataTerm = EncodeIntTerm{
+            Value: $3.dataTerm,
+            Width: $5.num,
+        }
+    }
+    | extractIntTok openParen dataTerm comma number closeParen
+    {
+        $$.dataTerm = ExtractIntTerm{
+            Data:  $3.dataTerm,
+            Width: $5.num,
+        }
+    }
+    | pickFirstValueTok openParen dataTermList closeParen
+    {
+        $$.dataTerm = PickFirstValueTerm($3.dataTermList)
+    }
+    | reverseTok openParen dataTerm comma dataTerm closeParen
+    {
+        $$.dataTerm = ReverseTerm{
+            Width: $3.dataTerm,
+            Data:  $5.dataTerm,
+        }
+    }
+    | lcaseTok openParen dataTerm closeParen
+    {
+        $$.dataTerm = LcaseTerm{
+            Data: $3.dataTerm,
+        }
+    }
+    | ucaseTok openParen dataTerm closeParen
+    {
+        $$.dataTerm = UcaseTerm{
+            Data: $3.dataTerm,
+        }
+    }
+    | clientStateTok
+    {
+        $$.dataTerm = ClientStateTerm{}
+    }
+    | leaseTimeTok
+    {
+        $$.dataTerm = LeaseTimeTerm{}
+    }
+    | number
+    {
+        $$.dataTerm = NumberTerm($1.num)
+    };
+
+dataTermList:
+    dataTermList comma dataTerm
+    {
+        $$.dataTermList = append($$.dataTermList, $3.dataTerm)
+    }
+    | dataTerm
+    {
+        $$.dataTermList = []fmt.Stringer{$1.dataTerm}
     };
 
-//wordList:
-//    wordList word
-//    {
-//        $$.strList = append($$.strList, $2.str)
-//    }
-//    | word
-//    {
-//        $$.strList = []string{$1.str}
-//    };
+optionName:
+    word;
+
+// A name may be any word, including those reserved only by the statements
+// they begin, as dhcpd only treats them specially in that position.
+name:
+    word
+    | poolTok | rangeTok | dynamicBootpTok
+    | failoverTok | peerTok | membersTok | ofTok
+    | AccessAllow | AccessDeny | AccessIgnore
+    | classTok | subclassTok | matchTok | spawnTok | withTok | leaseTok | limitTok
+    | binaryToASCIITok | clientStateTok | concatTok | configOptionTok | encodeIntTok
+    | extractIntTok | hostDeclNameTok | lcaseTok | leaseTimeTok | leasedAddressTok
+    | packetTok | pickFirstValueTok | reverseTok | substringTok | suffixTok | ucaseTok
+    | subnet6Tok | range6Tok | prefix6Tok | temporaryTok | fixedAddr6Tok | fixedPrefix6Tok
+    | codeTok | spaceTok | vendorOptionSpaceTok;
+
+wordList:
+    wordList word
+    {
+        $$.strList = append($$.strList, $2.str)
+    }
+    | word
+    {
+        $$.strList = []string{$1.str}
+    };
+
+hexData:
+    hexString
+    | macAddr;
+
+onOffState:
+    stateTok
+    {
+        $$.num = 0
+        cmpText := strings.ToLower($1.str)
+        if cmpText == "on" || cmpText == "true" {
+            $$.num = 1
+        }
+    };
 
 ipList:
     ipList comma ipAddr
@@ -229,7 +470,45 @@ ipList:
         $$.ipList = []net.IP{net.ParseIP($1.str)}
     };
 
+// An IPv6 address made up only of full, hex-digit groups looks like a hex
+// string to the lexer.
+ip6:
+    ip6Addr
+    {
+        $$.ip = net.ParseIP($1.str)
+    }
+    | hexString
+    {
+        $$.ip = net.ParseIP($1.str)
+        if $$.ip == nil {
+            yylex.Error(fmt.Sprintf("invalid IPv6 address %q", $1.str))
+        }
+    };
+
+// The address of a prefix is kept as written, even if it has bits set beyond
+// the prefix length.
+ip6Prefix:
+    ip6CIDR
+    {
+        ip, ipNet, err := net.ParseCIDR($1.str)
+        if err != nil {
+            yylex.Error(fmt.Sprintf("invalid IPv6 prefix %q", $1.str))
+        } else {
+            ipNet.IP = ip
+            $$.ipNet = ipNet
+        }
+    };
+
 // Declarations that include a block
+classDecl: classTok stringConst block
+    {
+        cs := ClassStatement{
+            Name:       $2.str,
+            Statements: $3.statementList,
+        }
+        $$.statement = cs
+    };
+
 groupdecl: groupTok block
     {
         gs := GroupStatement{
@@ -238,7 +517,7 @@ groupdecl: groupTok block
         $$.statement = gs
     };
 
-hostdecl: hostTok word block
+hostdecl: hostTok name block
     {
         hs := HostStatement {
             Hostname:   $2.str,
@@ -249,10 +528,123 @@ hostdecl: hostTok word block
 
 includedecl: includeTok stringConst semicolon
     {
-        is := IncludeStatement {
-            Filename: $2.str,
+        $$.statement = includeStatement(yylex, $2.str)
+    };
+
+pooldecl: poolTok block
+    {
+        ps := PoolStatement{
+            Statements: $2.statementList,
+        }
+        $$.statement = ps
+    };
+
+prefix6Decl:
+    prefix6Tok ip6 ip6 prefixLength semicolon
+    {
+        if $4.num > 128 {
+            yylex.Error(fmt.Sprintf("invalid prefix length %q", $4.str))
+        }
+        $$.statement = Prefix6Statement{
+            Low:       $2.ip,
+            High:      $3.ip,
+            PrefixLen: $4.num,
+        }
+    };
+
+rangedecl:
+    rangeTok rangeBounds semicolon
+    {
+        $$.statement = RangeStatement{
+            Low:  $2.ipList[0],
+            High: $2.ipList[1],
+        }
+    }
+    | rangeTok dynamicBootpTok rangeBounds semicolon
+    {
+        $$.statement = RangeStatement{
+            DynamicBootp: true,
+            Low:          $3.ipList[0],
+            High:         $3.ipList[1],
+        }
+    };
+
+rangeBounds:
+    ipAddr
+    {
+        $$.ipList = []net.IP{net.ParseIP($1.str), nil}
+    }
+    | ipAddr ipAddr
+    {
+        $$.ipList = []net.IP{net.ParseIP($1.str), net.ParseIP($2.str)}
+    };
+
+range6Decl:
+    range6Tok ip6 ip6 semicolon
+    {
+        $$.statement = Range6Statement{
+            Low:  $2.ip,
+            High: $3.ip,
+        }
+    }
+    | range6Tok ip6Prefix semicolon
+    {
+        $$.statement = Range6Statement{
+            Prefix: $2.ipNet,
+        }
+    }
+    | range6Tok ip6Prefix temporaryTok semicolon
+    {
+        $$.statement = Range6Statement{
+            Prefix:    $2.ipNet,
+            Temporary: true,
+        }
+    }
+    | range6Tok ip6 temporaryTok semicolon
+    {
+        $$.statement = Range6Statement{
+            Low:       $2.ip,
+            Temporary: true,
+        }
+    };
+
+sharedNetworkDecl:
+    sharedNetworkTok name block
+    {
+        sns := SharedNetworkStatement {
+            Name:       $2.str,
+            Statements: $3.statementList,
+        }
+        $$.statement = sns
+    }
+    | sharedNetworkTok stringConst block
+    {
+        sns := SharedNetworkStatement {
+            Name:       $2.str,
+            Statements: $3.statementList,
+        }
+        $$.statement = sns
+    };
+
+subclassDecl:
+    subclassTok stringConst dataTerm semicolon
+    {
+        $$.statement = SubclassStatement{
+            ClassName: $2.str,
+            Data:      $3.dataTerm,
+        }
+    }
+    | subclassTok stringConst dataTerm block
+    {
+        statements := $4.statementList
+        if statements == nil {
+            statements = []Statement{}
+        }
+        $$.statement = SubclassStatement{
+            ClassName:  $2.str,
+            Data:       $3.dataTerm,
+            Statements: statements,
         }
-        $$.statement = is
     };
 
 subnetdecl: subnetTok ipAddr netmaskTok ipAddr block
@@ -265,7 +657,64 @@ subnetdecl: subnetTok ipAddr netmaskTok ipAddr block
         $$.statement = sns
     };
 
+subnet6Decl: subnet6Tok ip6Prefix block
+    {
+        $$.statement = Subnet6Statement{
+            Prefix:     $2.ipNet,
+            Statements: $3.statementList,
+        }
+    };
+
 // Parameters found within a block
+adaptiveLeaseThresholdParam:
+    adaptiveLeaseThresholdTok number semicolon
+    {
+        $$.statement = AdaptiveLeaseThresholdStatement($2.num)
+    };
+
+allowDenyParam:
+    accessOperator wordList semicolon
+    {
+        $$.statement = AllowDenyStatement{
+            Operator: $1.num,
+            Flag:     strings.Join($2.strList, " "),
+        }
+    }
+    | accessOperator membersTok ofTok stringConst semicolon
+    {
+        $$.statement = AllowDenyStatement{
+            Operator:  $1.num,
+            Flag:      "members of",
+            ClassName: $4.str,
+        }
+    };
+
+accessOperator:
+    AccessAllow
+    {
+        $$.num = AccessAllow
+    }
+    | AccessDeny
+    {
+        $$.num = AccessDeny
+    }
+    | AccessIgnore
+    {
+        $$.num = AccessIgnore
+    };
+
+alwaysBroadcastParam:
+    alwaysBroadcastTok onOffState semicolon
+    {
+        $$.statement = AlwaysBroadcastStatement($2.num == 1)
+    };
+
+alwaysReplyRFC1048Param:
+    alwaysReplyRFC1048Tok onOffState semicolon
+    {
+        $$.statement = AlwaysReplyRFC1048Statement($2.num == 1)
+    };
+
 authoritativeParam:
     BoolNot authoritativeTok semicolon
     {
@@ -276,6 +725,109 @@ authoritativeParam:
         $$.statement = AuthoritativeStatement(true)
     };
 
+bootUnknownClientsParam:
+    bootUnknownClientsTok onOffState semicolon
+    {
+        $$.statement = BootUnknownClientsStatement($2.num == 1)
+    };
+
+dbTimeFormatParam:
+    dbTimeFormatTok word semicolon
+    {
+        switch strings.ToLower($2.str) {
+        case "default":
+            $$.statement = DBTimeFormatStatement{LocalTime: false}
+        case "local":
+            $$.statement = DBTimeFormatStatement{LocalTime: true}
+        default:
+            yylex.Error(fmt.Sprintf("unknown db-time-format %q", $2.str))
+        }
+    };
+
+ddnsDomainNameParam:
+    ddnsDomainNameTok stringConst semicolon
+    {
+        $$.statement = DDNSDomainNameStatement($2.str)
+    };
+
+ddnsHostNameParam:
+    ddnsHostNameTok stringConst semicolon
+    {
+        $$.statement = DDNSHostNameStatement($2.str)
+    };
+
+ddnsRevDomainNameParam:
+    ddnsRevDomainNameTok stringConst semicolon
+    {
+        $$.statement = DDNSRevDomainNameStatement($2.str)
+    };
+
+ddnsUpdateStyleParam:
+    ddnsUpdateStyleTok word semicolon
+    {
+        found := false
+        for style, styleString := range ddnsUpdateStyleStrings {
+            if strings.ToLower($2.str) == styleString {
+                $$.statement = DDNSUpdateStyleStatement(style)
+                found = true
+            }
+        }
+        if !found {
+            yylex.Error(fmt.Sprintf("unknown ddns-update-style %q", $2.str))
+        }
+    };
+
+ddnsUpdatesParam:
+    ddnsUpdatesTok onOffState semicolon
+    {
+        $$.statement = DDNSUpdatesStatement($2.num == 1)
+    };
+
+defaultLeaseTimeParam:
+    defaultLeaseTimeTok number semicolon
+    {
+        $$.statement = DefaultLeaseTimeStatement($2.num)
+    };
+
+delayedAckParam:
+    delayedAckTok number semicolon
+    {
+        $$.statement = DelayedAckStatement($2.num)
+    };
+
+doForwardUpdatesParam:
+    doForwardUpdatesTok onOffState semicolon
+    {
+        $$.statement = DoForwardUpdatesStatement($2.num == 1)
+    };
+
+dynamicBootpLeaseCutoffParam:
+    dynamicBootpLeaseCutoffTok number word timeOfDay semicolon
+    {
+        dblcs := DynamicBootpLeaseCutoffStatement{
+            DayOfWeek: $2.num,
+        }
+        _, errDate := fmt.Sscanf($3.str, "%d/%d/%d", &dblcs.Year, &dblcs.Month, &dblcs.DayOfMonth)
+        _, errTime := fmt.Sscanf($4.str, "%d:%d:%d", &dblcs.Hours, &dblcs.Minutes, &dblcs.Seconds)
+        if errDate != nil || errTime != nil {
+            yylex.Error(fmt.Sprintf("invalid dynamic-bootp-lease-cutoff date %q", $3.str + " " + $4.str))
+        }
+        $$.statement = dblcs
+    };
+
+// Times of day look like colon-separated hex, so they're lexed as such
+timeOfDay:
+    hexString
+    | word;
+
+failoverPeerParam:
+    failoverTok peerTok stringConst semicolon
+    {
+        $$.statement = FailoverPeerStatement{
+            Name: $3.str,
+        }
+    };
+
 hardwareparam:
     hardwareTok ethernetTok macAddr semicolon
     {
@@ -291,28 +843,146 @@ fixedaddressparam:
         $$.statement = FixedAddressStatement($2.ipList)
     };
 
-useHostDeclNamesParam:
-    useHostDeclNamesTok stateTok semicolon
+fixedAddress6Param:
+    fixedAddr6Tok ip6 semicolon
     {
-        val := false
-        cmpText := strings.ToLower($2.str)
-        if cmpText == "on" || cmpText == "true" {
-            val = true
+        $$.statement = FixedAddress6Statement($2.ip)
+    };
+
+fixedPrefix6Param:
+    fixedPrefix6Tok ip6Prefix semicolon
+    {
+        $$.statement = FixedPrefix6Statement{
+            Prefix: $2.ipNet,
         }
-        $$.statement = UseHostDeclNamesStatement(val)
+    };
+
+leaseLimitParam:
+    leaseTok limitTok number semicolon
+    {
+        $$.statement = LeaseLimitStatement($3.num)
+    };
+
+matchParam:
+    matchTok ConditionIf booleanExpr semicolon
+    {
+        $$.statement = MatchIfStatement{
+            Condition: $3.boolExpr,
+        }
+    }
+    | matchTok dataTerm semicolon
+    {
+        $$.statement = MatchStatement{
+            Data: $2.dataTerm,
+        }
+    };
+
+maxAckDelayParam:
+    maxAckDelayTok number semicolon
+    {
+        $$.statement = MaxAckDelayStatement($2.num)
+    };
+
+maxLeaseTimeParam:
+    maxLeaseTimeTok number semicolon
+    {
+        $$.statement = MaxLeaseTimeStatement($2.num)
+    };
+
+minLeaseTimeParam:
+    minLeaseTimeTok number semicolon
+    {
+        $$.statement = MinLeaseTimeStatement($2.num)
+    };
+
+spawnWithParam:
+    spawnTok withTok dataTerm semicolon
+    {
+        $$.statement = SpawnWithStatement{
+            Data: $3.dataTerm,
+        }
+    };
+
+useHostDeclNamesParam:
+    useHostDeclNamesTok onOffState semicolon
+    {
+        $$.statement = UseHostDeclNamesStatement($2.num == 1)
+    };
+
+vendorOptionSpaceParam:
+    vendorOptionSpaceTok word semicolon
+    {
+        $$.statement = VendorOptionSpaceStatement($2.str)
     };
 
 // Options, because they're weird
-optionparam: optionTok optionClause
+optionparam: optionTok optionName optionValue semicolon
+    {
+        $$.statement = optionStatement(yylex, $2.str, $3.optionTokens)
+    }
+    | optionSpaceDecl semicolon
     {
-        $$.statement = $2.statement
+        if l, ok := yylex.(*lexer); ok {
+            l.options.defineSpace($1.statement.(OptionSpaceStatement))
+        }
+    }
+    | optionTok optionName codeTok number BoolEqual optionType semicolon
+    {
+        $$.statement = optionDefinitionStatement(yylex, $2.str, $4.num, $6.strList)
+    };
+
+optionSpaceDecl:
+    optionTok spaceTok word
+    {
+        $$.statement = OptionSpaceStatement{Name: $3.str}
+    }
+    | optionSpaceDecl codeTok word number
+    {
+        $$.statement = optionSpaceParam(yylex, $1.statement.(OptionSpaceStatement), $2.str, $3.str, $4.num)
+    }
+    | optionSpaceDecl word word number
+    {
+        $$.statement = optionSpaceParam(yylex, $1.statement.(OptionSpaceStatement), $2.str, $3.str, $4.num)
+    };
+
+// An option's type is gathered as a list of words to be interpreted by
+// parseOptionType().
+optionType:
+    optionTypeWord
+    {
+        $$.strList = []string{$1.str}
+    }
+    | optionType optionTypeWord
+    {
+        $$.strList = append($1.strList, $2.str)
     };
 
-optionClause:
-    nameserversOptClause;
+optionTypeWord:
+    word
+    | number
+    | ofTok
+    | openBrace  { $$.str = "{" }
+    | closeBrace { $$.str = "}" }
+    | comma      { $$.str = "," };
 
-nameserversOptClause: optDomainNameServersTok ipList semicolon
+// The meaning of an option's value depends on the option's type, so it's
+// gathered as a list of tokens to be interpreted by optionStatement().
+optionValue:
+    optionValueToken
+    | optionValue optionValueToken
     {
-        $$.statement = DomainNameServersOption($2.ipList)
+        $$.optionTokens = append($1.optionTokens, $2.optionTokens...)
     };
+
+optionValueToken:
+    word          { $$.optionTokens = []optionToken{{word, $1.str}} }
+    | number      { $$.optionTokens = []optionToken{{number, $1.str}} }
+    | ipAddr      { $$.optionTokens = []optionToken{{ipAddr, $1.str}} }
+    | cidr        { $$.optionTokens = []optionToken{{cidr, $1.str}} }
+    | stringConst { $$.optionTokens = []optionToken{{stringConst, $1.str}} }
+    | macAddr     { $$.optionTokens = []optionToken{{macAddr, $1.str}} }
+    | hexString   { $$.optionTokens = []optionToken{{hexString, $1.str}} }
+    | ip6Addr     { $$.optionTokens = []optionToken{{ip6Addr, $1.str}} }
+    | stateTok    { $$.optionTokens = []optionToken{{stateTok, $1.str}} }
+    | comma       { $$.optionTokens = []optionToken{{comma, $1.str}} };
 %%
@@ -0,0 +1,93 @@
+package iscdhcp
+
+import "fmt"
+
+// A Position describes a location within config-file text.
+type Position struct {
+	// Filename is the name of the file the text was read from, if known.
+	Filename string
+	// Offset is the byte offset of the location, starting at 0.
+	Offset int
+	// Line and Column are the line number and byte-column of the location,
+	// both starting at 1.
+	Line   int
+	Column int
+}
+
+// after returns the position immediately following data, which is assumed
+// to begin at p.
+func (p Position) after(data []byte) Position {
+	for _, b := range data {
+		p.Offset++
+		if b == '\n' {
+			p.Line++
+			p.Column = 1
+		} else {
+			p.Column++
+		}
+	}
+	return p
+}
+
+func (p Position) String() string {
+	if p.Filename == "" {
+		return fmt.Sprintf("%d:%d", p.Line, p.Column)
+	}
+	return fmt.Sprintf("%s:%d:%d", p.Filename, p.Line, p.Column)
+}
+
+// A SourceStatement wraps a Statement returned by Decode when the Positions
+// or Comments option is given, recording where in the config-file text it
+// was found.
+type SourceStatement struct {
+	Statement
+	// Start is the position of the first byte of the statement, and End the
+	// position immediately after its last byte.
+	Start Position
+	End   Position
+	// LeadingComments holds the comments found on the lines before the
+	// statement, with an empty string standing for a blank line between
+	// two of them. OpeningComment is any comment following the opening
+	// brace of the statement's block on the same line, and TrailingComment
+	// any comment following the statement on its last line. Each includes
+	// its leading "#". They are only recorded when the Comments option is
+	// given, and are written out again by Encode.
+	LeadingComments []string
+	OpeningComment  string
+	TrailingComment string
+}
+
+// IndentedString implements the method of the same name in the Statement
+// interface.
+func (ss *SourceStatement) IndentedString(prefix string) string {
+	return indentedString(ss, prefix)
+}
+
+func (ss *SourceStatement) encode(e *encoder, prefix string) {
+	e.writeComments(prefix, ss.LeadingComments)
+	e.writeCommented(ss.OpeningComment, ss.TrailingComment, func(e *encoder) {
+		e.writeStatement(prefix, ss.Statement)
+	})
+}
+
+// Position implements the Positioned interface.
+func (ss *SourceStatement) Position() (start, end Position) {
+	return ss.Start, ss.End
+}
+
+// Positioned is implemented by Statements which know where they were found in
+// config-file text. Statements returned by Decode implement it when the
+// Positions option is given.
+type Positioned interface {
+	Statement
+	Position() (start, end Position)
+}
+
+// Unwrap returns the Statement wrapped by a *SourceStatement, or statement
+// itself if it isn't wrapped.
+func Unwrap(statement Statement) Statement {
+	if ss, ok := statement.(*SourceStatement); ok {
+		return ss.Statement
+	}
+	return statement
+}
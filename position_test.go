@@ -0,0 +1,84 @@
+package iscdhcp
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+)
+
+func TestDecode_positions(t *testing.T) {
+	data := `authoritative;
+host foo {
+	hardware ethernet 0:1:2:3:4:5;
+}
+if known {
+} else {
+	subclass "bar" "baz";
+}
+default-lease-time 600;`
+
+	statements, err := Decode(strings.NewReader(data), Positions(), Filename("dhcpd.conf"))
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	if len(statements) != 4 {
+		t.Fatalf("expected exactly 4 statements, got %d", len(statements))
+	}
+
+	// Each of these is the text spanned by a statement, and the line and
+	// column at which it starts.
+	type span struct {
+		text   string
+		line   int
+		column int
+	}
+	checkSpan := func(statement Statement, expected span) {
+		p, ok := statement.(Positioned)
+		if !ok {
+			t.Errorf("%T: expected Positioned", statement)
+			return
+		}
+		start, end := p.Position()
+		if start.Filename != "dhcpd.conf" || start.Line != expected.line || start.Column != expected.column {
+			t.Errorf("%T: expected start at dhcpd.conf:%d:%d, got %s", Unwrap(statement), expected.line, expected.column, start)
+		}
+		if data[start.Offset:end.Offset] != expected.text {
+			t.Errorf("%T: expected span %q, got %q", Unwrap(statement), expected.text, data[start.Offset:end.Offset])
+		}
+	}
+
+	checkSpan(statements[0], span{"authoritative;", 1, 1})
+	checkSpan(statements[1], span{"host foo {\n\thardware ethernet 0:1:2:3:4:5;\n}", 2, 1})
+	checkSpan(statements[2], span{"if known {\n} else {\n\tsubclass \"bar\" \"baz\";\n}", 5, 1})
+	checkSpan(statements[3], span{"default-lease-time 600;", 9, 1})
+
+	hs, ok := Unwrap(statements[1]).(HostStatement)
+	if !ok {
+		t.Fatalf("expected HostStatement, got %T", Unwrap(statements[1]))
+	}
+	checkSpan(hs.Statements[0], span{"hardware ethernet 0:1:2:3:4:5;", 3, 2})
+
+	cs, ok := Unwrap(statements[2]).(ConditionalStatement)
+	if !ok {
+		t.Fatalf("expected ConditionalStatement, got %T", Unwrap(statements[2]))
+	}
+	checkSpan(cs.SubConditionals[0].Statements[0], span{"subclass \"bar\" \"baz\";", 7, 2})
+
+	// Wrapped statements must encode just as their unwrapped counterparts
+	// would.
+	buf := &bytes.Buffer{}
+	if err := Encode(buf, statements); err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	unwrapped, err := Decode(strings.NewReader(data))
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	expectedBuf := &bytes.Buffer{}
+	if err := Encode(expectedBuf, unwrapped); err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	if buf.String() != expectedBuf.String() {
+		t.Errorf("expected %q, got %q", expectedBuf.String(), buf.String())
+	}
+}
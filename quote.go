@@ -0,0 +1,101 @@
+package iscdhcp
+
+import (
+	"fmt"
+	"strings"
+)
+
+// quoteString returns s enclosed in double quotes, in the form dhcpd writes
+// strings: quotes and backslashes are escaped with a backslash, and
+// non-printable bytes are written as three-digit octal escapes. It's used
+// wherever a Statement or data-term writes a quoted string, so that any
+// value survives being decoded again.
+func quoteString(s string) string {
+	var sb strings.Builder
+	sb.WriteByte('"')
+	for i := 0; i < len(s); i++ {
+		b := s[i]
+		switch {
+		case b == '"' || b == '\\':
+			sb.WriteByte('\\')
+			sb.WriteByte(b)
+		case b < ' ' || b > '~':
+			fmt.Fprintf(&sb, "\\%03o", b)
+		default:
+			sb.WriteByte(b)
+		}
+	}
+	sb.WriteByte('"')
+	return sb.String()
+}
+
+// unquoteString interprets the escape sequences understood by dhcpd in s,
+// the text found between a pair of double quotes: "\t", "\r", "\n" and "\b";
+// up to three octal digits; "\x" followed by up to two hex digits; and a
+// backslash followed by any other byte, which stands for that byte.
+func unquoteString(s string) (string, error) {
+	if strings.IndexByte(s, '\\') < 0 {
+		return s, nil
+	}
+
+	var sb strings.Builder
+	for i := 0; i < len(s); i++ {
+		if s[i] != '\\' {
+			sb.WriteByte(s[i])
+			continue
+		}
+		i++
+		if i == len(s) {
+			return "", fmt.Errorf("unterminated escape sequence in %q", s)
+		}
+		switch b := s[i]; {
+		case b == 't':
+			sb.WriteByte('\t')
+		case b == 'r':
+			sb.WriteByte('\r')
+		case b == 'n':
+			sb.WriteByte('\n')
+		case b == 'b':
+			sb.WriteByte('\b')
+		case b >= '0' && b <= '7':
+			var v int
+			j := i
+			for ; j < len(s) && j < i+3 && s[j] >= '0' && s[j] <= '7'; j++ {
+				v = v*8 + int(s[j]-'0')
+			}
+			if v > 0xff {
+				return "", fmt.Errorf("octal escape out of range in %q", s)
+			}
+			sb.WriteByte(byte(v))
+			i = j - 1
+		case b == 'x':
+			var v int
+			j := i + 1
+			for ; j < len(s) && j < i+3 && isHexDigit(s[j]); j++ {
+				v = v*16 + hexDigitValue(s[j])
+			}
+			if j == i+1 {
+				return "", fmt.Errorf("hex escape without digits in %q", s)
+			}
+			sb.WriteByte(byte(v))
+			i = j - 1
+		default:
+			sb.WriteByte(b)
+		}
+	}
+	return sb.String(), nil
+}
+
+func isHexDigit(b byte) bool {
+	return (b >= '0' && b <= '9') || (b >= 'a' && b <= 'f') || (b >= 'A' && b <= 'F')
+}
+
+func hexDigitValue(b byte) int {
+	switch {
+	case b >= 'a':
+		return int(b-'a') + 10
+	case b >= 'A':
+		return int(b-'A') + 10
+	}
+	return int(b - '0')
+}
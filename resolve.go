@@ -0,0 +1,141 @@
+package iscdhcp
+
+import (
+	"fmt"
+	"reflect"
+	"strings"
+)
+
+// A ResolvedParameter is a parameter in effect for a declaration or client,
+// along with the scope which supplied it.
+type ResolvedParameter struct {
+	// Parameter is the statement, as found in the config.
+	Parameter Statement
+	// Kind and Declaration identify the scope the parameter was found in,
+	// as in a Scope.
+	Kind        string
+	Declaration Statement
+	// Overridden holds the parameters of the same name from less specific
+	// scopes which this one takes precedence over, most specific first.
+	Overridden []ResolvedParameter
+}
+
+// ResolveParameters returns the parameters in effect within a declaration,
+// such as a HostStatement or SubnetStatement, found among statements. Each
+// parameter given in the declaration itself takes precedence over the same
+// parameter given in a declaration enclosing it, and so on out to the global
+// scope. A host declared outside any subnet also inherits the parameters of
+// the subnet holding its fixed address, and those enclosing that subnet.
+//
+// Options are resolved individually, so a host which sets only "routers"
+// still inherits "domain-name-servers" from its subnet. Parameters within
+// conditional statements aren't included, since they depend on the client;
+// use Simulate and Simulation.Parameters for those.
+//
+// declaration may be given with or without the *SourceStatement wrapping
+// it. A *SourceStatement is found by identity, so it picks out one of several
+// identical declarations; without one, an error is returned if more than one
+// declaration matches, as it is if none does.
+func ResolveParameters(statements []Statement, declaration Statement) ([]ResolvedParameter, error) {
+	sw := &simWalker{}
+	sw.walk(&simBlock{kind: "global", statements: statements})
+
+	target := Unwrap(declaration)
+	ss, wrapped := declaration.(*SourceStatement)
+	var found *simBlock
+	for _, b := range sw.blocks {
+		switch {
+		case wrapped && b.declaration != Statement(ss):
+			continue
+		case !wrapped && !reflect.DeepEqual(b.inner, target):
+			continue
+		case found != nil:
+			return nil, fmt.Errorf("declaration is ambiguous: %s", strings.TrimSpace(firstLine(target)))
+		}
+		found = b
+	}
+	if found == nil {
+		return nil, fmt.Errorf("declaration not found: %s", strings.TrimSpace(firstLine(target)))
+	}
+
+	blocks := []*simBlock{found}
+	if found.kind == "host" && found.enclosing("subnet") == nil {
+		if subnet, _ := fixedAddressSubnet(found, sw.subnets); subnet != nil {
+			blocks = []*simBlock{subnet, found}
+		}
+	}
+	var scopes []Scope
+	for _, b := range scopeChain(blocks) {
+		var params []Statement
+		for _, statement := range flattenIncludes(b.statements) {
+			switch Unwrap(statement).(type) {
+			case ConditionalStatement, blockStatement:
+			default:
+				params = append(params, statement)
+			}
+		}
+		scopes = append(scopes, b.scope(params))
+	}
+	return resolveScopes(scopes), nil
+}
+
+// Parameters returns the parameters in effect for the simulated client, as
+// ResolveParameters does for a declaration, but drawn from sim.Scopes and so
+// including those within the branches taken of conditional statements.
+func (sim *Simulation) Parameters() []ResolvedParameter {
+	return resolveScopes(sim.Scopes)
+}
+
+// resolveScopes merges the parameters of scopes given from least to most
+// specific. Each parameter stays in the position it first appeared in.
+func resolveScopes(scopes []Scope) []ResolvedParameter {
+	var resolved []ResolvedParameter
+	index := make(map[string]int)
+	for _, scope := range scopes {
+		for _, statement := range scope.Parameters {
+			key, ok := parameterKey(Unwrap(statement))
+			if !ok {
+				continue
+			}
+			rp := ResolvedParameter{
+				Parameter:   statement,
+				Kind:        scope.Kind,
+				Declaration: scope.Declaration,
+			}
+			if i, found := index[key]; found {
+				previous := resolved[i]
+				rp.Overridden = append([]ResolvedParameter{previous}, previous.Overridden...)
+				rp.Overridden[0].Overridden = nil
+				resolved[i] = rp
+				continue
+			}
+			index[key] = len(resolved)
+			resolved = append(resolved, rp)
+		}
+	}
+	return resolved
+}
+
+// parameterKey returns the name under which a parameter is resolved, or
+// false if the statement isn't a parameter inherited by enclosed scopes.
+func parameterKey(statement Statement) (string, bool) {
+	switch st := statement.(type) {
+	case OptionStatement:
+		return "option " + strings.TrimPrefix(st.Name, "dhcp."), true
+	case DomainNameServersOption:
+		return "option domain-name-servers", true
+	case AllowDenyStatement:
+		return "allow " + st.Flag + " " + st.ClassName, true
+	case CommentStatement, RangeStatement, Range6Statement, Prefix6Statement,
+		MatchIfStatement, MatchStatement, SpawnWithStatement,
+		OptionSpaceStatement, OptionDefinitionStatement:
+		return "", false
+	}
+	return fmt.Sprintf("%T", statement), true
+}
+
+// firstLine returns the first line of a statement's text, for identifying
+// it in error messages.
+func firstLine(statement Statement) string {
+	return strings.SplitN(statement.IndentedString(""), "\n", 2)[0]
+}
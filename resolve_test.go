@@ -0,0 +1,203 @@
+package iscdhcp
+
+import (
+	"net"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+const testResolveConfig = `
+default-lease-time 600;
+option domain-name-servers 10.0.0.53;
+option domain-name "example.com";
+shared-network "campus" {
+    max-lease-time 7200;
+    subnet 10.0.0.0 netmask 255.255.255.0 {
+        option routers 10.0.0.1;
+        default-lease-time 1200;
+        group {
+            option domain-name-servers 10.0.0.54, 10.0.0.55;
+            host printer {
+                hardware ethernet 0:1a:2b:3c:4d:5e;
+                default-lease-time 86400;
+                if known {
+                    option domain-name "ignored.example.com";
+                }
+            }
+        }
+    }
+}
+host laptop {
+    hardware ethernet 0:1a:2b:3c:4d:5f;
+    fixed-address 10.0.0.60;
+}
+`
+
+// describe summarizes resolved parameters as "kind: statement" lines.
+func describe(resolved []ResolvedParameter) []string {
+	var lines []string
+	for _, rp := range resolved {
+		lines = append(lines, rp.Kind+": "+strings.TrimSpace(Unwrap(rp.Parameter).IndentedString("")))
+	}
+	return lines
+}
+
+func TestResolveParameters(t *testing.T) {
+	statements, err := Decode(strings.NewReader(testResolveConfig), Positions())
+	if err != nil {
+		t.Fatalf("Decode(): %s", err)
+	}
+	subnet := Unwrap(Unwrap(statements[3]).(SharedNetworkStatement).Statements[1]).(SubnetStatement)
+	group := Unwrap(subnet.Statements[2]).(GroupStatement)
+	printer := group.Statements[1]
+	laptop := statements[4]
+
+	testCases := []struct {
+		name        string
+		declaration Statement
+		expected    []string
+	}{
+		{
+			name:        "host within a group",
+			declaration: printer,
+			expected: []string{
+				"host: default-lease-time 86400;",
+				"group: option domain-name-servers 10.0.0.54, 10.0.0.55;",
+				`global: option domain-name "example.com";`,
+				"shared-network: max-lease-time 7200;",
+				"subnet: option routers 10.0.0.1;",
+				"host: hardware ethernet 0:1a:2b:3c:4d:5e;",
+			},
+		},
+		{
+			name:        "host with a fixed address",
+			declaration: laptop,
+			expected: []string{
+				"subnet: default-lease-time 1200;",
+				"global: option domain-name-servers 10.0.0.53;",
+				`global: option domain-name "example.com";`,
+				"shared-network: max-lease-time 7200;",
+				"subnet: option routers 10.0.0.1;",
+				"host: hardware ethernet 0:1a:2b:3c:4d:5f;",
+				"host: fixed-address 10.0.0.60;",
+			},
+		},
+		{
+			name:        "subnet",
+			declaration: subnet,
+			expected: []string{
+				"subnet: default-lease-time 1200;",
+				"global: option domain-name-servers 10.0.0.53;",
+				`global: option domain-name "example.com";`,
+				"shared-network: max-lease-time 7200;",
+				"subnet: option routers 10.0.0.1;",
+			},
+		},
+	}
+	for _, tc := range testCases {
+		resolved, err := ResolveParameters(statements, tc.declaration)
+		if err != nil {
+			t.Errorf("%s: unexpected error: %s", tc.name, err)
+			continue
+		}
+		if actual := describe(resolved); !reflect.DeepEqual(actual, tc.expected) {
+			t.Errorf("%s: expected:\n%s\ngot:\n%s", tc.name, strings.Join(tc.expected, "\n"), strings.Join(actual, "\n"))
+		}
+	}
+
+	// provenance of the printer's lease time
+	resolved, _ := ResolveParameters(statements, printer)
+	rp := resolved[0]
+	if ss, ok := rp.Parameter.(*SourceStatement); !ok || ss.Start.Line != 14 {
+		t.Errorf("expected the parameter's position to be kept, got %#v", rp.Parameter)
+	}
+	if Unwrap(rp.Declaration).(HostStatement).Hostname != "printer" {
+		t.Errorf("expected the parameter to come from the printer, got %#v", rp.Declaration)
+	}
+	if overridden := describe(rp.Overridden); !reflect.DeepEqual(overridden, []string{
+		"subnet: default-lease-time 1200;",
+		"global: default-lease-time 600;",
+	}) {
+		t.Errorf("unexpected overridden parameters %q", overridden)
+	}
+}
+
+func TestResolveParameters_notFound(t *testing.T) {
+	statements, err := Decode(strings.NewReader(testResolveConfig))
+	if err != nil {
+		t.Fatalf("Decode(): %s", err)
+	}
+	_, err = ResolveParameters(statements, HostStatement{Hostname: "nobody"})
+	if err == nil || err.Error() != "declaration not found: host nobody {" {
+		t.Errorf("unexpected error: %v", err)
+	}
+}
+
+func TestResolveParameters_identicalDeclarations(t *testing.T) {
+	config := `subnet 10.0.0.0 netmask 255.255.255.0 {
+    option routers 10.0.0.1;
+    host kiosk {
+        default-lease-time 600;
+    }
+}
+subnet 10.0.1.0 netmask 255.255.255.0 {
+    option routers 10.0.1.1;
+    host kiosk {
+        default-lease-time 600;
+    }
+}
+`
+	statements, err := Decode(strings.NewReader(config), Positions())
+	if err != nil {
+		t.Fatalf("Decode(): %s", err)
+	}
+	second := Unwrap(statements[1]).(SubnetStatement).Statements[1]
+	resolved, err := ResolveParameters(statements, second)
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	expected := []string{
+		"subnet: option routers 10.0.1.1;",
+		"host: default-lease-time 600;",
+	}
+	if actual := describe(resolved); !reflect.DeepEqual(actual, expected) {
+		t.Errorf("expected:\n%s\ngot:\n%s", strings.Join(expected, "\n"), strings.Join(actual, "\n"))
+	}
+	if resolved[1].Declaration != second {
+		t.Errorf("expected the host to be the one given, got %#v", resolved[1].Declaration)
+	}
+
+	// without positions the two hosts can't be told apart
+	statements, err = Decode(strings.NewReader(config))
+	if err != nil {
+		t.Fatalf("Decode(): %s", err)
+	}
+	_, err = ResolveParameters(statements, statements[1].(SubnetStatement).Statements[1])
+	if err == nil || err.Error() != "declaration is ambiguous: host kiosk {" {
+		t.Errorf("unexpected error: %v", err)
+	}
+}
+
+func TestSimulation_Parameters(t *testing.T) {
+	statements, err := Decode(strings.NewReader(testResolveConfig))
+	if err != nil {
+		t.Fatalf("Decode(): %s", err)
+	}
+	mac, _ := net.ParseMAC("00:1a:2b:3c:4d:5e")
+	sim, err := Simulate(statements, ClientRequest{HardwareAddress: mac}, net.ParseIP("10.0.0.1"))
+	if err != nil {
+		t.Fatalf("Simulate(): %s", err)
+	}
+	expected := []string{
+		"host: default-lease-time 86400;",
+		"group: option domain-name-servers 10.0.0.54, 10.0.0.55;",
+		`host: option domain-name "ignored.example.com";`,
+		"shared-network: max-lease-time 7200;",
+		"subnet: option routers 10.0.0.1;",
+		"host: hardware ethernet 0:1a:2b:3c:4d:5e;",
+	}
+	if actual := describe(sim.Parameters()); !reflect.DeepEqual(actual, expected) {
+		t.Errorf("expected:\n%s\ngot:\n%s", strings.Join(expected, "\n"), strings.Join(actual, "\n"))
+	}
+}
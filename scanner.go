@@ -7,6 +7,7 @@ import (
 // scanner analyzes a stream of bytes, one byte at a time, and emits codes
 // denoting the beginning/end of lexical symbols
 type scanner struct {
+	// stateStack holds the scanner's states, the current one last.
 	stateStack []int
 }
 
@@ -15,94 +16,178 @@ func (l *scanner) init() {
 }
 
 func (l *scanner) step(b byte) (int, error) {
-	for re, spec := range lexMap[l.stateStack[0]] {
-		if re.Match([]byte{b}) {
-			for _, newState := range spec.newStates {
-				switch newState {
-				case scanSameState:
-					continue
-				case scanPopState:
-					l.stateStack = l.stateStack[1:]
-					if len(l.stateStack) == 0 {
-						return codeContinue, contextError("state stack is empty")
-					}
-				default:
-					l.stateStack = append([]int{newState}, l.stateStack...)
-				}
+	t := scanTable[l.stateStack[len(l.stateStack)-1]][b]
+	if t == nil {
+		return codeContinue, nil
+	}
+	for _, newState := range t.newStates {
+		switch newState {
+		case scanSameState:
+			continue
+		case scanPopState:
+			l.stateStack = l.stateStack[:len(l.stateStack)-1]
+			if len(l.stateStack) == 0 {
+				return codeContinue, contextError("state stack is empty")
 			}
-			return spec.code, nil
+		default:
+			l.stateStack = append(l.stateStack, newState)
 		}
 	}
+	return t.code, nil
+}
+
+// scanTable holds, for each scanner state, the transition to take on reading
+// each possible byte, or nil if the byte doesn't cause a transition. It's
+// built from lexTable, which is easier to read and maintain, so that step
+// needn't match regexps against every byte.
+var scanTable = buildScanTable()
 
-	return codeContinue, nil
+func buildScanTable() *[scanStateCount][256]*transition {
+	var table [scanStateCount][256]*transition
+	for state := range lexTable {
+		for i := range lexTable[state] {
+			t := &lexTable[state][i]
+			for b := 0; b < 256; b++ {
+				if table[state][b] == nil && t.class.Match([]byte{byte(b)}) {
+					table[state][b] = t
+				}
+			}
+		}
+	}
+	return &table
 }
 
-type transitionSpec struct {
+// A transition describes how the scanner reacts to a byte: if the byte is in
+// class, the scanner emits code and applies each of newStates to its stack in
+// turn.
+type transition struct {
+	class     *regexp.Regexp
 	code      int
 	newStates []int
 }
 
-var lexMap = map[int]map[*regexp.Regexp]transitionSpec{
-	scanStateFindAnyBegin: map[*regexp.Regexp]transitionSpec{
-		regexp.MustCompile(`\{`): {
+// lexTable lists, for each scanner state, the transitions the scanner may
+// take. They're in priority order: if more than one transition's class
+// contains a byte, the first of them is taken. A byte in none of them is
+// appended to the token in progress.
+var lexTable = [scanStateCount][]transition{
+	scanStateFindAnyBegin: {
+		{
+			class:     regexp.MustCompile(`\{`),
 			code:      codeBlockBegin,
 			newStates: []int{scanSameState},
 		},
-		regexp.MustCompile(`}`): {
+		{
+			class:     regexp.MustCompile(`}`),
 			code:      codeBlockEnd,
 			newStates: []int{scanSameState},
 		},
-		regexp.MustCompile("[0-9a-zA-Z!=~]"): {
+		{
+			class:     regexp.MustCompile("[0-9a-zA-Z!=~:/-]"),
 			code:      codeIdentifierBegin,
 			newStates: []int{scanStateFindIdentifierEnd},
 		},
-		regexp.MustCompile(`[\s\n]`): {
+		{
+			class:     regexp.MustCompile(`[\s\n]`),
 			code:      codeWhitespace,
 			newStates: []int{scanSameState},
 		},
-		regexp.MustCompile(`"`): {
+		{
+			class:     regexp.MustCompile(`"`),
 			code:      codeStringBegin,
 			newStates: []int{scanStateFindStringEnd},
 		},
-		regexp.MustCompile(`#`): {
+		{
+			class:     regexp.MustCompile(`#`),
 			code:      codeCommentBegin,
 			newStates: []int{scanStateFindCommentEnd},
 		},
-		regexp.MustCompile(`;`): {
+		{
+			class:     regexp.MustCompile(`;`),
 			code:      codeSemicolon,
 			newStates: []int{scanSameState},
 		},
+		{
+			class:     regexp.MustCompile(`,`),
+			code:      codeComma,
+			newStates: []int{scanSameState},
+		},
+		{
+			class:     regexp.MustCompile(`\(`),
+			code:      codeParenOpen,
+			newStates: []int{scanSameState},
+		},
+		{
+			class:     regexp.MustCompile(`\)`),
+			code:      codeParenClose,
+			newStates: []int{scanSameState},
+		},
 	},
-	scanStateFindIdentifierEnd: map[*regexp.Regexp]transitionSpec{
-		regexp.MustCompile(`[\s]`): {
+	scanStateFindIdentifierEnd: {
+		{
+			class:     regexp.MustCompile(`[\s]`),
 			code:      codeIdentifierEnd,
 			newStates: []int{scanPopState},
 		},
-		regexp.MustCompile(`{`): {
+		{
+			class:     regexp.MustCompile(`{`),
 			code:      codeBlockBegin,
 			newStates: []int{scanPopState},
 		},
-		regexp.MustCompile(`"`): {
+		{
+			class:     regexp.MustCompile(`}`),
+			code:      codeBlockEnd,
+			newStates: []int{scanPopState},
+		},
+		{
+			class:     regexp.MustCompile(`"`),
 			code:      codeStringBegin,
 			newStates: []int{scanPopState, scanStateFindStringEnd},
 		},
-		regexp.MustCompile(`;`): {
+		{
+			class:     regexp.MustCompile(`;`),
 			code:      codeSemicolon,
 			newStates: []int{scanPopState},
 		},
-		regexp.MustCompile(`,`): {
+		{
+			class:     regexp.MustCompile(`,`),
 			code:      codeComma,
 			newStates: []int{scanPopState},
 		},
+		{
+			class:     regexp.MustCompile(`\(`),
+			code:      codeParenOpen,
+			newStates: []int{scanPopState},
+		},
+		{
+			class:     regexp.MustCompile(`\)`),
+			code:      codeParenClose,
+			newStates: []int{scanPopState},
+		},
 	},
-	scanStateFindStringEnd: map[*regexp.Regexp]transitionSpec{
-		regexp.MustCompile(`"`): {
+	scanStateFindStringEnd: {
+		{
+			class:     regexp.MustCompile(`"`),
 			code:      codeStringEnd,
 			newStates: []int{scanPopState},
 		},
+		{
+			class:     regexp.MustCompile(`\\`),
+			code:      codeContinue,
+			newStates: []int{scanStateFindStringEscapeEnd},
+		},
+	},
+	// the byte following a backslash within a string never ends it
+	scanStateFindStringEscapeEnd: {
+		{
+			class:     regexp.MustCompile(`[\s\S]`),
+			code:      codeContinue,
+			newStates: []int{scanPopState},
+		},
 	},
-	scanStateFindCommentEnd: map[*regexp.Regexp]transitionSpec{
-		regexp.MustCompile(`\n`): {
+	scanStateFindCommentEnd: {
+		{
+			class:     regexp.MustCompile(`\n`),
 			code:      codeCommentEnd,
 			newStates: []int{scanPopState},
 		},
@@ -115,7 +200,10 @@ const (
 	scanStateFindAnyBegin
 	scanStateFindIdentifierEnd
 	scanStateFindStringEnd
+	scanStateFindStringEscapeEnd
 	scanStateFindCommentEnd
+	// scanStateCount is the number of states, not a state itself.
+	scanStateCount
 )
 
 const (
@@ -131,4 +219,6 @@ const (
 	codeCommentEnd
 	codeSemicolon
 	codeComma
+	codeParenOpen
+	codeParenClose
 )
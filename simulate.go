@@ -0,0 +1,501 @@
+package iscdhcp
+
+import (
+	"bytes"
+	"fmt"
+	"net"
+	"strings"
+)
+
+// A Simulation reports what dhcpd would make of a client's request, as
+// worked out by Simulate.
+type Simulation struct {
+	// SharedNetwork is the shared network the request arrived on, if the
+	// subnet it arrived on is part of one.
+	SharedNetwork *SharedNetworkStatement
+	// Subnet is the subnet the client would be given an address from: the
+	// one holding its fixed address, or the pool its address would be
+	// allocated from, or failing those the one the request arrived on.
+	Subnet *SubnetStatement
+	// Pool is the first pool on the network which permits the client, or
+	// nil if the client has a fixed address or no pool permits it.
+	Pool *PoolStatement
+	// Host is the host declaration matching the client, if any.
+	Host *HostStatement
+	// Classes holds the names of the classes the client is a member of, in
+	// the order they're declared.
+	Classes []string
+	// Branches records each conditional statement evaluated, in the order
+	// they were evaluated.
+	Branches []Branch
+	// Scopes lists the scopes whose parameters apply to the client, from
+	// least to most specific: the global scope, then the declarations
+	// enclosing the subnet and the subnet itself, the pool, each class, and
+	// finally the declarations enclosing the host and the host itself.
+	Scopes []Scope
+}
+
+// A Branch records the evaluation of a conditional statement.
+type Branch struct {
+	// Conditional is the statement, as found in the config.
+	Conditional Statement
+	// Taken is the index of the branch taken: 0 for the "if" branch, i+1 for
+	// the branch of SubConditionals[i], or -1 if none was.
+	Taken int
+}
+
+// A Scope is a declaration whose parameters apply to a client.
+type Scope struct {
+	// Kind is the keyword of the declaration, e.g. "subnet" or "host", or
+	// "global" for the statements outside any declaration.
+	Kind string
+	// Declaration is the declaration as found in the config, or nil for
+	// the global scope.
+	Declaration Statement
+	// Parameters holds the statements which apply to the client: those
+	// directly within the declaration's block, other than declarations, and
+	// those within the branches taken of its conditional statements.
+	Parameters []Statement
+}
+
+// Simulate works out what dhcpd, configured with statements, would make of
+// a request from the client described by cr. addr is the address the request
+// came from: the relay agent's giaddr if the request was relayed, or else
+// the address of the interface it was received on.
+//
+// Hosts are matched by hardware address, or by a dhcp-client-identifier
+// option given in the host declaration. Pools are chosen according to their
+// allow and deny statements. The Known, Static and HostDeclName fields of cr
+// are set according to the host found before conditions are evaluated.
+//
+// An error is returned if no subnet holds addr, or if a condition can't be
+// evaluated.
+func Simulate(statements []Statement, cr ClientRequest, addr net.IP) (*Simulation, error) {
+	sw := &simWalker{}
+	global := &simBlock{kind: "global", statements: statements}
+	sw.walk(global)
+
+	// find the network the request arrived on
+	var arrival *simBlock
+	for _, subnet := range sw.subnets {
+		if subnetContains(subnet.inner.(SubnetStatement), addr) {
+			arrival = subnet
+			break
+		}
+	}
+	if arrival == nil {
+		return nil, fmt.Errorf("no subnet declaration for address %s", addr)
+	}
+	network := arrival
+	if sn := arrival.enclosing("shared-network"); sn != nil {
+		network = sn
+	}
+	var subnets []*simBlock
+	for _, subnet := range sw.subnets {
+		if subnet == network || subnet.enclosing("shared-network") == network {
+			subnets = append(subnets, subnet)
+		}
+	}
+
+	sim := &Simulation{}
+	if network.kind == "shared-network" {
+		sn := network.inner.(SharedNetworkStatement)
+		sim.SharedNetwork = &sn
+	}
+
+	// a host with a fixed address on this network is preferred to one
+	// without, which is preferred to one with a fixed address elsewhere
+	var subnet, fixedHost, dynamicHost, elsewhereHost *simBlock
+	for _, candidate := range sw.hosts {
+		if !hostMatches(candidate, cr) {
+			continue
+		}
+		fixedSubnet, hasFixed := fixedAddressSubnet(candidate, subnets)
+		switch {
+		case fixedSubnet != nil && fixedHost == nil:
+			fixedHost, subnet = candidate, fixedSubnet
+		case !hasFixed && dynamicHost == nil:
+			dynamicHost = candidate
+		case hasFixed && fixedSubnet == nil && elsewhereHost == nil:
+			elsewhereHost = candidate
+		}
+	}
+	host := fixedHost
+	if host == nil {
+		host = dynamicHost
+	}
+	if host == nil {
+		host = elsewhereHost
+	}
+	cr.Known = host != nil
+	cr.Static = fixedHost != nil
+	if host != nil {
+		hs := host.inner.(HostStatement)
+		sim.Host = &hs
+		cr.HostDeclName = hs.Hostname
+	}
+
+	// classes need to know whether the client is known
+	var classScopes []*simBlock
+	for _, class := range sw.classes {
+		member, subclass, err := sw.classMember(class, cr)
+		if err != nil {
+			return nil, err
+		}
+		if member {
+			sim.Classes = append(sim.Classes, class.inner.(ClassStatement).Name)
+			classScopes = append(classScopes, class)
+			if subclass != nil {
+				classScopes = append(classScopes, subclass)
+			}
+		}
+	}
+
+	var pool *simBlock
+	if subnet == nil {
+		for _, candidate := range sw.pools {
+			if candidate.parent != network && !containsBlock(subnets, candidate.parent) {
+				continue
+			}
+			if poolPermits(candidate, sim.Classes, cr.Known) {
+				pool = candidate
+				break
+			}
+		}
+		if pool != nil {
+			ps := pool.inner.(PoolStatement)
+			sim.Pool = &ps
+			subnet = poolSubnet(pool, subnets)
+		}
+		if subnet == nil {
+			subnet = arrival
+		}
+	}
+	ss := subnet.inner.(SubnetStatement)
+	sim.Subnet = &ss
+
+	// gather the scopes, outermost first, without repeating any enclosing
+	// more than one of the declarations which apply
+	blocks := []*simBlock{subnet}
+	if pool != nil {
+		blocks = append(blocks, pool)
+	}
+	blocks = append(blocks, classScopes...)
+	if host != nil {
+		blocks = append(blocks, host)
+	}
+	for _, b := range scopeChain(blocks) {
+		params, err := sim.parameters(b.statements, cr)
+		if err != nil {
+			return nil, err
+		}
+		sim.Scopes = append(sim.Scopes, b.scope(params))
+	}
+	return sim, nil
+}
+
+// scopeChain returns the given declarations, each preceded by those
+// enclosing it, without repeating any which encloses more than one.
+func scopeChain(blocks []*simBlock) []*simBlock {
+	var chain []*simBlock
+	seen := make(map[*simBlock]bool)
+	for _, b := range blocks {
+		var ancestors []*simBlock
+		for ; b != nil; b = b.parent {
+			ancestors = append([]*simBlock{b}, ancestors...)
+		}
+		for _, ancestor := range ancestors {
+			if !seen[ancestor] {
+				seen[ancestor] = true
+				chain = append(chain, ancestor)
+			}
+		}
+	}
+	return chain
+}
+
+// parameters returns the statements of a block which apply to the client,
+// evaluating conditional statements and recording the branches taken.
+func (sim *Simulation) parameters(statements []Statement, cr ClientRequest) ([]Statement, error) {
+	var params []Statement
+	for _, statement := range flattenIncludes(statements) {
+		switch st := Unwrap(statement).(type) {
+		case ConditionalStatement:
+			branch := Branch{Conditional: statement, Taken: -1}
+			arms := append([]ConditionalStatement{st}, st.SubConditionals...)
+			for i, arm := range arms {
+				taken := arm.Operator == ConditionElse
+				if !taken {
+					var err error
+					if taken, err = arm.Condition.Evaluate(cr); err != nil {
+						return nil, fmt.Errorf("evaluating %q: %s", arm.Condition.string(), err)
+					}
+				}
+				if taken {
+					branch.Taken = i
+					break
+				}
+			}
+			sim.Branches = append(sim.Branches, branch)
+			if branch.Taken != -1 {
+				nested, err := sim.parameters(arms[branch.Taken].Statements, cr)
+				if err != nil {
+					return nil, err
+				}
+				params = append(params, nested...)
+			}
+		case blockStatement:
+			// declarations are scopes of their own
+		default:
+			params = append(params, statement)
+		}
+	}
+	return params, nil
+}
+
+// A simBlock is a declaration found by a simWalker.
+type simBlock struct {
+	kind        string
+	declaration Statement
+	inner       Statement
+	statements  []Statement
+	parent      *simBlock
+}
+
+// scope returns the Scope of b, holding the given parameters.
+func (b *simBlock) scope(params []Statement) Scope {
+	scope := Scope{Kind: b.kind, Parameters: params}
+	if b.kind != "global" {
+		scope.Declaration = b.declaration
+	}
+	return scope
+}
+
+// enclosing returns the nearest declaration of the given kind enclosing b.
+func (b *simBlock) enclosing(kind string) *simBlock {
+	for p := b.parent; p != nil; p = p.parent {
+		if p.kind == kind {
+			return p
+		}
+	}
+	return nil
+}
+
+func containsBlock(blocks []*simBlock, b *simBlock) bool {
+	for _, candidate := range blocks {
+		if candidate == b {
+			return true
+		}
+	}
+	return false
+}
+
+// A simWalker gathers the declarations in a config.
+type simWalker struct {
+	blocks     []*simBlock
+	subnets    []*simBlock
+	pools      []*simBlock
+	hosts      []*simBlock
+	classes    []*simBlock
+	subclasses []*simBlock
+}
+
+func (sw *simWalker) walk(parent *simBlock) {
+	for _, statement := range flattenIncludes(parent.statements) {
+		b := &simBlock{declaration: statement, inner: Unwrap(statement), parent: parent}
+		switch st := b.inner.(type) {
+		case SubnetStatement:
+			b.kind, b.statements = "subnet", st.Statements
+			sw.subnets = append(sw.subnets, b)
+		case SharedNetworkStatement:
+			b.kind, b.statements = "shared-network", st.Statements
+		case GroupStatement:
+			b.kind, b.statements = "group", st.Statements
+		case PoolStatement:
+			b.kind, b.statements = "pool", st.Statements
+			sw.pools = append(sw.pools, b)
+		case HostStatement:
+			b.kind, b.statements = "host", st.Statements
+			sw.hosts = append(sw.hosts, b)
+		case ClassStatement:
+			b.kind, b.statements = "class", st.Statements
+			sw.classes = append(sw.classes, b)
+		case SubclassStatement:
+			b.kind, b.statements = "subclass", st.Statements
+			sw.subclasses = append(sw.subclasses, b)
+		default:
+			continue
+		}
+		sw.blocks = append(sw.blocks, b)
+		sw.walk(b)
+	}
+}
+
+// flattenIncludes replaces each IncludeStatement in statements with the
+// Statements attached to it by DecodeFS.
+func flattenIncludes(statements []Statement) []Statement {
+	var flat []Statement
+	for _, statement := range statements {
+		if is, ok := Unwrap(statement).(IncludeStatement); ok {
+			flat = append(flat, flattenIncludes(is.Statements)...)
+			continue
+		}
+		flat = append(flat, statement)
+	}
+	return flat
+}
+
+// classMember reports whether the client is a member of a class, and the
+// subclass through which it's a member, if any.
+func (sw *simWalker) classMember(class *simBlock, cr ClientRequest) (bool, *simBlock, error) {
+	cs := class.inner.(ClassStatement)
+	for _, statement := range flattenIncludes(cs.Statements) {
+		switch st := Unwrap(statement).(type) {
+		case MatchIfStatement:
+			member, err := st.Condition.Evaluate(cr)
+			if err != nil {
+				return false, nil, fmt.Errorf("class %q: %s", cs.Name, err)
+			}
+			if member {
+				return true, nil, nil
+			}
+		case MatchStatement:
+			data, found, err := EvaluateData(st.Data, cr)
+			if err != nil {
+				return false, nil, fmt.Errorf("class %q: %s", cs.Name, err)
+			}
+			if !found {
+				continue
+			}
+			for _, subclass := range sw.subclasses {
+				sc := subclass.inner.(SubclassStatement)
+				if sc.ClassName != cs.Name {
+					continue
+				}
+				scData, scFound, err := EvaluateData(sc.Data, cr)
+				if err != nil {
+					return false, nil, fmt.Errorf("subclass %q %s: %s", sc.ClassName, sc.Data, err)
+				}
+				if scFound && bytes.Equal(data, scData) {
+					return true, subclass, nil
+				}
+			}
+		case SpawnWithStatement:
+			// a subclass is spawned for any client with a value
+			_, found, err := EvaluateData(st.Data, cr)
+			if err != nil {
+				return false, nil, fmt.Errorf("class %q: %s", cs.Name, err)
+			}
+			if found {
+				return true, nil, nil
+			}
+		}
+	}
+	return false, nil, nil
+}
+
+// hostMatches reports whether a host declaration matches the client.
+func hostMatches(host *simBlock, cr ClientRequest) bool {
+	for _, statement := range flattenIncludes(host.statements) {
+		switch st := Unwrap(statement).(type) {
+		case HardwareStatement:
+			mac, err := hexStringOctets(st.HardwareAddress)
+			if err == nil && len(cr.HardwareAddress) != 0 && bytes.Equal(mac, cr.HardwareAddress) {
+				return true
+			}
+		case OptionStatement:
+			if st.Name != "dhcp-client-identifier" || len(st.Values) != 1 || len(st.Values[0]) != 1 {
+				continue
+			}
+			term, ok := st.Values[0][0].(fmt.Stringer)
+			if !ok {
+				continue
+			}
+			id, found, err := EvaluateData(term, cr)
+			clientID, sent := cr.Options["dhcp-client-identifier"]
+			if err == nil && found && sent && bytes.Equal(id, clientID) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// fixedAddressSubnet returns the subnet among subnets holding one of the
+// host's fixed addresses, and whether it has any fixed address at all.
+func fixedAddressSubnet(host *simBlock, subnets []*simBlock) (*simBlock, bool) {
+	hasFixed := false
+	for _, statement := range flattenIncludes(host.statements) {
+		fas, ok := Unwrap(statement).(FixedAddressStatement)
+		if !ok {
+			continue
+		}
+		hasFixed = true
+		for _, ip := range fas {
+			for _, subnet := range subnets {
+				if subnetContains(subnet.inner.(SubnetStatement), ip) {
+					return subnet, true
+				}
+			}
+		}
+	}
+	return nil, hasFixed
+}
+
+// poolSubnet returns the subnet a pool's addresses belong to.
+func poolSubnet(pool *simBlock, subnets []*simBlock) *simBlock {
+	if pool.parent.kind == "subnet" {
+		return pool.parent
+	}
+	for _, statement := range flattenIncludes(pool.statements) {
+		if rs, ok := Unwrap(statement).(RangeStatement); ok {
+			for _, subnet := range subnets {
+				if subnetContains(subnet.inner.(SubnetStatement), rs.Low) {
+					return subnet
+				}
+			}
+		}
+	}
+	return nil
+}
+
+// poolPermits reports whether a pool's allow and deny statements permit a
+// client. If there are any allow statements the client must match one, and
+// it mustn't match any deny or ignore statement.
+func poolPermits(pool *simBlock, classes []string, known bool) bool {
+	allowed, hasAllow := false, false
+	for _, statement := range flattenIncludes(pool.statements) {
+		ads, ok := Unwrap(statement).(AllowDenyStatement)
+		if !ok {
+			continue
+		}
+		var matches bool
+		switch strings.Replace(ads.Flag, "-", " ", -1) {
+		case "all clients", "unauthenticated clients":
+			matches = true
+		case "known clients":
+			matches = known
+		case "unknown clients":
+			matches = !known
+		case "members of":
+			for _, class := range classes {
+				matches = matches || class == ads.ClassName
+			}
+		default:
+			// not a permit, or one which can't apply to the client
+			continue
+		}
+		if ads.Operator == AccessAllow {
+			hasAllow = true
+			allowed = allowed || matches
+		} else if matches {
+			return false
+		}
+	}
+	return allowed || !hasAllow
+}
+
+func subnetContains(ss SubnetStatement, ip net.IP) bool {
+	ipNet := net.IPNet{IP: ss.SubnetNumber.To4(), Mask: net.IPMask(ss.Netmask.To4())}
+	return ip != nil && ipNet.IP != nil && ipNet.Contains(ip)
+}
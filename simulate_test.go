@@ -0,0 +1,229 @@
+package iscdhcp
+
+import (
+	"net"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+const testSimulationConfig = `
+default-lease-time 600;
+class "pxe" {
+    match if substring(option vendor-class-identifier, 0, 9) = "PXEClient";
+}
+class "vendors" {
+    match option user-class;
+}
+subclass "vendors" "iPXE" {
+    max-lease-time 60;
+}
+shared-network "campus" {
+    subnet 10.0.0.0 netmask 255.255.255.0 {
+        option routers 10.0.0.1;
+    }
+    subnet 10.0.1.0 netmask 255.255.255.0 {
+        option routers 10.0.1.1;
+        pool {
+            allow members of "pxe";
+            range 10.0.1.100 10.0.1.199;
+        }
+        pool {
+            deny unknown-clients;
+            range 10.0.1.200 10.0.1.249;
+        }
+    }
+}
+subnet 192.168.0.0 netmask 255.255.255.0 {
+    if option user-class = "iPXE" {
+        default-lease-time 60;
+    }
+    elsif known {
+        default-lease-time 3600;
+    }
+    else {
+        default-lease-time 300;
+    }
+    pool {
+        range 192.168.0.100 192.168.0.199;
+    }
+}
+group {
+    default-lease-time 7200;
+    host printer {
+        hardware ethernet 0:1a:2b:3c:4d:5e;
+        fixed-address 10.0.0.50;
+    }
+}
+host laptop {
+    hardware ethernet 00:1a:2b:3c:4d:5f;
+}
+`
+
+func TestSimulate(t *testing.T) {
+	statements, err := Decode(strings.NewReader(testSimulationConfig))
+	if err != nil {
+		t.Fatalf("Decode(): %s", err)
+	}
+	printerMAC, _ := net.ParseMAC("00:1a:2b:3c:4d:5e")
+	laptopMAC, _ := net.ParseMAC("00:1a:2b:3c:4d:5f")
+	strangerMAC, _ := net.ParseMAC("00:00:00:00:00:01")
+	pxeOptions := map[string][]byte{"vendor-class-identifier": []byte("PXEClient:Arch:00000")}
+	ipxeOptions := map[string][]byte{"user-class": []byte("iPXE")}
+
+	testCases := []struct {
+		name     string
+		cr       ClientRequest
+		addr     string
+		subnet   string
+		pool     string // first range in the pool
+		host     string
+		classes  []string
+		scopes   []string
+		branches []int
+	}{
+		{
+			name:   "fixed address on the shared network",
+			cr:     ClientRequest{HardwareAddress: printerMAC},
+			addr:   "10.0.1.1",
+			subnet: "10.0.0.0",
+			host:   "printer",
+			scopes: []string{"global", "shared-network", "subnet", "group", "host"},
+		},
+		{
+			name:    "PXE client gets the first pool",
+			cr:      ClientRequest{HardwareAddress: strangerMAC, Options: pxeOptions},
+			addr:    "10.0.0.1",
+			subnet:  "10.0.1.0",
+			pool:    "10.0.1.100",
+			classes: []string{"pxe"},
+			scopes:  []string{"global", "shared-network", "subnet", "pool", "class"},
+		},
+		{
+			name:   "known client gets the second pool",
+			cr:     ClientRequest{HardwareAddress: laptopMAC},
+			addr:   "10.0.0.1",
+			subnet: "10.0.1.0",
+			pool:   "10.0.1.200",
+			host:   "laptop",
+			scopes: []string{"global", "shared-network", "subnet", "pool", "host"},
+		},
+		{
+			name:   "unknown client gets no pool",
+			cr:     ClientRequest{HardwareAddress: strangerMAC},
+			addr:   "10.0.0.1",
+			subnet: "10.0.0.0",
+			scopes: []string{"global", "shared-network", "subnet"},
+		},
+		{
+			name:     "iPXE client takes the if branch",
+			cr:       ClientRequest{HardwareAddress: strangerMAC, Options: ipxeOptions},
+			addr:     "192.168.0.1",
+			subnet:   "192.168.0.0",
+			pool:     "192.168.0.100",
+			classes:  []string{"vendors"},
+			scopes:   []string{"global", "subnet", "pool", "class", "subclass"},
+			branches: []int{0},
+		},
+		{
+			name:     "known client takes the elsif branch",
+			cr:       ClientRequest{HardwareAddress: laptopMAC},
+			addr:     "192.168.0.1",
+			subnet:   "192.168.0.0",
+			pool:     "192.168.0.100",
+			host:     "laptop",
+			scopes:   []string{"global", "subnet", "pool", "host"},
+			branches: []int{1},
+		},
+		{
+			name:     "unknown client takes the else branch",
+			cr:       ClientRequest{HardwareAddress: strangerMAC},
+			addr:     "192.168.0.1",
+			subnet:   "192.168.0.0",
+			pool:     "192.168.0.100",
+			scopes:   []string{"global", "subnet", "pool"},
+			branches: []int{2},
+		},
+	}
+
+	for _, tc := range testCases {
+		sim, err := Simulate(statements, tc.cr, net.ParseIP(tc.addr))
+		if err != nil {
+			t.Errorf("%s: unexpected error: %s", tc.name, err)
+			continue
+		}
+		if sim.Subnet == nil || sim.Subnet.SubnetNumber.String() != tc.subnet {
+			t.Errorf("%s: expected subnet %s, got %v", tc.name, tc.subnet, sim.Subnet)
+		}
+		var pool string
+		if sim.Pool != nil {
+			for _, statement := range sim.Pool.Statements {
+				if rs, ok := statement.(RangeStatement); ok {
+					pool = rs.Low.String()
+				}
+			}
+		}
+		if pool != tc.pool {
+			t.Errorf("%s: expected pool %q, got %q", tc.name, tc.pool, pool)
+		}
+		var host string
+		if sim.Host != nil {
+			host = sim.Host.Hostname
+		}
+		if host != tc.host {
+			t.Errorf("%s: expected host %q, got %q", tc.name, tc.host, host)
+		}
+		if !reflect.DeepEqual(sim.Classes, tc.classes) {
+			t.Errorf("%s: expected classes %q, got %q", tc.name, tc.classes, sim.Classes)
+		}
+		var scopes []string
+		for _, scope := range sim.Scopes {
+			scopes = append(scopes, scope.Kind)
+		}
+		if !reflect.DeepEqual(scopes, tc.scopes) {
+			t.Errorf("%s: expected scopes %q, got %q", tc.name, tc.scopes, scopes)
+		}
+		var branches []int
+		for _, branch := range sim.Branches {
+			branches = append(branches, branch.Taken)
+		}
+		if !reflect.DeepEqual(branches, tc.branches) {
+			t.Errorf("%s: expected branches %v, got %v", tc.name, tc.branches, branches)
+		}
+	}
+}
+
+func TestSimulate_parameters(t *testing.T) {
+	statements, err := Decode(strings.NewReader(testSimulationConfig))
+	if err != nil {
+		t.Fatalf("Decode(): %s", err)
+	}
+	cr := ClientRequest{Options: map[string][]byte{"user-class": []byte("iPXE")}}
+	sim, err := Simulate(statements, cr, net.ParseIP("192.168.0.1"))
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	expected := [][]Statement{
+		{DefaultLeaseTimeStatement(600)},
+		{DefaultLeaseTimeStatement(60)},
+		{RangeStatement{Low: net.ParseIP("192.168.0.100"), High: net.ParseIP("192.168.0.199")}},
+		{MatchStatement{Data: PacketOptionTerm{"user-class"}}},
+		{MaxLeaseTimeStatement(60)},
+	}
+	for i, scope := range sim.Scopes {
+		if i >= len(expected) || !reflect.DeepEqual(scope.Parameters, expected[i]) {
+			t.Errorf("scope %d (%s): unexpected parameters %#v", i, scope.Kind, scope.Parameters)
+		}
+	}
+}
+
+func TestSimulate_noSubnet(t *testing.T) {
+	statements, err := Decode(strings.NewReader(testSimulationConfig))
+	if err != nil {
+		t.Fatalf("Decode(): %s", err)
+	}
+	_, err = Simulate(statements, ClientRequest{}, net.ParseIP("172.16.0.1"))
+	if err == nil || err.Error() != "no subnet declaration for address 172.16.0.1" {
+		t.Errorf("unexpected error: %v", err)
+	}
+}
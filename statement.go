@@ -3,9 +3,10 @@ package iscdhcp
 import (
 	"fmt"
 	"net"
+	"strings"
 )
 
-const defaultIndent = "  "
+const defaultIndent = "    "
 
 // A Statement represents an ISC-DHCP configuration statement.
 // See dhcpd.conf(5) dhcp-options(5) and dhcpd-eval(5) for canonical types.
@@ -14,23 +15,23 @@ type Statement interface {
 	// IndentedString produces string representation of the config statement,
 	// in the form expected by dhcpd. The "prefix" argument is used to indent
 	// nested statements; it is not a standard indent-depth, but an explicit
-	// prefix for this particular statement's string representation.
-	// FIXME It'd really be nice to be able to configure the standard depth.
+	// prefix for this particular statement's string representation. Nested
+	// statements are indented by a further four spaces per level; use Encode
+	// to choose a different indent.
 	IndentedString(prefix string) string
 }
 
-// base behaviors
-
-type block []Statement
-
-func (b block) IndentedString(prefix string) string {
-	var s string
-	for _, decl := range b {
-		s += decl.IndentedString(prefix + defaultIndent)
-	}
-	return s
+// A blockStatement is a Statement which encloses one or more blocks of other
+// Statements, allowing code which walks a tree of Statements to reach them.
+type blockStatement interface {
+	Statement
+	// mapBlocks returns a copy of the Statement with each of its blocks
+	// replaced by the result of passing it to f.
+	mapBlocks(f func([]Statement) []Statement) Statement
 }
 
+// base behaviors
+
 type intDecl int
 
 func (id intDecl) IndentedString(prefix, identifier string) string {
@@ -51,11 +52,32 @@ func (oob onOffBool) IndentedString(prefix, identifier string) string {
 type stringDecl string
 
 func (sd stringDecl) IndentedString(prefix, identifier string) string {
-	return prefix + identifier + " \"" + string(sd) + "\";\n"
+	return prefix + identifier + " " + quoteString(string(sd)) + ";\n"
 }
 
 // DECLARATIONS
 
+// A ClassStatement represents a class declaration.
+// See "CLIENT CLASSING" in dhcpd.conf(5)
+type ClassStatement struct {
+	Name       string
+	Statements []Statement
+}
+
+// IndentedString implements the method of the same name in the Statement interface
+func (cs ClassStatement) IndentedString(prefix string) string {
+	return indentedString(cs, prefix)
+}
+
+func (cs ClassStatement) encode(e *encoder, prefix string) {
+	e.writeBlock(prefix, "class "+quoteString(cs.Name), cs.Statements)
+}
+
+func (cs ClassStatement) mapBlocks(f func([]Statement) []Statement) Statement {
+	cs.Statements = f(cs.Statements)
+	return cs
+}
+
 // A GroupStatement represents a group declaration.
 // See "The group statement" in dhcpd.conf(5)
 type GroupStatement struct {
@@ -64,8 +86,16 @@ type GroupStatement struct {
 
 // IndentedString implements the method of the same name in the Statement interface
 func (gs GroupStatement) IndentedString(prefix string) string {
-	return prefix + "group {\n" + block(gs.Statements).IndentedString(prefix+defaultIndent) +
-		prefix + "}\n"
+	return indentedString(gs, prefix)
+}
+
+func (gs GroupStatement) encode(e *encoder, prefix string) {
+	e.writeBlock(prefix, "group", gs.Statements)
+}
+
+func (gs GroupStatement) mapBlocks(f func([]Statement) []Statement) Statement {
+	gs.Statements = f(gs.Statements)
+	return gs
 }
 
 // A HostStatement represents a host declaration.
@@ -77,31 +107,176 @@ type HostStatement struct {
 
 // IndentedString implements the method of the same name in the Statement interface
 func (hs HostStatement) IndentedString(prefix string) string {
-	return prefix + "host " + hs.Hostname + " {\n" +
-		block(hs.Statements).IndentedString(prefix+defaultIndent) +
-		prefix + "}\n"
+	return indentedString(hs, prefix)
+}
+
+func (hs HostStatement) encode(e *encoder, prefix string) {
+	e.writeBlock(prefix, "host "+hs.Hostname, hs.Statements)
 }
 
-// An IncludeStatement represents an include-file declaration.
+func (hs HostStatement) mapBlocks(f func([]Statement) []Statement) Statement {
+	hs.Statements = f(hs.Statements)
+	return hs
+}
+
+// An IncludeStatement represents an include-file declaration. When it's
+// returned by DecodeFS or DecodeFile, Statements holds the contents of the
+// included file; they aren't written out by IndentedString or Encode.
 // See "The include statement" in dhcpd.conf(5)
 type IncludeStatement struct {
-	Filename string
+	Filename   string
+	Statements []Statement
 }
 
 // IndentedString implements the method of the same name in the Statement interface
 func (is IncludeStatement) IndentedString(prefix string) string {
-	return prefix + "include \"" + is.Filename + "\";\n"
+	return prefix + "include " + quoteString(is.Filename) + ";\n"
+}
+
+func (is IncludeStatement) mapBlocks(f func([]Statement) []Statement) Statement {
+	is.Statements = f(is.Statements)
+	return is
+}
+
+// A PoolStatement represents a pool declaration.
+// See "Address pools" in dhcpd.conf(5)
+type PoolStatement struct {
+	Statements []Statement
+}
+
+// IndentedString implements the method of the same name in the Statement interface
+func (ps PoolStatement) IndentedString(prefix string) string {
+	return indentedString(ps, prefix)
+}
+
+func (ps PoolStatement) encode(e *encoder, prefix string) {
+	e.writeBlock(prefix, "pool", ps.Statements)
+}
+
+func (ps PoolStatement) mapBlocks(f func([]Statement) []Statement) Statement {
+	ps.Statements = f(ps.Statements)
+	return ps
+}
+
+// A Prefix6Statement represents a prefix6 declaration, giving the range of
+// prefixes of length PrefixLen, from Low to High, available for delegation.
+// See "The prefix6 statement" in dhcpd.conf(5)
+type Prefix6Statement struct {
+	Low       net.IP
+	High      net.IP
+	PrefixLen int
+}
+
+// IndentedString implements the method of the same name in the Statement interface
+func (ps Prefix6Statement) IndentedString(prefix string) string {
+	return fmt.Sprintf("%sprefix6 %s %s /%d;\n", prefix, ps.Low, ps.High, ps.PrefixLen)
+}
+
+// A RangeStatement represents a range declaration. High may be nil, in which
+// case the range consists of the single address Low.
+// See "The range statement" in dhcpd.conf(5)
+type RangeStatement struct {
+	DynamicBootp bool
+	Low          net.IP
+	High         net.IP
 }
 
-type sharedNetworkStatement struct {
+// IndentedString implements the method of the same name in the Statement interface
+func (rs RangeStatement) IndentedString(prefix string) string {
+	s := prefix + "range "
+	if rs.DynamicBootp {
+		s += "dynamic-bootp "
+	}
+	s += rs.Low.String()
+	if rs.High != nil {
+		s += " " + rs.High.String()
+	}
+	return s + ";\n"
+}
+
+// A Range6Statement represents a range6 declaration. It takes one of three
+// forms: Low and High bound a range of addresses; Prefix gives a whole
+// subnet6 number; or, only if Temporary is set, Low alone gives a single
+// address. Temporary may also be set along with Prefix.
+// See "The range6 statement" in dhcpd.conf(5)
+type Range6Statement struct {
+	Low       net.IP
+	High      net.IP
+	Prefix    *net.IPNet
+	Temporary bool
+}
+
+// IndentedString implements the method of the same name in the Statement interface
+func (rs Range6Statement) IndentedString(prefix string) string {
+	s := prefix + "range6 "
+	if rs.Prefix != nil {
+		s += rs.Prefix.String()
+	} else {
+		s += rs.Low.String()
+		if rs.High != nil {
+			s += " " + rs.High.String()
+		}
+	}
+	if rs.Temporary {
+		s += " temporary"
+	}
+	return s + ";\n"
+}
+
+// A SharedNetworkStatement represents a shared-network declaration.
+// See "The shared-network statement" in dhcpd.conf(5)
+type SharedNetworkStatement struct {
 	Name       string
 	Statements []Statement
 }
 
-func (sns sharedNetworkStatement) IndentedString(prefix string) string {
-	return prefix + "shared-network " + sns.Name + " {\n" +
-		block(sns.Statements).IndentedString(prefix+defaultIndent) +
-		prefix + "}\n"
+// IndentedString implements the method of the same name in the Statement interface
+func (sns SharedNetworkStatement) IndentedString(prefix string) string {
+	return indentedString(sns, prefix)
+}
+
+func (sns SharedNetworkStatement) encode(e *encoder, prefix string) {
+	e.writeBlock(prefix, "shared-network "+quoteString(sns.Name), sns.Statements)
+}
+
+func (sns SharedNetworkStatement) mapBlocks(f func([]Statement) []Statement) Statement {
+	sns.Statements = f(sns.Statements)
+	return sns
+}
+
+// A SubclassStatement represents a subclass declaration, which adds a member
+// to a class whose Statements include a MatchStatement or SpawnWithStatement.
+// Data is the value the class's match expression is compared against, e.g. a
+// StringConstTerm or HexStringTerm. If Statements is nil the subclass is
+// written without a block.
+// See "SUBCLASSES" in dhcpd.conf(5)
+type SubclassStatement struct {
+	ClassName  string
+	Data       fmt.Stringer
+	Statements []Statement
+}
+
+// IndentedString implements the method of the same name in the Statement interface
+func (scs SubclassStatement) IndentedString(prefix string) string {
+	return indentedString(scs, prefix)
+}
+
+func (scs SubclassStatement) encode(e *encoder, prefix string) {
+	header := "subclass " + quoteString(scs.ClassName) + " " + scs.Data.String()
+	if scs.Statements == nil {
+		e.writeString(prefix + header + ";\n")
+		return
+	}
+	e.writeBlock(prefix, header, scs.Statements)
+}
+
+func (scs SubclassStatement) mapBlocks(f func([]Statement) []Statement) Statement {
+	// A nil block has a meaning of its own, and mustn't be replaced by an
+	// empty one or vice versa.
+	if scs.Statements != nil {
+		scs.Statements = append([]Statement{}, f(scs.Statements)...)
+	}
+	return scs
 }
 
 // A SubnetStatement represents a subnet declaration.
@@ -114,29 +289,99 @@ type SubnetStatement struct {
 
 // IndentedString implements the method of the same name in the Statement interface
 func (sns SubnetStatement) IndentedString(prefix string) string {
-	return prefix + "subnet " + sns.SubnetNumber.String() +
-		" netmask " + sns.Netmask.String() + " {\n" +
-		block(sns.Statements).IndentedString(prefix+defaultIndent) +
-		prefix + "}\n"
+	return indentedString(sns, prefix)
+}
+
+func (sns SubnetStatement) encode(e *encoder, prefix string) {
+	header := "subnet " + sns.SubnetNumber.String() + " netmask " + sns.Netmask.String()
+	e.writeBlock(prefix, header, sns.Statements)
+}
+
+func (sns SubnetStatement) mapBlocks(f func([]Statement) []Statement) Statement {
+	sns.Statements = f(sns.Statements)
+	return sns
+}
+
+// A Subnet6Statement represents a subnet6 declaration. Prefix.IP is the
+// address as written, which may have bits set beyond the prefix length.
+// See "The subnet6 statement" in dhcpd.conf(5)
+type Subnet6Statement struct {
+	Prefix     *net.IPNet
+	Statements []Statement
+}
+
+// IndentedString implements the method of the same name in the Statement interface
+func (sns Subnet6Statement) IndentedString(prefix string) string {
+	return indentedString(sns, prefix)
+}
+
+func (sns Subnet6Statement) encode(e *encoder, prefix string) {
+	e.writeBlock(prefix, "subnet6 "+sns.Prefix.String(), sns.Statements)
+}
+
+func (sns Subnet6Statement) mapBlocks(f func([]Statement) []Statement) Statement {
+	sns.Statements = f(sns.Statements)
+	return sns
 }
 
 // PARAMETERS
 
-type adaptiveLeaseThresholdStatement int
+// An AdaptiveLeaseThresholdStatement represents an
+// "adaptive-lease-time-threshold" parameter.
+// See "The adaptive-lease-time-threshold statement" in dhcpd.conf(5)
+type AdaptiveLeaseThresholdStatement int
 
-func (alts adaptiveLeaseThresholdStatement) IndentedString(prefix string) string {
+// IndentedString implements the method of the same name in the Statement interface
+func (alts AdaptiveLeaseThresholdStatement) IndentedString(prefix string) string {
 	return intDecl(alts).IndentedString(prefix, "adaptive-lease-time-threshold")
 }
 
-type alwaysBroadcastStatement bool
+var accessOpStrings = map[int]string{
+	AccessAllow:  "allow",
+	AccessDeny:   "deny",
+	AccessIgnore: "ignore",
+}
+
+// An AllowDenyStatement represents an allow, deny or ignore statement, either
+// as a parameter controlling dhcpd's behavior or as an entry in a pool's
+// permit list.
+// See "ALLOW AND DENY" in dhcpd.conf(5)
+type AllowDenyStatement struct {
+	// Operator is one of allow/deny/ignore, represented by the integers
+	// {AccessAllow, AccessDeny, AccessIgnore}.
+	Operator int
+	// Flag is the keyword (or keywords) following the operator, e.g.
+	// "unknown-clients" or "dynamic bootp clients". For class-membership
+	// permits it is "members of", and ClassName holds the class name.
+	Flag      string
+	ClassName string
+}
 
-func (abs alwaysBroadcastStatement) IndentedString(prefix string) string {
+// IndentedString implements the method of the same name in the Statement interface
+func (ads AllowDenyStatement) IndentedString(prefix string) string {
+	s := prefix + accessOpStrings[ads.Operator] + " " + ads.Flag
+	if ads.ClassName != "" {
+		s += " " + quoteString(ads.ClassName)
+	}
+	return s + ";\n"
+}
+
+// An AlwaysBroadcastStatement represents an "always-broadcast" parameter.
+// See "The always-broadcast statement" in dhcpd.conf(5)
+type AlwaysBroadcastStatement bool
+
+// IndentedString implements the method of the same name in the Statement interface
+func (abs AlwaysBroadcastStatement) IndentedString(prefix string) string {
 	return onOffBool(abs).IndentedString(prefix, "always-broadcast")
 }
 
-type alwaysReplyRFC1048 bool
+// An AlwaysReplyRFC1048Statement represents an "always-reply-rfc1048"
+// parameter.
+// See "The always-reply-rfc1048 statement" in dhcpd.conf(5)
+type AlwaysReplyRFC1048Statement bool
 
-func (arrfc alwaysReplyRFC1048) IndentedString(prefix string) string {
+// IndentedString implements the method of the same name in the Statement interface
+func (arrfc AlwaysReplyRFC1048Statement) IndentedString(prefix string) string {
 	return onOffBool(arrfc).IndentedString(prefix, "always-reply-rfc1048")
 }
 
@@ -152,19 +397,27 @@ func (as AuthoritativeStatement) IndentedString(prefix string) string {
 	return prefix + "not authoritative;\n"
 }
 
-type bootUnknownClientsStatement bool
+// A BootUnknownClientsStatement represents a "boot-unknown-clients"
+// parameter.
+// See "The boot-unknown-clients statement" in dhcpd.conf(5)
+type BootUnknownClientsStatement bool
 
-func (bucs bootUnknownClientsStatement) IndentedString(prefix string) string {
+// IndentedString implements the method of the same name in the Statement interface
+func (bucs BootUnknownClientsStatement) IndentedString(prefix string) string {
 	return onOffBool(bucs).IndentedString(prefix, "boot-unknown-clients")
 }
 
-type dbTimeFormatStatement struct {
-	localTime bool
+// A DBTimeFormatStatement represents a "db-time-format" parameter. LocalTime
+// selects the "local" format; otherwise the "default" format is used.
+// See "The db-time-format statement" in dhcpd.conf(5)
+type DBTimeFormatStatement struct {
+	LocalTime bool
 }
 
-func (dtfs dbTimeFormatStatement) IndentedString(prefix string) string {
+// IndentedString implements the method of the same name in the Statement interface
+func (dtfs DBTimeFormatStatement) IndentedString(prefix string) string {
 	var format string
-	if dtfs.localTime {
+	if dtfs.LocalTime {
 		format = "local"
 	} else {
 		format = "default"
@@ -172,93 +425,140 @@ func (dtfs dbTimeFormatStatement) IndentedString(prefix string) string {
 	return prefix + "db-time-format " + format + ";\n"
 }
 
-type ddnsDomainNameStatement string
+// A DDNSDomainNameStatement represents a "ddns-domainname" parameter.
+// See "The ddns-domainname statement" in dhcpd.conf(5)
+type DDNSDomainNameStatement string
 
-func (ddnsds ddnsDomainNameStatement) IndentedString(prefix string) string {
+// IndentedString implements the method of the same name in the Statement interface
+func (ddnsds DDNSDomainNameStatement) IndentedString(prefix string) string {
 	return stringDecl(ddnsds).IndentedString(prefix, "ddns-domainname")
 }
 
-type ddnsHostNameStatement string
+// A DDNSHostNameStatement represents a "ddns-hostname" parameter.
+// See "The ddns-hostname statement" in dhcpd.conf(5)
+type DDNSHostNameStatement string
 
-func (ddnshns ddnsHostNameStatement) IndentedString(prefix string) string {
+// IndentedString implements the method of the same name in the Statement interface
+func (ddnshns DDNSHostNameStatement) IndentedString(prefix string) string {
 	return stringDecl(ddnshns).IndentedString(prefix, "ddns-hostname")
 }
 
-type ddnsRevDomainNameStatement string
+// A DDNSRevDomainNameStatement represents a "ddns-rev-domainname" parameter.
+// See "The ddns-rev-domainname statement" in dhcpd.conf(5)
+type DDNSRevDomainNameStatement string
 
-func (ddnsrdns ddnsRevDomainNameStatement) IndentedString(prefix string) string {
+// IndentedString implements the method of the same name in the Statement interface
+func (ddnsrdns DDNSRevDomainNameStatement) IndentedString(prefix string) string {
 	return stringDecl(ddnsrdns).IndentedString(prefix, "ddns-rev-domainname")
 }
 
+// Values for a DDNSUpdateStyleStatement.
 const (
-	ddnsUpdateStyleNone = iota // nolint
-	ddnsUpdateStyleAdHoc
-	ddnsUpdateStyleInterim
+	DDNSUpdateStyleNone = iota
+	DDNSUpdateStyleAdHoc
+	DDNSUpdateStyleInterim
+	DDNSUpdateStyleStandard
 )
 
-type ddnsUpdateStyleStatement int
+var ddnsUpdateStyleStrings = map[int]string{
+	DDNSUpdateStyleNone:     "none",
+	DDNSUpdateStyleAdHoc:    "ad-hoc",
+	DDNSUpdateStyleInterim:  "interim",
+	DDNSUpdateStyleStandard: "standard",
+}
+
+// A DDNSUpdateStyleStatement represents a "ddns-update-style" parameter. Its
+// value is one of the DDNSUpdateStyle constants in this package.
+// See "The ddns-update-style parameter" in dhcpd.conf(5)
+type DDNSUpdateStyleStatement int
 
-func (ddnsuss ddnsUpdateStyleStatement) IndentedString(prefix string) string {
-	var style string
-	switch ddnsuss {
-	case ddnsUpdateStyleAdHoc:
-		style = "ad-hoc"
-	case ddnsUpdateStyleInterim:
-		style = "interim"
-	default:
+// IndentedString implements the method of the same name in the Statement interface
+func (ddnsuss DDNSUpdateStyleStatement) IndentedString(prefix string) string {
+	style, found := ddnsUpdateStyleStrings[int(ddnsuss)]
+	if !found {
 		style = "none"
 	}
 	return prefix + "ddns-update-style " + style + ";\n"
 }
 
-type ddnsUpdatesStatement bool
+// A DDNSUpdatesStatement represents a "ddns-updates" parameter.
+// See "The ddns-updates statement" in dhcpd.conf(5)
+type DDNSUpdatesStatement bool
 
-func (ddnsus ddnsUpdatesStatement) IndentedString(prefix string) string {
+// IndentedString implements the method of the same name in the Statement interface
+func (ddnsus DDNSUpdatesStatement) IndentedString(prefix string) string {
 	return onOffBool(ddnsus).IndentedString(prefix, "ddns-updates")
 }
 
-type defaultLeaseTimeStatement int
+// A DefaultLeaseTimeStatement represents a "default-lease-time" parameter,
+// in seconds.
+// See "The default-lease-time statement" in dhcpd.conf(5)
+type DefaultLeaseTimeStatement int
 
-func (dlts defaultLeaseTimeStatement) IndentedString(prefix string) string {
+// IndentedString implements the method of the same name in the Statement interface
+func (dlts DefaultLeaseTimeStatement) IndentedString(prefix string) string {
 	return intDecl(dlts).IndentedString(prefix, "default-lease-time")
 }
 
-type delayedAckStatement int
+// A DelayedAckStatement represents a "delayed-ack" parameter.
+// See "The delayed-ack and max-ack-delay statements" in dhcpd.conf(5)
+type DelayedAckStatement int
 
-func (das delayedAckStatement) IndentedString(prefix string) string {
+// IndentedString implements the method of the same name in the Statement interface
+func (das DelayedAckStatement) IndentedString(prefix string) string {
 	return intDecl(das).IndentedString(prefix, "delayed-ack")
 }
 
-type doForwardUpdatesStatement bool
+// A DoForwardUpdatesStatement represents a "do-forward-updates" parameter.
+// See "The do-forward-updates statement" in dhcpd.conf(5)
+type DoForwardUpdatesStatement bool
 
-func (dfus doForwardUpdatesStatement) IndentedString(prefix string) string {
+// IndentedString implements the method of the same name in the Statement interface
+func (dfus DoForwardUpdatesStatement) IndentedString(prefix string) string {
 	return onOffBool(dfus).IndentedString(prefix, "do-forward-updates")
 }
 
-type dynamicBootpLeaseCutoffStatement struct {
-	dayOfWeek  int
-	year       int
-	month      int
-	dayOfMonth int
-	hours      int
-	minutes    int
-	seconds    int
+// A DynamicBootpLeaseCutoffStatement represents a
+// "dynamic-bootp-lease-cutoff" parameter. The date is expressed in UTC, in
+// the same fields dhcpd uses: "W YYYY/MM/DD HH:MM:SS".
+// See "The dynamic-bootp-lease-cutoff statement" in dhcpd.conf(5)
+type DynamicBootpLeaseCutoffStatement struct {
+	DayOfWeek  int
+	Year       int
+	Month      int
+	DayOfMonth int
+	Hours      int
+	Minutes    int
+	Seconds    int
 }
 
-func (dblcs dynamicBootpLeaseCutoffStatement) IndentedString(prefix string) string {
+// IndentedString implements the method of the same name in the Statement interface
+func (dblcs DynamicBootpLeaseCutoffStatement) IndentedString(prefix string) string {
 	// W YYYY/MM/DD HH:MM:SS
 	return prefix + fmt.Sprintf(
 		"dynamic-bootp-lease-cutoff %1d %04d/%02d/%02d %02d:%02d:%02d;\n",
-		dblcs.dayOfWeek,
-		dblcs.year,
-		dblcs.month,
-		dblcs.dayOfMonth,
-		dblcs.hours,
-		dblcs.minutes,
-		dblcs.seconds,
+		dblcs.DayOfWeek,
+		dblcs.Year,
+		dblcs.Month,
+		dblcs.DayOfMonth,
+		dblcs.Hours,
+		dblcs.Minutes,
+		dblcs.Seconds,
 	)
 }
 
+// A FailoverPeerStatement represents a reference to a failover peer, as
+// found within a pool declaration.
+// See "Configuring failover" in dhcpd.conf(5)
+type FailoverPeerStatement struct {
+	Name string
+}
+
+// IndentedString implements the method of the same name in the Statement interface
+func (fps FailoverPeerStatement) IndentedString(prefix string) string {
+	return prefix + "failover peer " + quoteString(fps.Name) + ";\n"
+}
+
 // A FixedAddressStatement represents a fixed-address parameter.
 // See "The fixed-address declaration" in dhcpd.conf(5)
 type FixedAddressStatement []net.IP
@@ -272,6 +572,27 @@ func (fas FixedAddressStatement) IndentedString(prefix string) string {
 	return s + fas[len(fas)-1].String() + ";\n"
 }
 
+// A FixedAddress6Statement represents a fixed-address6 parameter.
+// See "The fixed-address6 declaration" in dhcpd.conf(5)
+type FixedAddress6Statement net.IP
+
+// IndentedString implements the method of the same name in the Statement interface
+func (fas FixedAddress6Statement) IndentedString(prefix string) string {
+	return prefix + "fixed-address6 " + net.IP(fas).String() + ";\n"
+}
+
+// A FixedPrefix6Statement represents a fixed-prefix6 parameter, which
+// reserves a delegated prefix for a host.
+// See "The fixed-prefix6 declaration" in dhcpd.conf(5)
+type FixedPrefix6Statement struct {
+	Prefix *net.IPNet
+}
+
+// IndentedString implements the method of the same name in the Statement interface
+func (fps FixedPrefix6Statement) IndentedString(prefix string) string {
+	return prefix + "fixed-prefix6 " + fps.Prefix.String() + ";\n"
+}
+
 // A HardwareStatement represents a hardware parameter.
 // See "The hardware statement" in dhcpd.conf(5)
 type HardwareStatement struct {
@@ -284,12 +605,85 @@ func (hs HardwareStatement) IndentedString(prefix string) string {
 	return prefix + "hardware " + hs.HardwareType + " " + hs.HardwareAddress + ";\n"
 }
 
-type maxAckDelayStatement int
+// A LeaseLimitStatement represents a "lease limit" parameter within a class
+// declaration.
+// See "PER-CLASS LIMITS ON DYNAMIC LEASES" in dhcpd.conf(5)
+type LeaseLimitStatement int
+
+// IndentedString implements the method of the same name in the Statement interface
+func (lls LeaseLimitStatement) IndentedString(prefix string) string {
+	return intDecl(lls).IndentedString(prefix, "lease limit")
+}
+
+// A MatchIfStatement represents a "match if" parameter within a class
+// declaration. Clients for which Condition evaluates true are members of the
+// class.
+// See "CLIENT CLASSING" in dhcpd.conf(5)
+type MatchIfStatement struct {
+	Condition BooleanExpression
+}
+
+// IndentedString implements the method of the same name in the Statement interface
+func (mis MatchIfStatement) IndentedString(prefix string) string {
+	return prefix + "match if " + mis.Condition.string() + ";\n"
+}
+
+// A MatchStatement represents a "match" parameter within a class
+// declaration. Clients are members of the class's subclass whose Data
+// equals the result of evaluating Data for the client.
+// See "SUBCLASSES" in dhcpd.conf(5)
+type MatchStatement struct {
+	Data fmt.Stringer
+}
+
+// IndentedString implements the method of the same name in the Statement interface
+func (ms MatchStatement) IndentedString(prefix string) string {
+	return prefix + "match " + ms.Data.String() + ";\n"
+}
 
-func (mads maxAckDelayStatement) IndentedString(prefix string) string {
+// A MaxAckDelayStatement represents a "max-ack-delay" parameter, in
+// microseconds.
+// See "The delayed-ack and max-ack-delay statements" in dhcpd.conf(5)
+type MaxAckDelayStatement int
+
+// IndentedString implements the method of the same name in the Statement interface
+func (mads MaxAckDelayStatement) IndentedString(prefix string) string {
 	return intDecl(mads).IndentedString(prefix, "max-ack-delay")
 }
 
+// A MaxLeaseTimeStatement represents a "max-lease-time" parameter, in
+// seconds.
+// See "The max-lease-time statement" in dhcpd.conf(5)
+type MaxLeaseTimeStatement int
+
+// IndentedString implements the method of the same name in the Statement interface
+func (mlts MaxLeaseTimeStatement) IndentedString(prefix string) string {
+	return intDecl(mlts).IndentedString(prefix, "max-lease-time")
+}
+
+// A MinLeaseTimeStatement represents a "min-lease-time" parameter, in
+// seconds.
+// See "The min-lease-time statement" in dhcpd.conf(5)
+type MinLeaseTimeStatement int
+
+// IndentedString implements the method of the same name in the Statement interface
+func (mlts MinLeaseTimeStatement) IndentedString(prefix string) string {
+	return intDecl(mlts).IndentedString(prefix, "min-lease-time")
+}
+
+// A SpawnWithStatement represents a "spawn with" parameter within a class
+// declaration, causing dhcpd to create a subclass for each distinct value of
+// Data.
+// See "SPAWNING CLASSES" in dhcpd.conf(5)
+type SpawnWithStatement struct {
+	Data fmt.Stringer
+}
+
+// IndentedString implements the method of the same name in the Statement interface
+func (sws SpawnWithStatement) IndentedString(prefix string) string {
+	return prefix + "spawn with " + sws.Data.String() + ";\n"
+}
+
 // A UseHostDeclNamesStatement represents a "use-host-decl-names" parameter.
 // See "The use-host-decl-names statement" in dhcpd.conf(5)
 type UseHostDeclNamesStatement bool
@@ -303,6 +697,9 @@ func (uhdns UseHostDeclNamesStatement) IndentedString(prefix string) string {
 
 // A DomainNameServersOption represents a domain-name-servers option parameter.
 // See "option domain-name-servers" in dhcp-options(5)
+//
+// Deprecated: Decode returns an OptionStatement for every option, including
+// this one. DomainNameServersOption is still written out as before.
 type DomainNameServersOption []net.IP
 
 // IndentedString implements the method of the same name in the Statement interface
@@ -316,6 +713,21 @@ func (dnso DomainNameServersOption) IndentedString(prefix string) string {
 
 // CONDITIONALS
 
+// A CommentStatement represents a comment, including its leading "#", which
+// Decode found with no statement following it in the same block. It's only
+// returned when the Comments option is given. An empty CommentStatement
+// stands for a blank line between two comments.
+type CommentStatement string
+
+// IndentedString implements the method of the same name in the Statement
+// interface.
+func (cs CommentStatement) IndentedString(prefix string) string {
+	if cs == "" {
+		return "\n"
+	}
+	return prefix + string(cs) + "\n"
+}
+
 var conditionOpStrings = map[int]string{
 	ConditionIf:    "if",
 	ConditionElsif: "elsif",
@@ -346,8 +758,8 @@ var conditionOpStrings = map[int]string{
 //			},
 //		},
 //	}
-//	cs.Statements = []Statement{IncludeStatement{"ipxe.conf"}}
-//	cs.SubConditionals[0].Statements = []Statement{IncludeStatement{"non-ipxe.conf"}}
+//	cs.Statements = []Statement{IncludeStatement{Filename: "ipxe.conf"}}
+//	cs.SubConditionals[0].Statements = []Statement{IncludeStatement{Filename: "non-ipxe.conf"}}
 //
 // This example corresponds to the following config-file text:
 //
@@ -373,30 +785,48 @@ type ConditionalStatement struct {
 	// evaluation is predicated on the top-level statement not being evaluated,
 	// and their own Conditions proving True.
 	SubConditionals []ConditionalStatement
+	// LeadingComments holds the comments found on the lines between the
+	// preceding block and an elsif/else, OpeningComment any comment
+	// following the opening brace of this block on the same line, and
+	// TrailingComment any comment following its closing brace when another
+	// elsif/else does too. The comments before an if-statement and after
+	// its last block belong to the SourceStatement wrapping it instead.
+	// They are only recorded when the Comments option is given.
+	LeadingComments []string
+	OpeningComment  string
+	TrailingComment string
 }
 
 // IndentedString implements the method of the same name in the Statement interface
 func (cs ConditionalStatement) IndentedString(prefix string) string {
-	var s string
+	return indentedString(cs, prefix)
+}
+
+func (cs ConditionalStatement) encode(e *encoder, prefix string) {
+	header := conditionOpStrings[cs.Operator]
 	if cs.Operator == ConditionIf || cs.Operator == ConditionElsif {
-		s = fmt.Sprintf(
-			"%s%s %s {\n%s}\n",
-			prefix,
-			conditionOpStrings[cs.Operator],
-			cs.Condition.string(),
-			block(cs.Statements).IndentedString(prefix+defaultIndent))
-	} else {
-		s = fmt.Sprintf(
-			"%s%s {\n%s}\n",
-			prefix,
-			conditionOpStrings[cs.Operator],
-			block(cs.Statements).IndentedString(prefix+defaultIndent))
+		header += " " + cs.Condition.string()
 	}
+	e.writeComments(prefix, cs.LeadingComments)
+	e.writeCommented(cs.OpeningComment, cs.TrailingComment, func(e *encoder) {
+		e.writeBlock(prefix, header, cs.Statements)
+	})
 	for _, sc := range cs.SubConditionals {
-		s += sc.IndentedString(prefix)
+		sc.encode(e, prefix)
 	}
+}
 
-	return s
+func (cs ConditionalStatement) mapBlocks(f func([]Statement) []Statement) Statement {
+	cs.Statements = f(cs.Statements)
+	if cs.SubConditionals != nil {
+		subConditionals := make([]ConditionalStatement, len(cs.SubConditionals))
+		for i, sc := range cs.SubConditionals {
+			sc.Statements = f(sc.Statements)
+			subConditionals[i] = sc
+		}
+		cs.SubConditionals = subConditionals
+	}
+	return cs
 }
 
 var boolOpStrings = map[int]string{
@@ -443,7 +873,15 @@ func (be BooleanExpression) string() string {
 		if len(be.BoolTerms) != 2 {
 			return "ERROR_INCORRECT_NUMBER_OF_TERMS (a)"
 		}
-		return fmt.Sprintf("%s %s %s", be.BoolTerms[0].string(), boolOpStrings[be.Operator], be.BoolTerms[1].string())
+		// Boolean operators are right-associative, so a compound expression
+		// on the left-hand side must be grouped explicitly or it will be
+		// parsed differently than it was written.
+		left := be.BoolTerms[0].string()
+		switch be.BoolTerms[0].Operator {
+		case BoolAnd, BoolOr, BoolNot:
+			left = "(" + left + ")"
+		}
+		return fmt.Sprintf("%s %s %s", left, boolOpStrings[be.Operator], be.BoolTerms[1].string())
 	case BoolNot:
 		if len(be.BoolTerms) != 1 {
 			return "ERROR_INCORRECT_NUMBER_OF_TERMS (b)"
@@ -475,11 +913,20 @@ func (be BooleanExpression) string() string {
 }
 
 // A StringConstTerm is a data-term used in a BooleanExpression. It represents
-// a quote-enclosed arbitrary string, e.g. ``"foo"''.
+// a quote-enclosed arbitrary string, e.g. ``"foo"''. Its value is held
+// without quotes or escape sequences; String() adds them back as needed.
 type StringConstTerm string
 
 func (sct StringConstTerm) String() string {
-	return "\"" + string(sct) + "\""
+	return quoteString(string(sct))
+}
+
+// A HexStringTerm is a data-term used in a BooleanExpression. It represents
+// a list of colon-separated hexadecimal octets, e.g. ``1:0:c0:ff:ee:0:1''.
+type HexStringTerm string
+
+func (hst HexStringTerm) String() string {
+	return string(hst)
 }
 
 // A PacketOptionTerm is a data-term used in a BooleanExpression. It represents
@@ -492,3 +939,199 @@ type PacketOptionTerm struct {
 func (pot PacketOptionTerm) String() string {
 	return "option " + pot.optionName
 }
+
+// A ConfigOptionTerm is a data-term used in a BooleanExpression. It represents
+// the value of an option as configured for the client, and is stringified
+// for config file syntax like ``config-option domain-name''.
+type ConfigOptionTerm struct {
+	OptionName string
+}
+
+func (cot ConfigOptionTerm) String() string {
+	return "config-option " + cot.OptionName
+}
+
+// A SubstringTerm is a data-term used in a BooleanExpression. It represents
+// Length bytes of Data, starting at Offset, e.g.
+// ``substring(option vendor-class-identifier, 0, 9)''.
+type SubstringTerm struct {
+	Data   fmt.Stringer
+	Offset fmt.Stringer
+	Length fmt.Stringer
+}
+
+func (st SubstringTerm) String() string {
+	return fmt.Sprintf("substring(%s, %s, %s)", st.Data, st.Offset, st.Length)
+}
+
+// A SuffixTerm is a data-term used in a BooleanExpression. It represents the
+// last Length bytes of Data, e.g. ``suffix(option dhcp-client-identifier, 6)''.
+type SuffixTerm struct {
+	Data   fmt.Stringer
+	Length fmt.Stringer
+}
+
+func (st SuffixTerm) String() string {
+	return fmt.Sprintf("suffix(%s, %s)", st.Data, st.Length)
+}
+
+// A ConcatTerm is a data-term used in a BooleanExpression. It represents the
+// concatenation of each of its terms, e.g. ``concat("foo", hardware)''.
+type ConcatTerm []fmt.Stringer
+
+func (ct ConcatTerm) String() string {
+	return "concat(" + joinTerms(ct) + ")"
+}
+
+// A HardwareTerm is a data-term used in a BooleanExpression. It represents
+// the client's hardware type followed by its hardware address, and is
+// stringified for config file syntax as ``hardware''.
+type HardwareTerm struct{}
+
+func (ht HardwareTerm) String() string {
+	return "hardware"
+}
+
+// A PacketTerm is a data-term used in a BooleanExpression. It represents
+// Length bytes of the raw client packet, starting at Offset, e.g.
+// ``packet(0, 1)''.
+type PacketTerm struct {
+	Offset fmt.Stringer
+	Length fmt.Stringer
+}
+
+func (pt PacketTerm) String() string {
+	return fmt.Sprintf("packet(%s, %s)", pt.Offset, pt.Length)
+}
+
+// A LeasedAddressTerm is a data-term used in a BooleanExpression. It
+// represents the IP address leased to the client, and is stringified for
+// config file syntax as ``leased-address''.
+type LeasedAddressTerm struct{}
+
+func (lat LeasedAddressTerm) String() string {
+	return "leased-address"
+}
+
+// A HostDeclNameTerm is a data-term used in a BooleanExpression. It
+// represents the name of the host declaration matching the client, and is
+// stringified for config file syntax as ``host-decl-name''.
+type HostDeclNameTerm struct{}
+
+func (hdnt HostDeclNameTerm) String() string {
+	return "host-decl-name"
+}
+
+// A BinaryToASCIITerm is a data-term used in a BooleanExpression. It
+// represents Data converted to text, each Width-bit chunk written in Base
+// and joined by Separator, e.g.
+// ``binary-to-ascii(16, 8, ":", substring(hardware, 1, 6))''.
+type BinaryToASCIITerm struct {
+	Base      fmt.Stringer
+	Width     fmt.Stringer
+	Separator fmt.Stringer
+	Data      fmt.Stringer
+}
+
+func (btat BinaryToASCIITerm) String() string {
+	return fmt.Sprintf("binary-to-ascii(%s, %s, %s, %s)", btat.Base, btat.Width, btat.Separator, btat.Data)
+}
+
+// An EncodeIntTerm is a data-term used in a BooleanExpression. It represents
+// the numeric Value encoded as a Width-bit (8, 16 or 32) network-byte-order
+// integer, e.g. ``encode-int(lease-time, 32)''.
+type EncodeIntTerm struct {
+	Value fmt.Stringer
+	Width int
+}
+
+func (eit EncodeIntTerm) String() string {
+	return fmt.Sprintf("encode-int(%s, %d)", eit.Value, eit.Width)
+}
+
+// An ExtractIntTerm is a numeric term used in a BooleanExpression. It
+// represents a Width-bit (8, 16 or 32) network-byte-order integer extracted
+// from Data, e.g. ``extract-int(option dhcp-message-type, 8)''.
+type ExtractIntTerm struct {
+	Data  fmt.Stringer
+	Width int
+}
+
+func (eit ExtractIntTerm) String() string {
+	return fmt.Sprintf("extract-int(%s, %d)", eit.Data, eit.Width)
+}
+
+// A PickFirstValueTerm is a data-term used in a BooleanExpression. It
+// represents the first of its terms which evaluates to a non-null value, e.g.
+// ``pick-first-value(option host-name, host-decl-name)''.
+type PickFirstValueTerm []fmt.Stringer
+
+func (pfvt PickFirstValueTerm) String() string {
+	return "pick-first-value(" + joinTerms(pfvt) + ")"
+}
+
+// A ReverseTerm is a data-term used in a BooleanExpression. It represents
+// Data with the order of its Width-byte chunks reversed, e.g.
+// ``reverse(1, leased-address)''.
+type ReverseTerm struct {
+	Width fmt.Stringer
+	Data  fmt.Stringer
+}
+
+func (rt ReverseTerm) String() string {
+	return fmt.Sprintf("reverse(%s, %s)", rt.Width, rt.Data)
+}
+
+// An LcaseTerm is a data-term used in a BooleanExpression. It represents
+// Data converted to lower case, e.g. ``lcase(option host-name)''.
+type LcaseTerm struct {
+	Data fmt.Stringer
+}
+
+func (lt LcaseTerm) String() string {
+	return fmt.Sprintf("lcase(%s)", lt.Data)
+}
+
+// A UcaseTerm is a data-term used in a BooleanExpression. It represents
+// Data converted to upper case, e.g. ``ucase(option host-name)''.
+type UcaseTerm struct {
+	Data fmt.Stringer
+}
+
+func (ut UcaseTerm) String() string {
+	return fmt.Sprintf("ucase(%s)", ut.Data)
+}
+
+// A ClientStateTerm is a numeric term used in a BooleanExpression. It
+// represents the state of the client's DHCP exchange, and is stringified for
+// config file syntax as ``client-state''.
+type ClientStateTerm struct{}
+
+func (cst ClientStateTerm) String() string {
+	return "client-state"
+}
+
+// A LeaseTimeTerm is a numeric term used in a BooleanExpression. It
+// represents the remaining duration of the client's lease in seconds, and is
+// stringified for config file syntax as ``lease-time''.
+type LeaseTimeTerm struct{}
+
+func (ltt LeaseTimeTerm) String() string {
+	return "lease-time"
+}
+
+// A NumberTerm is a numeric term used in a BooleanExpression. It represents
+// a non-negative decimal integer constant, e.g. ``42''.
+type NumberTerm int
+
+func (nt NumberTerm) String() string {
+	return fmt.Sprintf("%d", nt)
+}
+
+func joinTerms(terms []fmt.Stringer) string {
+	s := make([]string, len(terms))
+	for i, term := range terms {
+		s[i] = term.String()
+	}
+	return strings.Join(s, ", ")
+}
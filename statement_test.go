@@ -13,7 +13,7 @@ func TestComplex_roundTrip(t *testing.T) {
 	hs.Hostname = "serverA.myDomain.tld"
 	hs.Statements = append(hs.Statements, HardwareStatement{"ethernet", "0:1:2:3:4:5"})
 	hs.Statements = append(hs.Statements, FixedAddressStatement{net.ParseIP("1.2.3.4")})
-	hs.Statements = append(hs.Statements, IncludeStatement{"filename.cfg"})
+	hs.Statements = append(hs.Statements, IncludeStatement{Filename: "filename.cfg"})
 
 	subnetStmt := SubnetStatement{
 		SubnetNumber: net.ParseIP("1.2.3.0"),
@@ -21,6 +21,9 @@ func TestComplex_roundTrip(t *testing.T) {
 	}
 	subnetStmt.Statements = append(subnetStmt.Statements, hs)
 
+	sns := SharedNetworkStatement{Name: "vlans"}
+	sns.Statements = append(sns.Statements, subnetStmt)
+
 	gs := GroupStatement{}
 	//trueVal := true
 	//as := (*authoritativeStatement)(&trueVal)
@@ -28,7 +31,7 @@ func TestComplex_roundTrip(t *testing.T) {
 	//gs.params = append(gs.params, as)
 	//gs.params = append(gs.params, uhdns)
 	//gs.params = append(gs.params, &domainNameServersOption{net.ParseIP("1.2.3.4"), net.ParseIP("5.6.7.8")})
-	gs.Statements = append(gs.Statements, subnetStmt)
+	gs.Statements = append(gs.Statements, sns)
 
 	newStatements, err := Decode(strings.NewReader(gs.IndentedString("")))
 	if err != nil {
@@ -41,43 +44,82 @@ func TestComplex_roundTrip(t *testing.T) {
 }
 
 func TestStatements_roundtrip(t *testing.T) {
-	//trueReal := true
-	//stringReal := "foo"
-	//intReal := 0
 	ip1 := net.ParseIP("1.2.3.2")
 	ip2 := net.ParseIP("4.5.6.2")
 	statements := []Statement{
 		AuthoritativeStatement(false),
 		AuthoritativeStatement(true),
 		HostStatement{Hostname: "serverA.myDomain.tld"},
-		//		(*adaptiveLeaseThresholdStatement)(&intReal),
-		//		(*alwaysBroadcastStatement)(&trueReal),
-		//		(*alwaysReplyRFC1048)(&trueReal),
-		//		(*authoritativeStatement)(&trueReal),
-		//		(*bootUnknownClientsStatement)(&trueReal),
-		//		(*ddnsDomainNameStatement)(&stringReal),
-		//		(*ddnsHostNameStatement)(&stringReal),
-		//		(*ddnsRevDomainNameStatement)(&stringReal),
-		//		(*ddnsUpdateStyleStatement)(&intReal),
-		//		(*ddnsUpdatesStatement)(&trueReal),
-		//		(*defaultLeaseTimeStatement)(&intReal),
-		//		(*delayedAckStatement)(&intReal),
-		//		(*doForwardUpdatesStatement)(&trueReal),
-		//		&dynamicBootpLeaseCutoffStatement{
-		//			dayOfWeek:  1,
-		//			year:       1999,
-		//			month:      12,
-		//			dayOfMonth: 31,
-		//			hours:      23,
-		//			minutes:    59,
-		//			seconds:    59,
-		//		},
+		HostStatement{Hostname: "a"},
+		SharedNetworkStatement{Name: "vlans"},
+		SharedNetworkStatement{Name: "my net"},
+		SharedNetworkStatement{Name: "host"},
+		PoolStatement{},
+		RangeStatement{Low: ip1},
+		RangeStatement{Low: ip1, High: ip2},
+		RangeStatement{DynamicBootp: true, Low: ip1, High: ip2},
+		AllowDenyStatement{Operator: AccessAllow, Flag: "members of", ClassName: "foo"},
+		AllowDenyStatement{Operator: AccessDeny, Flag: "unknown-clients"},
+		AllowDenyStatement{Operator: AccessIgnore, Flag: "dynamic bootp clients"},
+		FailoverPeerStatement{Name: "dhcp-failover"},
+		ClassStatement{Name: "voip"},
+		SubclassStatement{ClassName: "voip", Data: StringConstTerm("SIP phone")},
+		SubclassStatement{ClassName: "voip", Data: HexStringTerm("1:0:c0:ff:ee:0:1")},
+		SubclassStatement{ClassName: "voip", Data: HexStringTerm("1:2:3:4:5:6"), Statements: []Statement{}},
+		LeaseLimitStatement(4),
+		MatchIfStatement{Condition: BooleanExpression{Operator: BoolKnown}},
+		MatchStatement{Data: PacketOptionTerm{"agent.circuit-id"}},
+		SpawnWithStatement{Data: PacketOptionTerm{"agent.circuit-id"}},
+		ConditionalStatement{
+			Operator:   ConditionIf,
+			Condition:  BooleanExpression{Operator: BoolKnown},
+			Statements: []Statement{AuthoritativeStatement(true)},
+			SubConditionals: []ConditionalStatement{
+				{
+					Operator:   ConditionElsif,
+					Condition:  BooleanExpression{Operator: BoolStatic},
+					Statements: []Statement{AuthoritativeStatement(false)},
+				},
+				{
+					Operator:   ConditionElse,
+					Statements: []Statement{IncludeStatement{Filename: "filename"}},
+				},
+			},
+		},
+		AdaptiveLeaseThresholdStatement(75),
+		AlwaysBroadcastStatement(true),
+		AlwaysReplyRFC1048Statement(false),
+		BootUnknownClientsStatement(true),
+		DBTimeFormatStatement{LocalTime: true},
+		DBTimeFormatStatement{LocalTime: false},
+		DDNSDomainNameStatement("example.com"),
+		DDNSHostNameStatement("foo"),
+		DDNSRevDomainNameStatement("in-addr.arpa."),
+		DDNSUpdateStyleStatement(DDNSUpdateStyleNone),
+		DDNSUpdateStyleStatement(DDNSUpdateStyleAdHoc),
+		DDNSUpdateStyleStatement(DDNSUpdateStyleInterim),
+		DDNSUpdateStyleStatement(DDNSUpdateStyleStandard),
+		DDNSUpdatesStatement(false),
+		DefaultLeaseTimeStatement(600),
+		DelayedAckStatement(28),
+		DoForwardUpdatesStatement(true),
+		DynamicBootpLeaseCutoffStatement{
+			DayOfWeek:  5,
+			Year:       1999,
+			Month:      12,
+			DayOfMonth: 31,
+			Hours:      23,
+			Minutes:    59,
+			Seconds:    59,
+		},
 		FixedAddressStatement{ip1, ip2},
 		HardwareStatement{HardwareType: "ethernet", HardwareAddress: "1:2:3:4:5:6"},
-		IncludeStatement{"filename"},
-		//		(*maxAckDelayStatement)(&intReal),
+		IncludeStatement{Filename: "filename"},
+		MaxAckDelayStatement(250000),
+		MaxLeaseTimeStatement(7200),
+		MinLeaseTimeStatement(0),
 		UseHostDeclNamesStatement(true),
-		DomainNameServersOption{ip1, ip2},
+		OptionStatement{Name: "domain-name-servers", Values: [][]interface{}{{ip1}, {ip2}}},
 	}
 
 	for _, statement := range statements {
@@ -91,11 +133,182 @@ func TestStatements_roundtrip(t *testing.T) {
 			t.Fatalf("expected exactly 1 statement, got %d", len(newStatements))
 		}
 		if !reflect.DeepEqual(newStatements[0], statement) {
-			t.Error("actual != expected")
+			t.Errorf("actual != expected: %#v != %#v", newStatements[0], statement)
+		}
+	}
+}
+
+func TestParameters_decodeInvalid(t *testing.T) {
+	for _, data := range []string{
+		"ddns-update-style bogus;\n",
+		"db-time-format utc;\n",
+		"dynamic-bootp-lease-cutoff 5 1999-12-31 23:59:59;\n",
+	} {
+		_, err := Decode(strings.NewReader(data))
+		if err == nil {
+			t.Errorf("expected error decoding %q, got nil", data)
+		}
+	}
+}
+
+func TestSharedNetworkStatement_decode(t *testing.T) {
+	data := `
+		# VLANs 10 and 20 share a wire
+		shared-network "vlan-10-20" {
+			subnet 10.0.10.0 netmask 255.255.255.0 { }
+			subnet 10.0.20.0 netmask 255.255.255.0 { }
+		}`
+	expected := SharedNetworkStatement{
+		Name: "vlan-10-20",
+		Statements: []Statement{
+			SubnetStatement{
+				SubnetNumber: net.ParseIP("10.0.10.0"),
+				Netmask:      net.ParseIP("255.255.255.0"),
+			},
+			SubnetStatement{
+				SubnetNumber: net.ParseIP("10.0.20.0"),
+				Netmask:      net.ParseIP("255.255.255.0"),
+			},
+		},
+	}
+
+	newStatements, err := Decode(strings.NewReader(data))
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+
+	if len(newStatements) != 1 {
+		t.Fatalf("expected exactly 1 statement, got %d", len(newStatements))
+	}
+	if !reflect.DeepEqual(expected, newStatements[0]) {
+		t.Errorf("expected %#v, got %#v", expected, newStatements[0])
+	}
+}
+
+func TestDecode_keywordNames(t *testing.T) {
+	keywords := []string{
+		"pool", "range", "dynamic-bootp", "failover", "peer", "members", "of",
+		"allow", "deny", "ignore",
+		"class", "subclass", "match", "spawn", "with", "lease", "limit",
+		"binary-to-ascii", "client-state", "concat", "config-option", "encode-int",
+		"extract-int", "host-decl-name", "lcase", "lease-time", "leased-address",
+		"packet", "pick-first-value", "reverse", "substring", "suffix", "ucase",
+		"subnet6", "range6", "prefix6", "temporary", "fixed-address6", "fixed-prefix6",
+		"code", "space", "vendor-option-space",
+	}
+	for _, keyword := range keywords {
+		data := "host " + keyword + " { }\nshared-network " + keyword + " { }\n"
+		newStatements, err := Decode(strings.NewReader(data))
+		if err != nil {
+			t.Errorf("%q: unexpected error: %s", keyword, err)
+			continue
+		}
+		expected := []Statement{
+			HostStatement{Hostname: keyword},
+			SharedNetworkStatement{Name: keyword},
+		}
+		if !reflect.DeepEqual(expected, newStatements) {
+			t.Errorf("%q: expected %#v, got %#v", keyword, expected, newStatements)
 		}
 	}
 }
 
+func TestPoolStatement_decode(t *testing.T) {
+	data := `
+		subnet 10.0.10.0 netmask 255.255.255.0 {
+			pool {
+				failover peer "dhcp-failover";
+				range 10.0.10.100 10.0.10.199;
+				allow members of "voip";
+				deny unknown-clients;
+			}
+		}
+`
+	expected := SubnetStatement{
+		SubnetNumber: net.ParseIP("10.0.10.0"),
+		Netmask:      net.ParseIP("255.255.255.0"),
+		Statements: []Statement{
+			PoolStatement{
+				Statements: []Statement{
+					FailoverPeerStatement{Name: "dhcp-failover"},
+					RangeStatement{
+						Low:  net.ParseIP("10.0.10.100"),
+						High: net.ParseIP("10.0.10.199"),
+					},
+					AllowDenyStatement{Operator: AccessAllow, Flag: "members of", ClassName: "voip"},
+					AllowDenyStatement{Operator: AccessDeny, Flag: "unknown-clients"},
+				},
+			},
+		},
+	}
+
+	newStatements, err := Decode(strings.NewReader(data))
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+
+	if len(newStatements) != 1 {
+		t.Fatalf("expected exactly 1 statement, got %d", len(newStatements))
+	}
+	if !reflect.DeepEqual(expected, newStatements[0]) {
+		t.Errorf("expected %#v, got %#v", expected, newStatements[0])
+	}
+}
+
+func TestClassStatement_decode(t *testing.T) {
+	data := `
+		class "pxe" {
+			match if option vendor-class-identifier = "PXEClient";
+			lease limit 20;
+		}
+		class "phones" {
+			match option user-class;
+		}
+		subclass "phones" "polycom";
+		subclass "phones" 1:0:4:f2:0:0:1 {
+			authoritative;
+		}
+`
+	expected := []Statement{
+		ClassStatement{
+			Name: "pxe",
+			Statements: []Statement{
+				MatchIfStatement{
+					Condition: BooleanExpression{
+						Operator: BoolEqual,
+						DataTerms: []fmt.Stringer{
+							PacketOptionTerm{"vendor-class-identifier"},
+							StringConstTerm("PXEClient"),
+						},
+					},
+				},
+				LeaseLimitStatement(20),
+			},
+		},
+		ClassStatement{
+			Name: "phones",
+			Statements: []Statement{
+				MatchStatement{Data: PacketOptionTerm{"user-class"}},
+			},
+		},
+		SubclassStatement{ClassName: "phones", Data: StringConstTerm("polycom")},
+		SubclassStatement{
+			ClassName:  "phones",
+			Data:       HexStringTerm("1:0:4:f2:0:0:1"),
+			Statements: []Statement{AuthoritativeStatement(true)},
+		},
+	}
+
+	newStatements, err := Decode(strings.NewReader(data))
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+
+	if !reflect.DeepEqual(expected, newStatements) {
+		t.Errorf("expected %#v, got %#v", expected, newStatements)
+	}
+}
+
 func TestConditionalStatement_roundtrip(t *testing.T) {
 	// Create this:
 	//   if ("foo" != "foo") or ((option domain = "foo") and not static) { }
@@ -194,3 +407,203 @@ else {
 		t.Error("expected != actual")
 	}
 }
+
+func TestDataTerms_roundtrip(t *testing.T) {
+	terms := []fmt.Stringer{
+		StringConstTerm("foo"),
+		HexStringTerm("1:0:c0:ff:ee:0:1"),
+		PacketOptionTerm{"domain-name-servers"},
+		ConfigOptionTerm{OptionName: "domain-name"},
+		SubstringTerm{
+			Data:   PacketOptionTerm{"vendor-class-identifier"},
+			Offset: NumberTerm(0),
+			Length: NumberTerm(9),
+		},
+		SuffixTerm{Data: HardwareTerm{}, Length: NumberTerm(6)},
+		ConcatTerm{StringConstTerm("foo-"), HostDeclNameTerm{}, LeasedAddressTerm{}},
+		PacketTerm{Offset: NumberTerm(0), Length: NumberTerm(1)},
+		BinaryToASCIITerm{
+			Base:      NumberTerm(16),
+			Width:     NumberTerm(8),
+			Separator: StringConstTerm(":"),
+			Data:      SubstringTerm{Data: HardwareTerm{}, Offset: NumberTerm(1), Length: NumberTerm(6)},
+		},
+		EncodeIntTerm{Value: LeaseTimeTerm{}, Width: 32},
+		ExtractIntTerm{Data: PacketOptionTerm{"dhcp-message-type"}, Width: 8},
+		PickFirstValueTerm{PacketOptionTerm{"host-name"}, HostDeclNameTerm{}},
+		ReverseTerm{Width: NumberTerm(1), Data: LeasedAddressTerm{}},
+		LcaseTerm{Data: PacketOptionTerm{"host-name"}},
+		UcaseTerm{Data: PacketOptionTerm{"host-name"}},
+		ClientStateTerm{},
+		NumberTerm(7),
+	}
+
+	for _, term := range terms {
+		expected := MatchIfStatement{
+			Condition: BooleanExpression{
+				Operator:  BoolEqual,
+				DataTerms: []fmt.Stringer{term, term},
+			},
+		}
+
+		newStatements, err := Decode(strings.NewReader(expected.IndentedString("")))
+		if err != nil {
+			t.Fatalf("unexpected error decoding %q: %s", expected.IndentedString(""), err)
+		}
+
+		if len(newStatements) != 1 {
+			t.Fatalf("expected exactly 1 statement, got %d", len(newStatements))
+		}
+		if !reflect.DeepEqual(expected, newStatements[0]) {
+			t.Errorf("expected %#v, got %#v", expected, newStatements[0])
+		}
+	}
+}
+
+func TestBooleanExpression_grouping(t *testing.T) {
+	// (known or static) and exists option host-name
+	expected := MatchIfStatement{
+		Condition: BooleanExpression{
+			Operator: BoolAnd,
+			BoolTerms: []BooleanExpression{
+				{
+					Operator: BoolOr,
+					BoolTerms: []BooleanExpression{
+						{Operator: BoolKnown},
+						{Operator: BoolStatic},
+					},
+				},
+				{
+					Operator:  BoolExists,
+					DataTerms: []fmt.Stringer{PacketOptionTerm{"host-name"}},
+				},
+			},
+		},
+	}
+
+	expectedString := "match if (known or static) and exists option host-name;\n"
+	actualString := expected.IndentedString("")
+	if actualString != expectedString {
+		t.Errorf("expected %q, got %q", expectedString, actualString)
+	}
+
+	newStatements, err := Decode(strings.NewReader(actualString))
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+
+	if len(newStatements) != 1 {
+		t.Fatalf("expected exactly 1 statement, got %d", len(newStatements))
+	}
+	if !reflect.DeepEqual(expected, newStatements[0]) {
+		t.Errorf("expected %#v, got %#v", expected, newStatements[0])
+	}
+}
+
+func TestDHCPv6Statements_roundtrip(t *testing.T) {
+	_, prefix64, _ := net.ParseCIDR("2001:db8::/64")
+	_, prefix56, _ := net.ParseCIDR("2001:db8:0:100::/56")
+	statements := []Statement{
+		Subnet6Statement{
+			Prefix: prefix64,
+			Statements: []Statement{
+				Range6Statement{Low: net.ParseIP("2001:db8::100"), High: net.ParseIP("2001:db8::1ff")},
+				Range6Statement{Prefix: prefix64, Temporary: true},
+				Range6Statement{Low: net.ParseIP("2001:db8::7"), Temporary: true},
+				Prefix6Statement{Low: net.ParseIP("2001:db8:0:100::"), High: net.ParseIP("2001:db8:0:f00::"), PrefixLen: 56},
+				HostStatement{
+					Hostname: "printer",
+					Statements: []Statement{
+						FixedAddress6Statement(net.ParseIP("1:2:3:4:5:6:7:8")),
+						FixedPrefix6Statement{Prefix: prefix56},
+					},
+				},
+			},
+		},
+		Range6Statement{Prefix: prefix64},
+		FixedAddress6Statement(net.ParseIP("::1")),
+		// host bits are kept as written
+		Subnet6Statement{Prefix: &net.IPNet{IP: net.ParseIP("2001:db8::5"), Mask: prefix64.Mask}},
+	}
+
+	for _, statement := range statements {
+		newStatements, err := Decode(strings.NewReader(statement.IndentedString("")))
+		if err != nil {
+			t.Fatalf("unexpected error: %s", err)
+		}
+		if len(newStatements) != 1 {
+			t.Fatalf("expected exactly 1 statement, got %d", len(newStatements))
+		}
+		if !reflect.DeepEqual(newStatements[0], statement) {
+			t.Errorf("actual != expected: %#v != %#v", newStatements[0], statement)
+		}
+	}
+}
+
+func TestDHCPv6Statements_decodeInvalid(t *testing.T) {
+	for _, data := range []string{
+		"prefix6 2001:db8:: 2001:db8:ff:: /129;",
+		"fixed-address6 1:2:3:4:5:6:7:8:9;",
+		"subnet6 2001:db8::1 { }",
+	} {
+		if _, err := Decode(strings.NewReader(data)); err == nil {
+			t.Errorf("expected error decoding %q", data)
+		}
+	}
+}
+
+func TestStringEscapes_roundtrip(t *testing.T) {
+	values := []string{
+		`say "hi"`,
+		`C:\tftp\`,
+		"tab\there",
+		"\x00\x01\xff",
+	}
+	for _, value := range values {
+		statements := []Statement{
+			DDNSHostNameStatement(value),
+			ClassStatement{Name: value},
+			IncludeStatement{Filename: value},
+			FailoverPeerStatement{Name: value},
+			MatchStatement{Data: StringConstTerm(value)},
+		}
+		for _, statement := range statements {
+			text := statement.IndentedString("")
+			newStatements, err := Decode(strings.NewReader(text))
+			if err != nil {
+				t.Fatalf("%q: unexpected error: %s", text, err)
+			}
+			if len(newStatements) != 1 {
+				t.Fatalf("expected exactly 1 statement, got %d", len(newStatements))
+			}
+			if !reflect.DeepEqual(newStatements[0], statement) {
+				t.Errorf("actual != expected: %#v != %#v", newStatements[0], statement)
+			}
+		}
+	}
+}
+
+func TestStringEscapes_decode(t *testing.T) {
+	testCases := []struct {
+		data     string
+		expected string
+	}{
+		{`ddns-hostname "\101\x42C";`, "ABC"},
+		{`ddns-hostname "a\nb\tc\r\b";`, "a\nb\tc\r\b"},
+		{`ddns-hostname "\0";`, "\x00"},
+		{`ddns-hostname "\q";`, "q"},
+	}
+	for _, tc := range testCases {
+		statements, err := Decode(strings.NewReader(tc.data))
+		if err != nil {
+			t.Fatalf("%q: unexpected error: %s", tc.data, err)
+		}
+		if actual := string(Unwrap(statements[0]).(DDNSHostNameStatement)); actual != tc.expected {
+			t.Errorf("%q: expected %q, got %q", tc.data, tc.expected, actual)
+		}
+	}
+
+	if _, err := Decode(strings.NewReader(`ddns-hostname "\777";`)); err == nil {
+		t.Error("expected error for out-of-range octal escape")
+	}
+}
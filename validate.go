@@ -0,0 +1,272 @@
+package iscdhcp
+
+import (
+	"bytes"
+	"fmt"
+	"net"
+)
+
+// A Severity is the seriousness of a Diagnostic.
+type Severity int
+
+// Severities of Diagnostic
+const (
+	// SeverityError marks a problem which would stop dhcpd from starting.
+	SeverityError Severity = iota
+	// SeverityWarning marks a likely mistake which dhcpd would accept.
+	SeverityWarning
+)
+
+func (s Severity) String() string {
+	if s == SeverityWarning {
+		return "warning"
+	}
+	return "error"
+}
+
+// A Diagnostic describes a problem found in a config by Validate.
+type Diagnostic struct {
+	Severity Severity
+	// Code identifies the kind of problem, e.g. "subnet-host-bits", so that
+	// diagnostics can be filtered without matching their messages.
+	Code    string
+	Message string
+	// Position is where the statement at fault was found, if the config was
+	// decoded with the Positions option, or the zero Position otherwise.
+	Position Position
+}
+
+// newDiagnostic returns a Diagnostic concerning statement, positioned at it
+// if it's a *SourceStatement.
+func newDiagnostic(statement Statement, severity Severity, code, format string, args ...interface{}) Diagnostic {
+	d := Diagnostic{
+		Severity: severity,
+		Code:     code,
+		Message:  fmt.Sprintf(format, args...),
+	}
+	if ss, ok := statement.(*SourceStatement); ok {
+		d.Position = ss.Start
+	}
+	return d
+}
+
+// declaredAt describes where statement was declared, for a Diagnostic which
+// refers to it, if it's a *SourceStatement.
+func declaredAt(statement Statement) string {
+	if ss, ok := statement.(*SourceStatement); ok {
+		return " declared at " + ss.Start.String()
+	}
+	return ""
+}
+
+func (d Diagnostic) String() string {
+	s := fmt.Sprintf("%s: %s [%s]", d.Severity, d.Message, d.Code)
+	if d.Position.Line != 0 {
+		s = d.Position.String() + ": " + s
+	}
+	return s
+}
+
+// Validate checks a config for problems which the parser doesn't catch but
+// dhcpd would complain of at startup, returning a Diagnostic for each one.
+// Included files' statements are checked if they've been attached by
+// DecodeFS or DecodeFile.
+//
+// The checks made, by Diagnostic code, are:
+//
+//	subnet-netmask-noncontiguous  a subnet's netmask isn't a run of 1 bits
+//	subnet-host-bits              a subnet number has bits set outside its netmask
+//	subnet-nested                 a subnet is declared within another subnet
+//	shared-network-empty          a shared network declares no subnets
+//	fixed-address-outside-subnet  a host's fixed address isn't in the subnet
+//	                              or shared network enclosing it
+//	fixed-address-no-subnet       a host's fixed address isn't in any subnet
+//	                              (warning)
+//	hardware-address-invalid      a hardware address isn't hexadecimal octets
+//	class-duplicate               a class is declared more than once
+//	class-unknown                 a subclass or permit names an undeclared class
+//	pool-no-range                 a pool has no range statement (warning)
+//
+// These are followed by the Diagnostics of CheckAddressSpace, then those of
+// CheckHosts.
+func Validate(statements []Statement) []Diagnostic {
+	v := &validator{
+		classes:  make(map[string]bool),
+		declared: make(map[string]bool),
+	}
+	v.gather(statements)
+	v.check(statements, nil)
+	v.diagnostics = append(v.diagnostics, CheckAddressSpace(statements)...)
+	return append(v.diagnostics, CheckHosts(statements)...)
+}
+
+// A validator accumulates the Diagnostics found by Validate.
+type validator struct {
+	diagnostics []Diagnostic
+	// subnets and classes hold the subnets and class names declared anywhere
+	// in the config.
+	subnets []SubnetStatement
+	classes map[string]bool
+	// declared holds the class names checked so far, to catch duplicates.
+	declared map[string]bool
+}
+
+func (v *validator) report(statement Statement, severity Severity, code, format string, args ...interface{}) {
+	v.diagnostics = append(v.diagnostics, newDiagnostic(statement, severity, code, format, args...))
+}
+
+// gather records the subnets and classes declared within statements.
+func (v *validator) gather(statements []Statement) {
+	for _, statement := range flattenIncludes(statements) {
+		inner := Unwrap(statement)
+		switch st := inner.(type) {
+		case SubnetStatement:
+			v.subnets = append(v.subnets, st)
+		case ClassStatement:
+			v.classes[st.Name] = true
+		}
+		if bs, ok := inner.(blockStatement); ok {
+			bs.mapBlocks(func(block []Statement) []Statement {
+				v.gather(block)
+				return block
+			})
+		}
+	}
+}
+
+// check validates statements found within the declarations in enclosing,
+// outermost first.
+func (v *validator) check(statements []Statement, enclosing []Statement) {
+	for _, statement := range flattenIncludes(statements) {
+		inner := Unwrap(statement)
+		switch st := inner.(type) {
+		case SubnetStatement:
+			v.checkSubnet(statement, st, enclosing)
+		case SharedNetworkStatement:
+			if len(subnetsWithin(st.Statements)) == 0 {
+				v.report(statement, SeverityError, "shared-network-empty",
+					"shared-network %s declares no subnets", quoteString(st.Name))
+			}
+		case HostStatement:
+			v.checkHost(statement, st, enclosing)
+		case HardwareStatement:
+			if _, err := hexStringOctets(st.HardwareAddress); err != nil {
+				v.report(statement, SeverityError, "hardware-address-invalid",
+					"invalid hardware address %q", st.HardwareAddress)
+			}
+		case ClassStatement:
+			if v.declared[st.Name] {
+				v.report(statement, SeverityError, "class-duplicate",
+					"class %s is already declared", quoteString(st.Name))
+			}
+			v.declared[st.Name] = true
+		case SubclassStatement:
+			if !v.classes[st.ClassName] {
+				v.report(statement, SeverityError, "class-unknown",
+					"subclass of undeclared class %s", quoteString(st.ClassName))
+			}
+		case AllowDenyStatement:
+			if st.Flag == "members of" && !v.classes[st.ClassName] {
+				v.report(statement, SeverityError, "class-unknown",
+					"permit for members of undeclared class %s", quoteString(st.ClassName))
+			}
+		case PoolStatement:
+			if !hasRange(st.Statements) {
+				v.report(statement, SeverityWarning, "pool-no-range", "pool has no range statement")
+			}
+		}
+
+		if bs, ok := inner.(blockStatement); ok {
+			nested := append(enclosing[:len(enclosing):len(enclosing)], inner)
+			bs.mapBlocks(func(block []Statement) []Statement {
+				v.check(block, nested)
+				return block
+			})
+		}
+	}
+}
+
+func (v *validator) checkSubnet(statement Statement, ss SubnetStatement, enclosing []Statement) {
+	mask := net.IPMask(ss.Netmask.To4())
+	if ones, bits := mask.Size(); ones == 0 && bits == 0 {
+		v.report(statement, SeverityError, "subnet-netmask-noncontiguous",
+			"subnet %s has non-contiguous netmask %s", ss.SubnetNumber, ss.Netmask)
+	} else if number := ss.SubnetNumber.To4(); !bytes.Equal(number.Mask(mask), number) {
+		v.report(statement, SeverityError, "subnet-host-bits",
+			"subnet number %s has bits set outside netmask %s", ss.SubnetNumber, ss.Netmask)
+	}
+	for _, outer := range enclosing {
+		if _, ok := outer.(SubnetStatement); ok {
+			v.report(statement, SeverityError, "subnet-nested",
+				"subnet %s is declared within another subnet", ss.SubnetNumber)
+			break
+		}
+	}
+}
+
+func (v *validator) checkHost(statement Statement, hs HostStatement, enclosing []Statement) {
+	// the innermost subnet or shared network limits where a fixed address
+	// can be
+	var network []SubnetStatement
+	var networkDesc string
+	for i := len(enclosing) - 1; i >= 0 && network == nil; i-- {
+		switch st := enclosing[i].(type) {
+		case SubnetStatement:
+			network = []SubnetStatement{st}
+			networkDesc = "subnet " + st.SubnetNumber.String()
+		case SharedNetworkStatement:
+			network = subnetsWithin(st.Statements)
+			networkDesc = "shared-network " + quoteString(st.Name)
+		}
+	}
+
+	for _, child := range flattenIncludes(hs.Statements) {
+		fas, ok := Unwrap(child).(FixedAddressStatement)
+		if !ok {
+			continue
+		}
+		for _, ip := range fas {
+			switch {
+			case network != nil && !anySubnetContains(network, ip):
+				v.report(child, SeverityError, "fixed-address-outside-subnet",
+					"fixed address %s of host %s is outside its %s", ip, hs.Hostname, networkDesc)
+			case network == nil && !anySubnetContains(v.subnets, ip):
+				v.report(child, SeverityWarning, "fixed-address-no-subnet",
+					"fixed address %s of host %s isn't in any declared subnet", ip, hs.Hostname)
+			}
+		}
+	}
+}
+
+// subnetsWithin returns the subnets declared within statements, including
+// within groups.
+func subnetsWithin(statements []Statement) []SubnetStatement {
+	var subnets []SubnetStatement
+	for _, statement := range flattenIncludes(statements) {
+		switch st := Unwrap(statement).(type) {
+		case SubnetStatement:
+			subnets = append(subnets, st)
+		case GroupStatement:
+			subnets = append(subnets, subnetsWithin(st.Statements)...)
+		}
+	}
+	return subnets
+}
+
+func anySubnetContains(subnets []SubnetStatement, ip net.IP) bool {
+	for _, ss := range subnets {
+		if subnetContains(ss, ip) {
+			return true
+		}
+	}
+	return false
+}
+
+func hasRange(statements []Statement) bool {
+	for _, statement := range flattenIncludes(statements) {
+		if _, ok := Unwrap(statement).(RangeStatement); ok {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,211 @@
+package iscdhcp
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+	"testing/fstest"
+)
+
+// A diagnosticTestCase is a config snippet along with the Diagnostics, as
+// strings, which a check should find in it. If included is set the config is
+// decoded as dhcpd.conf, alongside included as included.conf.
+type diagnosticTestCase struct {
+	config   string
+	included string
+	expected []string
+}
+
+func (tc diagnosticTestCase) decode(opts ...DecodeOption) ([]Statement, error) {
+	if tc.included == "" {
+		return Decode(strings.NewReader(tc.config), opts...)
+	}
+	fsys := fstest.MapFS{
+		"dhcpd.conf":    {Data: []byte(tc.config)},
+		"included.conf": {Data: []byte(tc.included)},
+	}
+	return DecodeFS(fsys, "dhcpd.conf", opts...)
+}
+
+// testDiagnostics runs check over each test case's config, decoded with the
+// Positions option. The same problems should be found without it, although
+// they can't be located.
+func testDiagnostics(t *testing.T, check func([]Statement) []Diagnostic, testCases []diagnosticTestCase) {
+	t.Helper()
+	for _, tc := range testCases {
+		statements, err := tc.decode(Positions())
+		if err != nil {
+			t.Errorf("Decode(): %s\n%s", err, tc.config)
+			continue
+		}
+		var actual []string
+		for _, d := range check(statements) {
+			actual = append(actual, d.String())
+		}
+		if !reflect.DeepEqual(actual, tc.expected) {
+			t.Errorf("%s\nexpected:\n%s\ngot:\n%s", tc.config, strings.Join(tc.expected, "\n"), strings.Join(actual, "\n"))
+		}
+
+		statements, err = tc.decode()
+		if err != nil {
+			t.Errorf("Decode(): %s\n%s", err, tc.config)
+			continue
+		}
+		diagnostics := check(statements)
+		if len(diagnostics) != len(actual) {
+			t.Errorf("%s\nexpected %d diagnostics without positions, got %v", tc.config, len(actual), diagnostics)
+			continue
+		}
+		for i, d := range diagnostics {
+			if !strings.HasSuffix(actual[i], "["+d.Code+"]") || d.Position != (Position{}) {
+				t.Errorf("%s\nexpected %q without a position, got %q", tc.config, actual[i], d)
+			}
+		}
+	}
+}
+
+func TestValidate(t *testing.T) {
+	testDiagnostics(t, Validate, []diagnosticTestCase{
+		{
+			config: `subnet 10.0.0.0 netmask 255.255.255.0 {
+    host printer {
+        hardware ethernet 0:1:2:3:4:5;
+        fixed-address 10.0.0.5;
+    }
+}
+`,
+		},
+		{
+			config: `subnet 10.1.0.0 netmask 255.0.255.0 {
+}
+`,
+			expected: []string{
+				`1:1: error: subnet 10.1.0.0 has non-contiguous netmask 255.0.255.0 [subnet-netmask-noncontiguous]`,
+			},
+		},
+		{
+			config: `subnet 10.0.0.1 netmask 255.255.255.0 {
+}
+`,
+			expected: []string{
+				`1:1: error: subnet number 10.0.0.1 has bits set outside netmask 255.255.255.0 [subnet-host-bits]`,
+			},
+		},
+		{
+			config: `subnet 10.0.0.0 netmask 255.255.255.0 {
+    subnet 10.1.0.0 netmask 255.255.255.0 {
+    }
+}
+`,
+			expected: []string{
+				`2:5: error: subnet 10.1.0.0 is declared within another subnet [subnet-nested]`,
+			},
+		},
+		{
+			config: `shared-network "empty" {
+    option routers 10.0.0.1;
+}
+`,
+			expected: []string{
+				`1:1: error: shared-network "empty" declares no subnets [shared-network-empty]`,
+			},
+		},
+		{
+			config: `subnet 10.0.0.0 netmask 255.255.255.0 {
+    host printer {
+        fixed-address 10.0.1.5;
+    }
+}
+shared-network "campus" {
+    group {
+        subnet 10.2.0.0 netmask 255.255.255.0 {
+        }
+    }
+    host scanner {
+        fixed-address 10.2.0.5, 10.3.0.5;
+    }
+}
+`,
+			expected: []string{
+				`3:9: error: fixed address 10.0.1.5 of host printer is outside its subnet 10.0.0.0 [fixed-address-outside-subnet]`,
+				`12:9: error: fixed address 10.3.0.5 of host scanner is outside its shared-network "campus" [fixed-address-outside-subnet]`,
+			},
+		},
+		{
+			config: `subnet 10.0.0.0 netmask 255.255.255.0 {
+}
+host laptop {
+    fixed-address 192.168.0.5;
+}
+`,
+			expected: []string{
+				`4:5: warning: fixed address 192.168.0.5 of host laptop isn't in any declared subnet [fixed-address-no-subnet]`,
+			},
+		},
+		{
+			config: `class "pxe" {
+    match if substring(option vendor-class-identifier, 0, 9) = "PXEClient";
+}
+class "pxe" {
+}
+`,
+			expected: []string{
+				`4:1: error: class "pxe" is already declared [class-duplicate]`,
+			},
+		},
+		{
+			config: `subclass "vendors" "iPXE";
+subnet 10.0.0.0 netmask 255.255.255.0 {
+    pool {
+        range 10.0.0.10 10.0.0.20;
+        allow members of "nobody";
+    }
+}
+`,
+			expected: []string{
+				`1:1: error: subclass of undeclared class "vendors" [class-unknown]`,
+				`5:9: error: permit for members of undeclared class "nobody" [class-unknown]`,
+			},
+		},
+		{
+			config: `subnet 10.0.0.0 netmask 255.255.255.0 {
+    pool {
+        allow unknown-clients;
+    }
+}
+`,
+			expected: []string{
+				`2:5: warning: pool has no range statement [pool-no-range]`,
+			},
+		},
+		{
+			config: `subnet 10.0.0.0 netmask 255.255.255.0 {
+}
+subnet 10.0.0.0 netmask 255.255.0.0 {
+}
+`,
+			expected: []string{
+				`3:1: error: subnet 10.0.0.0 netmask 255.255.0.0 overlaps subnet 10.0.0.0 netmask 255.255.255.0 declared at 1:1 [subnet-overlap]`,
+			},
+		},
+	})
+}
+
+// An invalid hardware address can't be decoded, so the statement has to be
+// built by hand.
+func TestValidate_invalidHardwareAddress(t *testing.T) {
+	statements := []Statement{
+		HostStatement{
+			Hostname:   "broken",
+			Statements: []Statement{HardwareStatement{HardwareType: "ethernet", HardwareAddress: "0:1:2:3:4:zz"}},
+		},
+	}
+	expected := []Diagnostic{{
+		Severity: SeverityError,
+		Code:     "hardware-address-invalid",
+		Message:  `invalid hardware address "0:1:2:3:4:zz"`,
+	}}
+	if actual := Validate(statements); !reflect.DeepEqual(actual, expected) {
+		t.Errorf("expected %v, got %v", expected, actual)
+	}
+}
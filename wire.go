@@ -0,0 +1,350 @@
+package iscdhcp
+
+import (
+	"encoding/binary"
+	"fmt"
+	"net"
+	"strconv"
+	"strings"
+)
+
+// EncodeWire returns an option with the given value in the wire format used
+// in DHCP packets: a one-byte code, a one-byte length, and the value encoded
+// as described by RFC 2132 and, for destination descriptors, RFC 3442. The
+// value is given as in an OptionStatement's Values.
+//
+// An error is returned if the value doesn't suit the option's type, or its
+// encoding is longer than the 255 bytes an option can hold.
+func (od OptionDefinition) EncodeWire(values [][]interface{}) ([]byte, error) {
+	if od.Code < 0 || od.Code > 255 {
+		return nil, fmt.Errorf("option %q: code %d doesn't fit in a byte", od.QualifiedName(), od.Code)
+	}
+	payload, err := encodeOptionValues(od.Type, values)
+	if err != nil {
+		return nil, fmt.Errorf("option %q: %s", od.QualifiedName(), err)
+	}
+	if len(payload) > 255 {
+		return nil, fmt.Errorf("option %q: value is %d bytes long, more than the 255 an option can hold", od.QualifiedName(), len(payload))
+	}
+	return append([]byte{byte(od.Code), byte(len(payload))}, payload...), nil
+}
+
+// DecodeWire interprets an option in the wire format produced by EncodeWire,
+// returning its value as it would be held in an OptionStatement's Values.
+// Values of OptionFieldString and OptionFieldEncapsulation fields are
+// returned as HexStringTerms.
+func (od OptionDefinition) DecodeWire(b []byte) ([][]interface{}, error) {
+	switch {
+	case len(b) < 2:
+		return nil, fmt.Errorf("option %q: truncated option", od.QualifiedName())
+	case int(b[0]) != od.Code:
+		return nil, fmt.Errorf("option %q: expected code %d but found %d", od.QualifiedName(), od.Code, b[0])
+	case int(b[1]) != len(b)-2:
+		return nil, fmt.Errorf("option %q: length %d doesn't match the %d bytes of its value", od.QualifiedName(), b[1], len(b)-2)
+	}
+	values, err := decodeWireValues(od.Type, b[2:])
+	if err != nil {
+		return nil, fmt.Errorf("option %q: %s", od.QualifiedName(), err)
+	}
+	return values, nil
+}
+
+func encodeOptionValues(ot OptionType, values [][]interface{}) ([]byte, error) {
+	if !ot.Array && len(values) != 1 {
+		return nil, fmt.Errorf("expected a single value but found %d", len(values))
+	}
+	var b []byte
+	for i, element := range values {
+		if len(element) != len(ot.Fields) {
+			return nil, fmt.Errorf("expected %d field(s) in value %d but found %d", len(ot.Fields), i+1, len(element))
+		}
+		for j, field := range element {
+			var err error
+			b, err = encodeOptionField(b, ot.Fields[j], field)
+			if err != nil {
+				return nil, err
+			}
+		}
+	}
+	return b, nil
+}
+
+// encodeOptionField appends the wire encoding of a field's value to b.
+func encodeOptionField(b []byte, of OptionField, v interface{}) ([]byte, error) {
+	switch of.Kind {
+	case OptionFieldIPAddress:
+		if ip, ok := v.(net.IP); ok && ip.To4() != nil {
+			return append(b, ip.To4()...), nil
+		}
+	case OptionFieldIP6Address:
+		if ip, ok := v.(net.IP); ok && ip.To16() != nil {
+			return append(b, ip.To16()...), nil
+		}
+	case OptionFieldText:
+		if s, ok := v.(string); ok {
+			for i := 0; i < len(s); i++ {
+				if s[i] >= 0x80 {
+					return nil, fmt.Errorf("text %q isn't ASCII", s)
+				}
+			}
+			return append(b, s...), nil
+		}
+	case OptionFieldString, OptionFieldEncapsulation:
+		switch s := v.(type) {
+		case StringConstTerm:
+			return append(b, s...), nil
+		case HexStringTerm:
+			octets, err := hexStringOctets(string(s))
+			if err != nil {
+				return nil, err
+			}
+			return append(b, octets...), nil
+		}
+	case OptionFieldBoolean:
+		if flag, ok := v.(bool); ok {
+			if flag {
+				return append(b, 1), nil
+			}
+			return append(b, 0), nil
+		}
+	case OptionFieldInteger:
+		if n, ok := v.(int); ok {
+			min, max := int64(0), int64(1)<<uint(of.Width)-1
+			if of.Signed {
+				min, max = -int64(1)<<uint(of.Width-1), int64(1)<<uint(of.Width-1)-1
+			}
+			if int64(n) < min || int64(n) > max {
+				return nil, fmt.Errorf("%d out of range for %s", n, of)
+			}
+			for shift := of.Width - 8; shift >= 0; shift -= 8 {
+				b = append(b, byte(n>>uint(shift)))
+			}
+			return b, nil
+		}
+	case OptionFieldDomainName:
+		if s, ok := v.(string); ok {
+			return appendDomainName(b, s, nil)
+		}
+	case OptionFieldDomainList:
+		if names, ok := v.([]string); ok {
+			// a domain-list is always the sole field of its type, so
+			// offsets within b are offsets within the option's value, which
+			// is what compression pointers refer to
+			var offsets map[string]int
+			if of.Compressed {
+				offsets = make(map[string]int)
+			}
+			for _, name := range names {
+				var err error
+				if b, err = appendDomainName(b, name, offsets); err != nil {
+					return nil, err
+				}
+			}
+			return b, nil
+		}
+	case OptionFieldDestinationDescriptor:
+		if ipNet, ok := v.(*net.IPNet); ok && ipNet.IP.To4() != nil {
+			ones, bits := ipNet.Mask.Size()
+			if bits != 8*net.IPv4len {
+				return nil, fmt.Errorf("%s isn't an IPv4 network", ipNet)
+			}
+			b = append(b, byte(ones))
+			return append(b, ipNet.IP.To4()[:(ones+7)/8]...), nil
+		}
+	}
+	return nil, fmt.Errorf("expected %s but found %#v", of, v)
+}
+
+// hexStringOctets converts colon-separated hexadecimal octets, as held by a
+// HexStringTerm, to bytes.
+func hexStringOctets(s string) ([]byte, error) {
+	var octets []byte
+	for _, digits := range strings.Split(s, ":") {
+		n, err := strconv.ParseUint(digits, 16, 8)
+		if err != nil {
+			return nil, fmt.Errorf("invalid hexadecimal octet %q", digits)
+		}
+		octets = append(octets, byte(n))
+	}
+	return octets, nil
+}
+
+// appendDomainName appends a domain name to b as a sequence of labels, per
+// RFC 1035. If offsets isn't nil, it's used to compress the name, and updated
+// with the offset within b of each of the name's suffixes.
+func appendDomainName(b []byte, name string, offsets map[string]int) ([]byte, error) {
+	name = strings.TrimSuffix(name, ".")
+	if name == "" {
+		return append(b, 0), nil
+	}
+	labels := strings.Split(name, ".")
+	for i, label := range labels {
+		suffix := strings.ToLower(strings.Join(labels[i:], "."))
+		if offset, found := offsets[suffix]; found {
+			return append(b, 0xc0|byte(offset>>8), byte(offset)), nil
+		}
+		if offsets != nil && len(b) < 0x4000 {
+			offsets[suffix] = len(b)
+		}
+		if len(label) == 0 || len(label) > 63 {
+			return nil, fmt.Errorf("invalid label %q in domain name %q", label, name)
+		}
+		b = append(b, byte(len(label)))
+		b = append(b, label...)
+	}
+	return append(b, 0), nil
+}
+
+func decodeWireValues(ot OptionType, b []byte) ([][]interface{}, error) {
+	var values [][]interface{}
+	payload := b
+	for len(values) == 0 || ot.Array && len(b) != 0 {
+		element := make([]interface{}, len(ot.Fields))
+		for i, field := range ot.Fields {
+			var err error
+			element[i], b, err = decodeWireField(field, payload, b)
+			if err != nil {
+				return nil, err
+			}
+		}
+		values = append(values, element)
+		if !ot.Array && len(b) != 0 {
+			return nil, fmt.Errorf("%d unexpected byte(s) after value", len(b))
+		}
+	}
+	return values, nil
+}
+
+// decodeWireField interprets the field at the start of b, which is part of
+// payload, and returns its value and the bytes which follow it.
+func decodeWireField(of OptionField, payload, b []byte) (interface{}, []byte, error) {
+	need := func(n int) error {
+		if len(b) < n {
+			return fmt.Errorf("truncated %s", of)
+		}
+		return nil
+	}
+	switch of.Kind {
+	case OptionFieldIPAddress:
+		if err := need(net.IPv4len); err != nil {
+			return nil, nil, err
+		}
+		return net.IPv4(b[0], b[1], b[2], b[3]), b[net.IPv4len:], nil
+	case OptionFieldIP6Address:
+		if err := need(net.IPv6len); err != nil {
+			return nil, nil, err
+		}
+		return net.IP(append([]byte(nil), b[:net.IPv6len]...)), b[net.IPv6len:], nil
+	case OptionFieldText:
+		return string(b), nil, nil
+	case OptionFieldString, OptionFieldEncapsulation:
+		octets := make([]string, len(b))
+		for i, octet := range b {
+			octets[i] = fmt.Sprintf("%02x", octet)
+		}
+		return HexStringTerm(strings.Join(octets, ":")), nil, nil
+	case OptionFieldBoolean:
+		if err := need(1); err != nil {
+			return nil, nil, err
+		}
+		return b[0] != 0, b[1:], nil
+	case OptionFieldInteger:
+		size := of.Width / 8
+		if err := need(size); err != nil {
+			return nil, nil, err
+		}
+		var n int64
+		switch size {
+		case 1:
+			n = int64(b[0])
+			if of.Signed {
+				n = int64(int8(b[0]))
+			}
+		case 2:
+			n = int64(binary.BigEndian.Uint16(b))
+			if of.Signed {
+				n = int64(int16(n))
+			}
+		case 4:
+			n = int64(binary.BigEndian.Uint32(b))
+			if of.Signed {
+				n = int64(int32(n))
+			}
+		}
+		return int(n), b[size:], nil
+	case OptionFieldDomainName:
+		name, n, err := readDomainName(payload, len(payload)-len(b))
+		if err != nil {
+			return nil, nil, err
+		}
+		return name, b[n:], nil
+	case OptionFieldDomainList:
+		var names []string
+		for len(b) != 0 {
+			name, n, err := readDomainName(payload, len(payload)-len(b))
+			if err != nil {
+				return nil, nil, err
+			}
+			names = append(names, name)
+			b = b[n:]
+		}
+		return names, nil, nil
+	case OptionFieldDestinationDescriptor:
+		if err := need(1); err != nil {
+			return nil, nil, err
+		}
+		ones := int(b[0])
+		size := (ones + 7) / 8
+		if ones > 32 {
+			return nil, nil, fmt.Errorf("invalid prefix length %d in %s", ones, of)
+		}
+		if err := need(1 + size); err != nil {
+			return nil, nil, err
+		}
+		ip := make(net.IP, net.IPv4len)
+		copy(ip, b[1:1+size])
+		return &net.IPNet{IP: ip, Mask: net.CIDRMask(ones, 32)}, b[1+size:], nil
+	}
+	return nil, nil, fmt.Errorf("unknown field type %s", of)
+}
+
+// readDomainName reads the RFC 1035 domain name at the given offset in
+// payload, following any compression pointers, and returns it along with the
+// number of bytes it occupies at that offset.
+func readDomainName(payload []byte, offset int) (string, int, error) {
+	var labels []string
+	size := -1
+	for pos, jumps := offset, 0; ; {
+		if pos >= len(payload) {
+			return "", 0, fmt.Errorf("truncated domain name")
+		}
+		n := int(payload[pos])
+		switch {
+		case n == 0:
+			if size == -1 {
+				size = pos + 1 - offset
+			}
+			return strings.Join(labels, "."), size, nil
+		case n&0xc0 == 0xc0:
+			if pos+1 >= len(payload) {
+				return "", 0, fmt.Errorf("truncated domain name")
+			}
+			if size == -1 {
+				size = pos + 2 - offset
+			}
+			// no valid name needs more pointers than there are bytes
+			if jumps++; jumps > len(payload) {
+				return "", 0, fmt.Errorf("compression loop in domain name")
+			}
+			pos = (n&0x3f)<<8 | int(payload[pos+1])
+		case n > 63:
+			return "", 0, fmt.Errorf("invalid label length %d in domain name", n)
+		default:
+			if pos+1+n > len(payload) {
+				return "", 0, fmt.Errorf("truncated domain name")
+			}
+			labels = append(labels, string(payload[pos+1:pos+1+n]))
+			pos += 1 + n
+		}
+	}
+}
@@ -0,0 +1,137 @@
+package iscdhcp
+
+import (
+	"bytes"
+	"net"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestOptionDefinition_wire(t *testing.T) {
+	_, route, _ := net.ParseCIDR("10.17.0.0/16")
+	_, defaultRoute, _ := net.ParseCIDR("0.0.0.0/0")
+	testCases := []struct {
+		name   string
+		values [][]interface{}
+		wire   []byte
+	}{
+		{
+			"subnet-mask",
+			[][]interface{}{{net.ParseIP("255.255.255.0")}},
+			[]byte{1, 4, 255, 255, 255, 0},
+		},
+		{
+			"routers",
+			[][]interface{}{{net.ParseIP("10.0.0.1")}, {net.ParseIP("10.0.0.2")}},
+			[]byte{3, 8, 10, 0, 0, 1, 10, 0, 0, 2},
+		},
+		{
+			"time-offset",
+			[][]interface{}{{-18000}},
+			[]byte{2, 4, 0xff, 0xff, 0xb9, 0xb0},
+		},
+		{
+			"interface-mtu",
+			[][]interface{}{{9000}},
+			[]byte{26, 2, 0x23, 0x28},
+		},
+		{
+			"ip-forwarding",
+			[][]interface{}{{true}},
+			[]byte{19, 1, 1},
+		},
+		{
+			"domain-name",
+			[][]interface{}{{"example.com"}},
+			[]byte{15, 11, 'e', 'x', 'a', 'm', 'p', 'l', 'e', '.', 'c', 'o', 'm'},
+		},
+		{
+			"dhcp-client-identifier",
+			[][]interface{}{{HexStringTerm("01:00:c0:ff:ee:00:01")}},
+			[]byte{61, 7, 1, 0, 0xc0, 0xff, 0xee, 0, 1},
+		},
+		{
+			// RFC 3397, section 4
+			"domain-search",
+			[][]interface{}{{[]string{"eng.apple.com", "marketing.apple.com"}}},
+			[]byte{119, 27,
+				3, 'e', 'n', 'g', 5, 'a', 'p', 'p', 'l', 'e', 3, 'c', 'o', 'm', 0,
+				9, 'm', 'a', 'r', 'k', 'e', 't', 'i', 'n', 'g', 0xc0, 4},
+		},
+		{
+			"classless-static-routes",
+			[][]interface{}{
+				{route, net.ParseIP("10.0.0.1")},
+				{defaultRoute, net.ParseIP("10.0.0.254")},
+			},
+			[]byte{121, 12, 16, 10, 17, 10, 0, 0, 1, 0, 10, 0, 0, 254},
+		},
+	}
+
+	for _, tc := range testCases {
+		def, _ := LookupOption(tc.name)
+		wire, err := def.EncodeWire(tc.values)
+		if err != nil {
+			t.Errorf("%s: unexpected error from EncodeWire(): %s", tc.name, err)
+			continue
+		}
+		if !bytes.Equal(wire, tc.wire) {
+			t.Errorf("%s: expected % x, got % x", tc.name, tc.wire, wire)
+		}
+		values, err := def.DecodeWire(tc.wire)
+		if err != nil {
+			t.Errorf("%s: unexpected error from DecodeWire(): %s", tc.name, err)
+			continue
+		}
+		if !reflect.DeepEqual(values, tc.values) {
+			t.Errorf("%s: expected %#v, got %#v", tc.name, tc.values, values)
+		}
+	}
+}
+
+func TestOptionDefinition_EncodeWireErrors(t *testing.T) {
+	testCases := []struct {
+		name     string
+		values   [][]interface{}
+		expected string
+	}{
+		{"subnet-mask", nil, `option "subnet-mask": expected a single value but found 0`},
+		{"routers", [][]interface{}{{net.ParseIP("::1")}}, `option "routers": expected ip-address but found net.IP{`},
+		{"default-ip-ttl", [][]interface{}{{256}}, `option "default-ip-ttl": 256 out of range for unsigned integer 8`},
+		{"host-name", [][]interface{}{{"café"}}, `option "host-name": text "café" isn't ASCII`},
+		{"dhcp-client-identifier", [][]interface{}{{HexStringTerm("1:zz")}}, `option "dhcp-client-identifier": invalid hexadecimal octet "zz"`},
+		{"root-path", [][]interface{}{{strings.Repeat("x", 256)}}, `option "root-path": value is 256 bytes long, more than the 255 an option can hold`},
+	}
+	for _, tc := range testCases {
+		def, _ := LookupOption(tc.name)
+		_, err := def.EncodeWire(tc.values)
+		if err == nil || !strings.HasPrefix(err.Error(), tc.expected) {
+			t.Errorf("%s: expected error %q, got %v", tc.name, tc.expected, err)
+		}
+	}
+}
+
+func TestOptionDefinition_DecodeWireErrors(t *testing.T) {
+	testCases := []struct {
+		name     string
+		wire     []byte
+		expected string
+	}{
+		{"subnet-mask", []byte{1}, `option "subnet-mask": truncated option`},
+		{"subnet-mask", []byte{3, 4, 255, 255, 255, 0}, `option "subnet-mask": expected code 1 but found 3`},
+		{"subnet-mask", []byte{1, 5, 255, 255, 255, 0}, `option "subnet-mask": length 5 doesn't match the 4 bytes of its value`},
+		{"subnet-mask", []byte{1, 5, 255, 255, 255, 0, 0}, `option "subnet-mask": 1 unexpected byte(s) after value`},
+		{"routers", []byte{3, 6, 10, 0, 0, 1, 10, 0}, `option "routers": truncated ip-address`},
+		{"domain-search", []byte{119, 4, 3, 'c', 'o', 'm'}, `option "domain-search": truncated domain name`},
+		{"domain-search", []byte{119, 2, 0xc0, 0}, `option "domain-search": compression loop in domain name`},
+		{"classless-static-routes", []byte{121, 5, 33, 10, 0, 0, 1}, `option "classless-static-routes": invalid prefix length 33 in destination-descriptor`},
+	}
+	for _, tc := range testCases {
+		def, _ := LookupOption(tc.name)
+		_, err := def.DecodeWire(tc.wire)
+		if err == nil || err.Error() != tc.expected {
+			t.Errorf("%s % x: expected error %q, got %v", tc.name, tc.wire, tc.expected, err)
+		}
+	}
+}
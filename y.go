@@ -16,50 +16,120 @@ type yySymType struct {
 	strList         []string
 	ipList          []net.IP
 	ip              net.IP
+	ipNet           *net.IPNet
 	statement       Statement
 	statementList   []Statement
 	dataTerm        fmt.Stringer
+	dataTermList    []fmt.Stringer
 	boolExpr        BooleanExpression
 	subConditionals []ConditionalStatement
+	optionTokens    []optionToken
+	// pos is the position of the first token of a symbol
+	pos Position
 }
 
 const openBrace = 57346
 const closeBrace = 57347
-const quote = 57348
-const semicolon = 57349
-const comma = 57350
-const ConditionIf = 57351
-const ConditionElsif = 57352
-const ConditionElse = 57353
-const BoolAnd = 57354
-const BoolOr = 57355
-const BoolNot = 57356
-const BoolEqual = 57357
-const BoolInequal = 57358
-const BoolRegexMatch = 57359
-const BoolRegexIMatch = 57360
-const BoolExists = 57361
-const BoolKnown = 57362
-const BoolStatic = 57363
-const number = 57364
-const ipAddr = 57365
-const cidr = 57366
-const stringConst = 57367
-const macAddr = 57368
-const stateTok = 57369
-const authoritativeTok = 57370
-const groupTok = 57371
-const hostTok = 57372
-const subnetTok = 57373
-const netmaskTok = 57374
-const optionTok = 57375
-const includeTok = 57376
-const hardwareTok = 57377
-const ethernetTok = 57378
-const fixedAddrTok = 57379
-const useHostDeclNamesTok = 57380
-const optDomainNameServersTok = 57381
-const word = 57382
+const openParen = 57348
+const closeParen = 57349
+const quote = 57350
+const semicolon = 57351
+const comma = 57352
+const ConditionIf = 57353
+const ConditionElsif = 57354
+const ConditionElse = 57355
+const BoolAnd = 57356
+const BoolOr = 57357
+const BoolNot = 57358
+const BoolEqual = 57359
+const BoolInequal = 57360
+const BoolRegexMatch = 57361
+const BoolRegexIMatch = 57362
+const BoolExists = 57363
+const BoolKnown = 57364
+const BoolStatic = 57365
+const AccessAllow = 57366
+const AccessDeny = 57367
+const AccessIgnore = 57368
+const binaryToASCIITok = 57369
+const clientStateTok = 57370
+const concatTok = 57371
+const configOptionTok = 57372
+const encodeIntTok = 57373
+const extractIntTok = 57374
+const hostDeclNameTok = 57375
+const lcaseTok = 57376
+const leaseTimeTok = 57377
+const leasedAddressTok = 57378
+const packetTok = 57379
+const pickFirstValueTok = 57380
+const reverseTok = 57381
+const substringTok = 57382
+const suffixTok = 57383
+const ucaseTok = 57384
+const number = 57385
+const ipAddr = 57386
+const cidr = 57387
+const stringConst = 57388
+const macAddr = 57389
+const hexString = 57390
+const ip6Addr = 57391
+const ip6CIDR = 57392
+const prefixLength = 57393
+const stateTok = 57394
+const authoritativeTok = 57395
+const groupTok = 57396
+const hostTok = 57397
+const sharedNetworkTok = 57398
+const subnetTok = 57399
+const netmaskTok = 57400
+const optionTok = 57401
+const includeTok = 57402
+const poolTok = 57403
+const rangeTok = 57404
+const dynamicBootpTok = 57405
+const subnet6Tok = 57406
+const range6Tok = 57407
+const prefix6Tok = 57408
+const temporaryTok = 57409
+const fixedAddr6Tok = 57410
+const fixedPrefix6Tok = 57411
+const classTok = 57412
+const subclassTok = 57413
+const matchTok = 57414
+const spawnTok = 57415
+const withTok = 57416
+const leaseTok = 57417
+const limitTok = 57418
+const hardwareTok = 57419
+const ethernetTok = 57420
+const fixedAddrTok = 57421
+const membersTok = 57422
+const ofTok = 57423
+const failoverTok = 57424
+const peerTok = 57425
+const useHostDeclNamesTok = 57426
+const codeTok = 57427
+const spaceTok = 57428
+const vendorOptionSpaceTok = 57429
+const adaptiveLeaseThresholdTok = 57430
+const alwaysBroadcastTok = 57431
+const alwaysReplyRFC1048Tok = 57432
+const bootUnknownClientsTok = 57433
+const dbTimeFormatTok = 57434
+const ddnsDomainNameTok = 57435
+const ddnsHostNameTok = 57436
+const ddnsRevDomainNameTok = 57437
+const ddnsUpdateStyleTok = 57438
+const ddnsUpdatesTok = 57439
+const defaultLeaseTimeTok = 57440
+const delayedAckTok = 57441
+const doForwardUpdatesTok = 57442
+const dynamicBootpLeaseCutoffTok = 57443
+const maxAckDelayTok = 57444
+const maxLeaseTimeTok = 57445
+const minLeaseTimeTok = 57446
+const word = 57447
 
 var yyToknames = [...]string{
 	"$end",
@@ -67,6 +137,8 @@ var yyToknames = [...]string{
 	"$unk",
 	"openBrace",
 	"closeBrace",
+	"openParen",
+	"closeParen",
 	"quote",
 	"semicolon",
 	"comma",
@@ -83,33 +155,97 @@ var yyToknames = [...]string{
 	"BoolExists",
 	"BoolKnown",
 	"BoolStatic",
+	"AccessAllow",
+	"AccessDeny",
+	"AccessIgnore",
+	"binaryToASCIITok",
+	"clientStateTok",
+	"concatTok",
+	"configOptionTok",
+	"encodeIntTok",
+	"extractIntTok",
+	"hostDeclNameTok",
+	"lcaseTok",
+	"leaseTimeTok",
+	"leasedAddressTok",
+	"packetTok",
+	"pickFirstValueTok",
+	"reverseTok",
+	"substringTok",
+	"suffixTok",
+	"ucaseTok",
 	"number",
 	"ipAddr",
 	"cidr",
 	"stringConst",
 	"macAddr",
+	"hexString",
+	"ip6Addr",
+	"ip6CIDR",
+	"prefixLength",
 	"stateTok",
 	"authoritativeTok",
 	"groupTok",
 	"hostTok",
+	"sharedNetworkTok",
 	"subnetTok",
 	"netmaskTok",
 	"optionTok",
 	"includeTok",
+	"poolTok",
+	"rangeTok",
+	"dynamicBootpTok",
+	"subnet6Tok",
+	"range6Tok",
+	"prefix6Tok",
+	"temporaryTok",
+	"fixedAddr6Tok",
+	"fixedPrefix6Tok",
+	"classTok",
+	"subclassTok",
+	"matchTok",
+	"spawnTok",
+	"withTok",
+	"leaseTok",
+	"limitTok",
 	"hardwareTok",
 	"ethernetTok",
 	"fixedAddrTok",
+	"membersTok",
+	"ofTok",
+	"failoverTok",
+	"peerTok",
 	"useHostDeclNamesTok",
-	"optDomainNameServersTok",
+	"codeTok",
+	"spaceTok",
+	"vendorOptionSpaceTok",
+	"adaptiveLeaseThresholdTok",
+	"alwaysBroadcastTok",
+	"alwaysReplyRFC1048Tok",
+	"bootUnknownClientsTok",
+	"dbTimeFormatTok",
+	"ddnsDomainNameTok",
+	"ddnsHostNameTok",
+	"ddnsRevDomainNameTok",
+	"ddnsUpdateStyleTok",
+	"ddnsUpdatesTok",
+	"defaultLeaseTimeTok",
+	"delayedAckTok",
+	"doForwardUpdatesTok",
+	"dynamicBootpLeaseCutoffTok",
+	"maxAckDelayTok",
+	"maxLeaseTimeTok",
+	"minLeaseTimeTok",
 	"word",
 }
+
 var yyStatenames = [...]string{}
 
 const yyEofCode = 1
 const yyErrCode = 2
 const yyInitialStackSize = 16
 
-var yyExca = [...]int{
+var yyExca = [...]int8{
 	-1, 1,
 	1, -1,
 	-2, 0,
@@ -117,94 +253,294 @@ var yyExca = [...]int{
 
 const yyPrivate = 57344
 
-const yyLast = 125
-
-var yyAct = [...]int{
-
-	26, 36, 3, 31, 42, 25, 62, 28, 46, 41,
-	52, 32, 37, 39, 2, 69, 35, 34, 33, 18,
-	38, 47, 37, 29, 19, 64, 79, 70, 43, 50,
-	38, 30, 53, 54, 55, 78, 56, 57, 20, 14,
-	15, 17, 49, 23, 16, 21, 27, 22, 24, 82,
-	83, 67, 25, 58, 59, 60, 61, 27, 72, 73,
-	74, 75, 76, 77, 48, 54, 55, 68, 18, 80,
-	65, 81, 63, 19, 66, 65, 51, 40, 45, 44,
-	71, 13, 12, 11, 85, 86, 84, 20, 14, 15,
-	17, 10, 23, 16, 21, 18, 22, 24, 9, 8,
-	19, 7, 6, 5, 4, 1, 0, 0, 0, 0,
-	0, 0, 0, 0, 20, 14, 15, 17, 0, 23,
-	16, 21, 0, 22, 24,
+const yyLast = 666
+
+var yyAct = [...]int16{
+	165, 394, 303, 97, 339, 3, 221, 316, 95, 159,
+	351, 148, 191, 222, 145, 315, 314, 288, 223, 229,
+	203, 199, 320, 209, 274, 398, 399, 273, 59, 2,
+	409, 400, 223, 64, 210, 215, 246, 192, 227, 196,
+	194, 92, 93, 94, 147, 146, 224, 150, 147, 146,
+	153, 153, 398, 399, 290, 144, 321, 355, 400, 147,
+	146, 349, 289, 240, 396, 152, 149, 352, 202, 151,
+	65, 48, 49, 55, 57, 201, 87, 50, 51, 53,
+	241, 58, 54, 52, 217, 80, 81, 47, 56, 83,
+	89, 396, 82, 99, 78, 213, 79, 358, 313, 77,
+	230, 90, 397, 233, 91, 60, 62, 63, 66, 67,
+	68, 69, 70, 71, 72, 73, 74, 75, 76, 84,
+	85, 86, 225, 272, 158, 200, 395, 156, 232, 397,
+	143, 305, 306, 307, 308, 309, 310, 311, 242, 96,
+	312, 328, 226, 238, 313, 150, 212, 214, 157, 154,
+	391, 390, 362, 395, 361, 360, 193, 245, 243, 244,
+	235, 237, 247, 248, 295, 253, 239, 220, 219, 218,
+	251, 252, 208, 206, 161, 258, 259, 305, 306, 307,
+	308, 309, 310, 311, 160, 205, 312, 189, 382, 164,
+	163, 162, 414, 304, 405, 177, 184, 172, 169, 178,
+	179, 176, 182, 185, 175, 174, 180, 181, 170, 171,
+	183, 186, 357, 381, 166, 188, 187, 249, 250, 302,
+	195, 249, 250, 198, 366, 367, 296, 168, 317, 204,
+	402, 231, 207, 254, 255, 256, 257, 59, 95, 304,
+	377, 376, 64, 98, 371, 173, 375, 228, 374, 327,
+	92, 93, 94, 249, 250, 333, 334, 335, 336, 330,
+	331, 337, 338, 340, 341, 342, 343, 344, 340, 346,
+	347, 348, 345, 370, 292, 291, 371, 373, 372, 65,
+	48, 49, 55, 57, 369, 87, 50, 51, 53, 368,
+	58, 54, 52, 380, 80, 81, 47, 56, 83, 89,
+	364, 82, 98, 78, 359, 79, 363, 326, 77, 356,
+	90, 354, 353, 91, 60, 62, 63, 66, 67, 68,
+	69, 70, 71, 72, 73, 74, 75, 76, 84, 85,
+	86, 216, 365, 332, 325, 98, 324, 323, 322, 319,
+	249, 250, 318, 300, 299, 298, 297, 177, 184, 172,
+	169, 178, 179, 176, 182, 185, 175, 174, 180, 181,
+	170, 171, 183, 186, 294, 293, 166, 188, 187, 385,
+	386, 384, 387, 388, 389, 59, 383, 287, 392, 168,
+	64, 286, 285, 284, 283, 282, 281, 401, 92, 93,
+	94, 280, 279, 278, 277, 410, 276, 173, 275, 271,
+	236, 234, 197, 411, 416, 413, 412, 408, 407, 406,
+	404, 403, 379, 378, 270, 415, 269, 65, 48, 49,
+	55, 57, 268, 87, 50, 51, 53, 267, 58, 54,
+	52, 266, 80, 81, 47, 56, 83, 89, 265, 82,
+	264, 78, 263, 79, 262, 261, 77, 260, 90, 393,
+	88, 91, 60, 62, 63, 66, 67, 68, 69, 70,
+	71, 72, 73, 74, 75, 76, 84, 85, 86, 108,
+	109, 110, 118, 119, 120, 121, 122, 123, 124, 125,
+	126, 127, 128, 129, 130, 131, 132, 133, 301, 350,
+	61, 155, 211, 190, 167, 329, 46, 45, 44, 43,
+	42, 41, 40, 39, 38, 37, 101, 102, 103, 134,
+	135, 136, 137, 138, 139, 111, 112, 113, 114, 115,
+	116, 117, 36, 35, 34, 106, 107, 104, 105, 33,
+	140, 141, 142, 32, 31, 30, 29, 28, 27, 26,
+	25, 24, 23, 22, 21, 20, 19, 18, 17, 16,
+	100, 108, 109, 110, 118, 119, 120, 121, 122, 123,
+	124, 125, 126, 127, 128, 129, 130, 131, 132, 133,
+	15, 14, 13, 12, 11, 10, 9, 8, 7, 6,
+	5, 4, 1, 0, 0, 0, 0, 0, 101, 102,
+	103, 134, 135, 136, 137, 138, 139, 111, 112, 113,
+	114, 115, 116, 117, 0, 0, 0, 106, 107, 104,
+	105, 0, 140, 141, 142, 177, 184, 172, 169, 178,
+	179, 176, 182, 185, 175, 174, 180, 181, 170, 171,
+	183, 186, 100, 0, 166, 188, 187, 0, 0, 0,
+	0, 0, 0, 0, 0, 0, 0, 168, 0, 0,
+	0, 0, 0, 0, 0, 0, 0, 0, 0, 0,
+	0, 0, 0, 0, 0, 173,
 }
-var yyPact = [...]int{
-
-	86, -1000, 86, -1000, -1000, -1000, -1000, -1000, -1000, -1000,
-	-1000, -1000, -1000, -1000, 42, -33, -2, 8, -3, -15,
-	70, -27, 5, -31, -6, -1000, -1000, 59, 42, 69,
-	-22, 53, -3, -1000, -1000, -13, 38, -1000, -34, 65,
-	-1000, -1, 67, -1000, -1000, -1000, 5, 60, -1000, 10,
-	-1000, -1000, 4, -1000, -3, -3, 21, -1000, -13, -13,
-	-13, -13, -1000, -1000, 28, 3, -1000, 62, -1000, -1000,
-	42, 39, 21, 21, -1000, -1000, -1000, -1000, -1000, -1000,
-	-1000, -1000, -3, 42, 53, -1000, -1000,
+
+var yyPact = [...]int16{
+	364, -32768, 364, -32768, -32768, -32768, -32768, -32768, -32768, -32768,
+	-32768, -32768, -32768, -32768, -32768, -32768, -32768, -32768, -32768, -32768,
+	-32768, -32768, -32768, -32768, -32768, -32768, -32768, -32768, -32768, -32768,
+	-32768, -32768, -32768, -32768, -32768, -32768, -32768, -32768, -32768, -32768,
+	-32768, -32768, -32768, -32768, -32768, -32768, -32768, 93, 331, 527,
+	84, 331, 11, 3, 0, 445, 81, 104, 1, 168,
+	144, -68, -12, -12, -14, 393, -12, -84, 79, 29,
+	22, -85, -12, 142, 130, -12, 129, -60, -44, 102,
+	11, 1, -41, 320, 126, 125, 124, -73, 37, -36,
+	-12, -86, -32768, -32768, -32768, -32768, 331, -32768, 226, 331,
+	-32768, -32768, -32768, -32768, -32768, -32768, -32768, -32768, -32768, -32768,
+	-32768, -32768, -32768, -32768, -32768, -32768, -32768, -32768, -32768, -32768,
+	-32768, -32768, -32768, -32768, -32768, -32768, -32768, -32768, -32768, -32768,
+	-32768, -32768, -32768, -32768, -32768, -32768, -32768, -32768, -32768, -32768,
+	-32768, -32768, -32768, 392, -32768, 11, -32768, -32768, 391, 101,
+	99, -4, 71, -32768, 331, 331, 588, -22, 331, 239,
+	168, 168, -32768, -32768, 588, 216, -32768, -32768, -87, -87,
+	441, 439, 438, -32768, 436, -32768, -32768, 434, 432, 425,
+	421, 416, 410, 408, -32768, -32768, -32768, -32768, -32768, 390,
+	18, -57, -32768, 389, -32768, 387, 385, -32768, 384, 383,
+	382, 377, 376, 375, 374, 373, 372, 368, -88, 16,
+	7, 265, -32768, 356, 355, 121, 168, 337, 336, 335,
+	334, 134, -89, -32768, -32768, -90, -98, 588, 333, 330,
+	-32768, -32768, 17, -32768, -32768, 5, -32768, 329, -32768, 328,
+	327, -32768, 325, -32768, -32768, 298, 97, -32768, -32768, 168,
+	168, 207, 326, -32768, 588, 588, 588, 588, -32768, -32768,
+	588, 588, 588, 588, 588, 588, 588, 588, 588, 588,
+	588, -32768, -32768, -32768, 15, -32768, -32768, -32768, -32768, -32768,
+	-32768, -32768, -32768, -32768, -32768, -32768, -32768, -32768, -38, 303,
+	302, 13, -32768, -32768, -32768, 300, 203, -32768, -32768, -32768,
+	-32768, 88, 112, -32768, -32768, -32768, -32768, -32768, -32768, -32768,
+	-32768, -32768, -32768, -32768, -32768, 111, 109, 297, -32768, -32768,
+	-32768, 291, -32768, -32768, -32768, -32768, -32768, -32768, 331, 212,
+	207, 207, -32768, -32768, -32768, -32768, -32768, 279, 274, 266,
+	-32768, 268, 267, 238, 236, 234, 230, 406, 405, 284,
+	204, -32768, -32768, -32768, -32768, -32768, -32768, -32768, -32768, -32768,
+	171, -32768, -32768, -32768, -32768, -32768, 168, 331, 588, 588,
+	-32768, 588, 588, 588, 108, 107, -32768, 588, -32768, -32768,
+	-32768, -32768, 48, 239, -32768, 220, 404, -32768, 403, 184,
+	402, 401, 400, 21, -32768, -32768, -32768, -32768, -32768, -32768,
+	-32768, -32768, 588, -32768, -32768, 588, -32768, -32768, -32768, -32768,
+	-32768, 398, 182, -32768, 588, 397, -32768,
 }
-var yyPgo = [...]int{
 
-	0, 105, 14, 2, 104, 103, 102, 101, 99, 98,
-	91, 83, 82, 81, 0, 3, 80, 1, 4, 79,
-	78,
+var yyPgo = [...]int16{
+	0, 582, 29, 5, 581, 580, 579, 578, 577, 576,
+	575, 574, 573, 572, 571, 570, 549, 548, 547, 546,
+	545, 544, 543, 542, 541, 540, 539, 538, 537, 536,
+	535, 534, 533, 529, 524, 523, 522, 505, 504, 503,
+	502, 501, 500, 499, 498, 497, 496, 3, 9, 495,
+	0, 494, 6, 4, 93, 493, 156, 492, 14, 65,
+	11, 490, 489, 488, 450, 449, 1, 2,
 }
-var yyR1 = [...]int{
 
-	0, 1, 2, 2, 3, 3, 3, 3, 3, 3,
-	3, 3, 3, 3, 14, 14, 8, 16, 16, 16,
-	15, 15, 15, 15, 15, 15, 15, 15, 15, 15,
-	17, 17, 18, 18, 4, 5, 6, 7, 9, 9,
-	10, 11, 13, 12, 19, 20,
+var yyR1 = [...]int8{
+	0, 1, 1, 2, 2, 3, 3, 3, 3, 3,
+	3, 3, 3, 3, 3, 3, 3, 3, 3, 3,
+	3, 3, 3, 3, 3, 3, 3, 3, 3, 3,
+	3, 3, 3, 3, 3, 3, 3, 3, 3, 3,
+	3, 3, 3, 3, 3, 3, 3, 3, 47, 47,
+	16, 49, 49, 49, 48, 48, 48, 48, 48, 48,
+	48, 48, 48, 48, 48, 50, 50, 50, 50, 50,
+	50, 50, 50, 50, 50, 50, 50, 50, 50, 50,
+	50, 50, 50, 50, 50, 50, 53, 53, 52, 54,
+	54, 54, 54, 54, 54, 54, 54, 54, 54, 54,
+	54, 54, 54, 54, 54, 54, 54, 54, 54, 54,
+	54, 54, 54, 54, 54, 54, 54, 54, 54, 54,
+	54, 54, 54, 54, 54, 54, 54, 54, 54, 54,
+	54, 54, 55, 55, 51, 51, 56, 57, 57, 58,
+	58, 59, 4, 5, 6, 7, 8, 9, 10, 10,
+	60, 60, 11, 11, 11, 11, 12, 12, 13, 13,
+	14, 15, 17, 18, 18, 61, 61, 61, 19, 20,
+	21, 21, 22, 23, 24, 25, 26, 27, 28, 29,
+	30, 31, 32, 62, 62, 33, 34, 35, 36, 37,
+	38, 39, 39, 40, 41, 42, 44, 45, 46, 43,
+	43, 43, 64, 64, 64, 65, 65, 66, 66, 66,
+	66, 66, 66, 63, 63, 67, 67, 67, 67, 67,
+	67, 67, 67, 67, 67,
 }
-var yyR2 = [...]int{
 
-	0, 1, 1, 2, 1, 1, 1, 1, 1, 1,
-	1, 1, 1, 1, 2, 3, 4, 0, 4, 3,
-	3, 3, 2, 1, 1, 2, 3, 3, 3, 3,
-	1, 2, 3, 1, 2, 3, 3, 5, 3, 2,
-	4, 3, 3, 2, 1, 3,
+var yyR2 = [...]int8{
+	0, 0, 1, 1, 2, 1, 1, 1, 1, 1,
+	1, 1, 1, 1, 1, 1, 1, 1, 1, 1,
+	1, 1, 1, 1, 1, 1, 1, 1, 1, 1,
+	1, 1, 1, 1, 1, 1, 1, 1, 1, 1,
+	1, 1, 1, 1, 1, 1, 1, 1, 2, 3,
+	4, 0, 4, 3, 3, 3, 2, 3, 1, 1,
+	2, 3, 3, 3, 3, 1, 1, 2, 2, 8,
+	6, 4, 1, 6, 1, 1, 10, 6, 6, 4,
+	6, 4, 4, 1, 1, 1, 3, 1, 1, 1,
+	1, 1, 1, 1, 1, 1, 1, 1, 1, 1,
+	1, 1, 1, 1, 1, 1, 1, 1, 1, 1,
+	1, 1, 1, 1, 1, 1, 1, 1, 1, 1,
+	1, 1, 1, 1, 1, 1, 1, 1, 1, 1,
+	1, 1, 2, 1, 1, 1, 1, 3, 1, 1,
+	1, 1, 3, 2, 3, 3, 2, 5, 3, 4,
+	1, 2, 4, 3, 4, 4, 3, 3, 4, 4,
+	5, 3, 3, 3, 5, 1, 1, 1, 3, 3,
+	3, 2, 3, 3, 3, 3, 3, 3, 3, 3,
+	3, 3, 5, 1, 1, 4, 4, 3, 3, 3,
+	4, 4, 3, 3, 3, 3, 4, 3, 3, 4,
+	2, 7, 3, 4, 4, 1, 2, 1, 1, 1,
+	1, 1, 1, 1, 2, 1, 1, 1, 1, 1,
+	1, 1, 1, 1, 1,
 }
-var yyChk = [...]int{
-
-	-1000, -1, -2, -3, -4, -5, -6, -7, -8, -9,
-	-10, -11, -12, -13, 29, 30, 34, 31, 9, 14,
-	28, 35, 37, 33, 38, -3, -14, 4, 40, 25,
-	23, -15, 14, 21, 20, 19, -17, 25, 33, 28,
-	7, 36, -18, 23, -19, -20, 39, 27, 5, -2,
-	-14, 7, 32, -14, 12, 13, -15, -17, 15, 16,
-	17, 18, 40, 7, 26, 8, 7, -18, 7, 5,
-	23, -16, -15, -15, -17, -17, -17, -17, 7, 23,
-	7, -14, 10, 11, -15, -14, -14,
+
+var yyChk = [...]int16{
+	-32768, -1, -2, -3, -4, -5, -6, -7, -8, -9,
+	-10, -11, -12, -13, -14, -15, -16, -17, -18, -19,
+	-20, -21, -22, -23, -24, -25, -26, -27, -28, -29,
+	-30, -31, -32, -33, -34, -35, -36, -37, -38, -39,
+	-40, -41, -42, -43, -44, -45, -46, 70, 54, 55,
+	60, 61, 66, 62, 65, 56, 71, 57, 64, 11,
+	88, -61, 89, 90, 16, 53, 91, 92, 93, 94,
+	95, 96, 97, 98, 99, 100, 101, 82, 77, 79,
+	68, 69, 75, 72, 102, 103, 104, 59, -64, 73,
+	84, 87, 24, 25, 26, -3, 46, -47, 4, -54,
+	105, 61, 62, 63, 82, 83, 80, 81, 24, 25,
+	26, 70, 71, 72, 73, 74, 75, 76, 27, 28,
+	29, 30, 31, 32, 33, 34, 35, 36, 37, 38,
+	39, 40, 41, 42, 64, 65, 66, 67, 68, 69,
+	85, 86, 87, 46, -47, -58, 49, 48, -60, 63,
+	44, -58, -59, 50, -54, 46, 46, 44, -59, -48,
+	16, 6, 23, 22, 21, -50, 46, -51, 59, 30,
+	40, 41, 29, 77, 37, 36, 33, 27, 31, 32,
+	38, 39, 34, 42, 28, 35, 43, 48, 47, 43,
+	-55, 80, 105, -56, 52, -56, 53, 9, -56, 105,
+	46, 46, 46, 105, -56, 43, 43, -56, 43, 83,
+	78, -57, 44, -58, -59, 76, 11, -50, 43, 43,
+	43, -52, 86, 105, 9, 85, 105, 74, -56, 105,
+	-47, 5, -2, -47, 9, -58, 9, -60, 44, -58,
+	67, 9, 67, -47, -47, -50, 58, -47, -47, 14,
+	15, -48, -48, -50, 17, 18, 19, 20, -52, -52,
+	6, 6, 6, 6, 6, 6, 6, 6, 6, 6,
+	6, 9, 105, 9, 81, 9, 9, 9, 9, 9,
+	9, 9, 9, 9, 9, 9, 9, 9, 105, 46,
+	47, 10, 9, 9, 9, 43, -48, 9, 9, 9,
+	9, -63, 85, -67, 105, 43, 44, 45, 46, 47,
+	48, 49, 52, 10, 105, 105, 105, -50, 9, 9,
+	5, 51, 9, 9, 9, 9, 9, -47, 44, -49,
+	-48, -48, 7, -50, -50, -50, -50, -50, -50, -53,
+	-50, -50, -50, -50, -50, -53, -50, -50, -50, 46,
+	-62, 48, 105, 9, 9, 44, 9, 9, 9, -67,
+	43, 43, 43, 9, 9, -47, 12, 13, 10, 10,
+	7, 10, 10, 10, 10, 10, 7, 10, 7, 7,
+	9, 9, 17, -48, -47, -50, -50, -50, -50, -50,
+	43, 43, -50, -65, -66, 105, 43, 81, 4, 5,
+	10, -47, 10, 7, 7, 10, 7, 7, 7, 9,
+	-66, -50, -50, 7, 10, -50, 7,
 }
-var yyDef = [...]int{
-
-	0, -2, 1, 2, 4, 5, 6, 7, 8, 9,
-	10, 11, 12, 13, 0, 0, 0, 0, 0, 0,
-	0, 0, 0, 0, 0, 3, 34, 0, 0, 0,
-	0, 0, 0, 23, 24, 0, 0, 30, 0, 0,
-	39, 0, 0, 33, 43, 44, 0, 0, 14, 0,
-	35, 36, 0, 17, 0, 0, 22, 25, 0, 0,
-	0, 0, 31, 38, 0, 0, 41, 0, 42, 15,
-	0, 16, 20, 21, 26, 27, 28, 29, 40, 32,
-	45, 37, 0, 0, 0, 19, 18,
+
+var yyDef = [...]int16{
+	1, -2, 2, 3, 5, 6, 7, 8, 9, 10,
+	11, 12, 13, 14, 15, 16, 17, 18, 19, 20,
+	21, 22, 23, 24, 25, 26, 27, 28, 29, 30,
+	31, 32, 33, 34, 35, 36, 37, 38, 39, 40,
+	41, 42, 43, 44, 45, 46, 47, 0, 0, 0,
+	0, 0, 0, 0, 0, 0, 0, 0, 0, 0,
+	0, 0, 0, 0, 0, 0, 0, 0, 0, 0,
+	0, 0, 0, 0, 0, 0, 0, 0, 0, 0,
+	0, 0, 0, 0, 0, 0, 0, 0, 0, 0,
+	0, 0, 165, 166, 167, 4, 0, 143, 0, 0,
+	89, 90, 91, 92, 93, 94, 95, 96, 97, 98,
+	99, 100, 101, 102, 103, 104, 105, 106, 107, 108,
+	109, 110, 111, 112, 113, 114, 115, 116, 117, 118,
+	119, 120, 121, 122, 123, 124, 125, 126, 127, 128,
+	129, 130, 131, 0, 146, 0, 139, 140, 0, 0,
+	150, 0, 0, 141, 0, 0, 0, 0, 0, 0,
+	0, 0, 58, 59, 0, 0, 65, 66, 0, 0,
+	0, 0, 0, 72, 0, 74, 75, 0, 0, 0,
+	0, 0, 0, 0, 83, 84, 85, 134, 135, 0,
+	0, 0, 133, 0, 136, 0, 0, 171, 0, 0,
+	0, 0, 0, 0, 0, 0, 0, 0, 0, 0,
+	0, 0, 138, 0, 0, 0, 0, 0, 0, 0,
+	0, 0, 0, 88, 200, 0, 0, 0, 0, 0,
+	142, 48, 0, 144, 145, 0, 148, 0, 151, 0,
+	0, 153, 0, 156, 157, 0, 0, 161, 51, 0,
+	0, 56, 0, 60, 0, 0, 0, 0, 67, 68,
+	0, 0, 0, 0, 0, 0, 0, 0, 0, 0,
+	0, 162, 132, 163, 0, 168, 169, 170, 172, 173,
+	174, 175, 176, 177, 178, 179, 180, 181, 0, 0,
+	0, 0, 187, 188, 189, 0, 0, 192, 193, 194,
+	195, 0, 0, 213, 215, 216, 217, 218, 219, 220,
+	221, 222, 223, 224, 202, 0, 0, 0, 197, 198,
+	49, 0, 149, 152, 155, 154, 158, 159, 0, 50,
+	54, 55, 57, 61, 62, 63, 64, 0, 0, 0,
+	87, 0, 0, 0, 0, 0, 0, 0, 0, 0,
+	0, 183, 184, 185, 186, 137, 190, 191, 199, 214,
+	0, 203, 204, 196, 147, 160, 0, 0, 0, 0,
+	71, 0, 0, 0, 0, 0, 79, 0, 81, 82,
+	164, 182, 0, 0, 53, 0, 0, 86, 0, 0,
+	0, 0, 0, 0, 205, 207, 208, 209, 210, 211,
+	212, 52, 0, 70, 73, 0, 77, 78, 80, 201,
+	206, 0, 0, 69, 0, 0, 76,
 }
-var yyTok1 = [...]int{
 
+var yyTok1 = [...]int8{
 	1,
 }
-var yyTok2 = [...]int{
 
+var yyTok2 = [...]int8{
 	2, 3, 4, 5, 6, 7, 8, 9, 10, 11,
 	12, 13, 14, 15, 16, 17, 18, 19, 20, 21,
 	22, 23, 24, 25, 26, 27, 28, 29, 30, 31,
-	32, 33, 34, 35, 36, 37, 38, 39, 40,
+	32, 33, 34, 35, 36, 37, 38, 39, 40, 41,
+	42, 43, 44, 45, 46, 47, 48, 49, 50, 51,
+	52, 53, 54, 55, 56, 57, 58, 59, 60, 61,
+	62, 63, 64, 65, 66, 67, 68, 69, 70, 71,
+	72, 73, 74, 75, 76, 77, 78, 79, 80, 81,
+	82, 83, 84, 85, 86, 87, 88, 89, 90, 91,
+	92, 93, 94, 95, 96, 97, 98, 99, 100, 101,
+	102, 103, 104, 105,
 }
-var yyTok3 = [...]int{
+
+var yyTok3 = [...]int8{
 	0,
 }
 
@@ -245,7 +581,7 @@ func yyNewParser() yyParser {
 	return &yyParserImpl{}
 }
 
-const yyFlag = -1000
+const yyFlag = -32768
 
 func yyTokname(c int) string {
 	if c >= 1 && c-1 < len(yyToknames) {
@@ -284,9 +620,9 @@ func yyErrorMessage(state, lookAhead int) string {
 	expected := make([]int, 0, 4)
 
 	// Look for shiftable tokens.
-	base := yyPact[state]
+	base := int(yyPact[state])
 	for tok := TOKSTART; tok-1 < len(yyToknames); tok++ {
-		if n := base + tok; n >= 0 && n < yyLast && yyChk[yyAct[n]] == tok {
+		if n := base + tok; n >= 0 && n < yyLast && int(yyChk[int(yyAct[n])]) == tok {
 			if len(expected) == cap(expected) {
 				return res
 			}
@@ -296,13 +632,13 @@ func yyErrorMessage(state, lookAhead int) string {
 
 	if yyDef[state] == -2 {
 		i := 0
-		for yyExca[i] != -1 || yyExca[i+1] != state {
+		for yyExca[i] != -1 || int(yyExca[i+1]) != state {
 			i += 2
 		}
 
 		// Look for tokens that we accept or reduce.
 		for i += 2; yyExca[i] >= 0; i += 2 {
-			tok := yyExca[i]
+			tok := int(yyExca[i])
 			if tok < TOKSTART || yyExca[i+1] == 0 {
 				continue
 			}
@@ -333,30 +669,30 @@ func yylex1(lex yyLexer, lval *yySymType) (char, token int) {
 	token = 0
 	char = lex.Lex(lval)
 	if char <= 0 {
-		token = yyTok1[0]
+		token = int(yyTok1[0])
 		goto out
 	}
 	if char < len(yyTok1) {
-		token = yyTok1[char]
+		token = int(yyTok1[char])
 		goto out
 	}
 	if char >= yyPrivate {
 		if char < yyPrivate+len(yyTok2) {
-			token = yyTok2[char-yyPrivate]
+			token = int(yyTok2[char-yyPrivate])
 			goto out
 		}
 	}
 	for i := 0; i < len(yyTok3); i += 2 {
-		token = yyTok3[i+0]
+		token = int(yyTok3[i+0])
 		if token == char {
-			token = yyTok3[i+1]
+			token = int(yyTok3[i+1])
 			goto out
 		}
 	}
 
 out:
 	if token == 0 {
-		token = yyTok2[1] /* unknown char */
+		token = int(yyTok2[1]) /* unknown char */
 	}
 	if yyDebug >= 3 {
 		__yyfmt__.Printf("lex %s(%d)\n", yyTokname(token), uint(char))
@@ -411,7 +747,7 @@ yystack:
 	yyS[yyp].yys = yystate
 
 yynewstate:
-	yyn = yyPact[yystate]
+	yyn = int(yyPact[yystate])
 	if yyn <= yyFlag {
 		goto yydefault /* simple state */
 	}
@@ -422,8 +758,8 @@ yynewstate:
 	if yyn < 0 || yyn >= yyLast {
 		goto yydefault
 	}
-	yyn = yyAct[yyn]
-	if yyChk[yyn] == yytoken { /* valid shift */
+	yyn = int(yyAct[yyn])
+	if int(yyChk[yyn]) == yytoken { /* valid shift */
 		yyrcvr.char = -1
 		yytoken = -1
 		yyVAL = yyrcvr.lval
@@ -436,7 +772,7 @@ yynewstate:
 
 yydefault:
 	/* default state action */
-	yyn = yyDef[yystate]
+	yyn = int(yyDef[yystate])
 	if yyn == -2 {
 		if yyrcvr.char < 0 {
 			yyrcvr.char, yytoken = yylex1(yylex, &yyrcvr.lval)
@@ -445,18 +781,18 @@ yydefault:
 		/* look through exception table */
 		xi := 0
 		for {
-			if yyExca[xi+0] == -1 && yyExca[xi+1] == yystate {
+			if yyExca[xi+0] == -1 && int(yyExca[xi+1]) == yystate {
 				break
 			}
 			xi += 2
 		}
 		for xi += 2; ; xi += 2 {
-			yyn = yyExca[xi+0]
+			yyn = int(yyExca[xi+0])
 			if yyn < 0 || yyn == yytoken {
 				break
 			}
 		}
-		yyn = yyExca[xi+1]
+		yyn = int(yyExca[xi+1])
 		if yyn < 0 {
 			goto ret0
 		}
@@ -478,10 +814,10 @@ yydefault:
 
 			/* find a state where "error" is a legal shift action */
 			for yyp >= 0 {
-				yyn = yyPact[yyS[yyp].yys] + yyErrCode
+				yyn = int(yyPact[yyS[yyp].yys]) + yyErrCode
 				if yyn >= 0 && yyn < yyLast {
-					yystate = yyAct[yyn] /* simulate a shift of "error" */
-					if yyChk[yystate] == yyErrCode {
+					yystate = int(yyAct[yyn]) /* simulate a shift of "error" */
+					if int(yyChk[yystate]) == yyErrCode {
 						goto yystack
 					}
 				}
@@ -517,7 +853,7 @@ yydefault:
 	yypt := yyp
 	_ = yypt // guard against "declared and not used"
 
-	yyp -= yyR2[yyn]
+	yyp -= int(yyR2[yyn])
 	// yyp is now the index of $0. Perform the default action. Iff the
 	// reduced production is ε, $1 is possibly out of range.
 	if yyp+1 >= len(yyS) {
@@ -528,43 +864,58 @@ yydefault:
 	yyVAL = yyS[yyp+1]
 
 	/* consult goto table to find next state */
-	yyn = yyR1[yyn]
-	yyg := yyPgo[yyn]
+	yyn = int(yyR1[yyn])
+	yyg := int(yyPgo[yyn])
 	yyj := yyg + yyS[yyp].yys + 1
 
 	if yyj >= yyLast {
-		yystate = yyAct[yyg]
+		yystate = int(yyAct[yyg])
 	} else {
-		yystate = yyAct[yyj]
-		if yyChk[yystate] != -yyn {
-			yystate = yyAct[yyg]
+		yystate = int(yyAct[yyj])
+		if int(yyChk[yystate]) != -yyn {
+			yystate = int(yyAct[yyg])
 		}
 	}
 	// dummy call; replaced with literal code
 	switch yynt {
 
 	case 1:
-		yyDollar = yyS[yypt-1 : yypt+1]
+		yyDollar = yyS[yypt-0 : yypt+1]
 		{
-			l := yylex.(*lexer)
-			l.dirtyHackReturn = yyDollar[1].statementList
+			if l, ok := yylex.(*lexer); ok {
+				l.dirtyHackReturn = danglingComments(yylex, l.currentStart)
+			}
 		}
 	case 2:
 		yyDollar = yyS[yypt-1 : yypt+1]
 		{
-			yyVAL.statementList = []Statement{yyDollar[1].statement}
+			// yylex may not be a *lexer when expectedTokens() is replaying
+			// tokens after an error
+			if l, ok := yylex.(*lexer); ok {
+				l.dirtyHackReturn = append(yyDollar[1].statementList, danglingComments(yylex, l.currentStart)...)
+			}
 		}
 	case 3:
+		yyDollar = yyS[yypt-1 : yypt+1]
+		{
+			yyVAL.statementList = []Statement{sourceStatement(yylex, yyDollar[1].statement, yyDollar[1].pos)}
+		}
+	case 4:
+		yyDollar = yyS[yypt-2 : yypt+1]
+		{
+			yyVAL.statementList = append(yyVAL.statementList, sourceStatement(yylex, yyDollar[2].statement, yyDollar[2].pos))
+		}
+	case 48:
 		yyDollar = yyS[yypt-2 : yypt+1]
 		{
-			yyVAL.statementList = append(yyVAL.statementList, yyDollar[2].statement)
+			yyVAL.statementList = danglingComments(yylex, yyDollar[2].pos)
 		}
-	case 15:
+	case 49:
 		yyDollar = yyS[yypt-3 : yypt+1]
 		{
-			yyVAL.statementList = yyDollar[2].statementList
+			yyVAL.statementList = append(yyDollar[2].statementList, danglingComments(yylex, yyDollar[3].pos)...)
 		}
-	case 16:
+	case 50:
 		yyDollar = yyS[yypt-4 : yypt+1]
 		{
 			cs := ConditionalStatement{
@@ -572,29 +923,53 @@ yydefault:
 				Condition:       yyDollar[2].boolExpr,
 				Statements:      yyDollar[3].statementList,
 				SubConditionals: yyDollar[4].subConditionals,
+				OpeningComment:  openingComment(yylex, yyDollar[3].pos),
+			}
+			// the comment trailing each block is only found on reaching the
+			// elsif/else following it
+			for i, comment := range yyDollar[4].strList {
+				if i == 0 {
+					cs.TrailingComment = comment
+				} else {
+					cs.SubConditionals[i-1].TrailingComment = comment
+				}
 			}
 			yyVAL.statement = cs
 		}
-	case 18:
+	case 51:
+		yyDollar = yyS[yypt-0 : yypt+1]
+		{
+			yyVAL.subConditionals = nil
+			yyVAL.strList = nil
+		}
+	case 52:
 		yyDollar = yyS[yypt-4 : yypt+1]
 		{
 			cs := ConditionalStatement{
-				Operator:   ConditionElsif,
-				Condition:  yyDollar[3].boolExpr,
-				Statements: yyDollar[3].statementList,
+				Operator:       ConditionElsif,
+				Condition:      yyDollar[3].boolExpr,
+				Statements:     yyDollar[4].statementList,
+				OpeningComment: openingComment(yylex, yyDollar[4].pos),
 			}
+			var trailing string
+			trailing, cs.LeadingComments = commentsBeforeBranch(yylex, yyDollar[2].pos)
 			yyVAL.subConditionals = append(yyVAL.subConditionals, cs)
+			yyVAL.strList = append(yyVAL.strList, trailing)
 		}
-	case 19:
+	case 53:
 		yyDollar = yyS[yypt-3 : yypt+1]
 		{
 			cs := ConditionalStatement{
-				Operator:   ConditionElse,
-				Statements: yyDollar[2].statementList,
+				Operator:       ConditionElse,
+				Statements:     yyDollar[3].statementList,
+				OpeningComment: openingComment(yylex, yyDollar[3].pos),
 			}
+			var trailing string
+			trailing, cs.LeadingComments = commentsBeforeBranch(yylex, yyDollar[2].pos)
 			yyVAL.subConditionals = append(yyVAL.subConditionals, cs)
+			yyVAL.strList = append(yyVAL.strList, trailing)
 		}
-	case 20:
+	case 54:
 		yyDollar = yyS[yypt-3 : yypt+1]
 		{
 			yyVAL.boolExpr = BooleanExpression{
@@ -602,7 +977,7 @@ yydefault:
 				BoolTerms: []BooleanExpression{yyDollar[1].boolExpr, yyDollar[3].boolExpr},
 			}
 		}
-	case 21:
+	case 55:
 		yyDollar = yyS[yypt-3 : yypt+1]
 		{
 			yyVAL.boolExpr = BooleanExpression{
@@ -610,7 +985,7 @@ yydefault:
 				BoolTerms: []BooleanExpression{yyDollar[1].boolExpr, yyDollar[3].boolExpr},
 			}
 		}
-	case 22:
+	case 56:
 		yyDollar = yyS[yypt-2 : yypt+1]
 		{
 			yyVAL.boolExpr = BooleanExpression{
@@ -618,21 +993,26 @@ yydefault:
 				BoolTerms: []BooleanExpression{yyDollar[2].boolExpr},
 			}
 		}
-	case 23:
+	case 57:
+		yyDollar = yyS[yypt-3 : yypt+1]
+		{
+			yyVAL.boolExpr = yyDollar[2].boolExpr
+		}
+	case 58:
 		yyDollar = yyS[yypt-1 : yypt+1]
 		{
 			yyVAL.boolExpr = BooleanExpression{
 				Operator: BoolStatic,
 			}
 		}
-	case 24:
+	case 59:
 		yyDollar = yyS[yypt-1 : yypt+1]
 		{
 			yyVAL.boolExpr = BooleanExpression{
 				Operator: BoolKnown,
 			}
 		}
-	case 25:
+	case 60:
 		yyDollar = yyS[yypt-2 : yypt+1]
 		{
 			yyVAL.boolExpr = BooleanExpression{
@@ -640,7 +1020,7 @@ yydefault:
 				DataTerms: []fmt.Stringer{yyDollar[2].dataTerm},
 			}
 		}
-	case 26:
+	case 61:
 		yyDollar = yyS[yypt-3 : yypt+1]
 		{
 			yyVAL.boolExpr = BooleanExpression{
@@ -648,7 +1028,7 @@ yydefault:
 				DataTerms: []fmt.Stringer{yyDollar[1].dataTerm, yyDollar[3].dataTerm},
 			}
 		}
-	case 27:
+	case 62:
 		yyDollar = yyS[yypt-3 : yypt+1]
 		{
 			yyVAL.boolExpr = BooleanExpression{
@@ -656,7 +1036,7 @@ yydefault:
 				DataTerms: []fmt.Stringer{yyDollar[1].dataTerm, yyDollar[3].dataTerm},
 			}
 		}
-	case 28:
+	case 63:
 		yyDollar = yyS[yypt-3 : yypt+1]
 		{
 			yyVAL.boolExpr = BooleanExpression{
@@ -664,7 +1044,7 @@ yydefault:
 				DataTerms: []fmt.Stringer{yyDollar[1].dataTerm, yyDollar[3].dataTerm},
 			}
 		}
-	case 29:
+	case 64:
 		yyDollar = yyS[yypt-3 : yypt+1]
 		{
 			yyVAL.boolExpr = BooleanExpression{
@@ -672,29 +1052,216 @@ yydefault:
 				DataTerms: []fmt.Stringer{yyDollar[1].dataTerm, yyDollar[3].dataTerm},
 			}
 		}
-	case 30:
+	case 65:
 		yyDollar = yyS[yypt-1 : yypt+1]
 		{
 			yyVAL.dataTerm = StringConstTerm(yyDollar[1].str)
 		}
-	case 31:
+	case 66:
+		yyDollar = yyS[yypt-1 : yypt+1]
+		{
+			yyVAL.dataTerm = HexStringTerm(yyDollar[1].str)
+		}
+	case 67:
 		yyDollar = yyS[yypt-2 : yypt+1]
 		{
 			yyVAL.dataTerm = PacketOptionTerm{
 				optionName: yyDollar[2].str,
 			}
 		}
-	case 32:
+	case 68:
+		yyDollar = yyS[yypt-2 : yypt+1]
+		{
+			yyVAL.dataTerm = ConfigOptionTerm{
+				OptionName: yyDollar[2].str,
+			}
+		}
+	case 69:
+		yyDollar = yyS[yypt-8 : yypt+1]
+		{
+			yyVAL.dataTerm = SubstringTerm{
+				Data:   yyDollar[3].dataTerm,
+				Offset: yyDollar[5].dataTerm,
+				Length: yyDollar[7].dataTerm,
+			}
+		}
+	case 70:
+		yyDollar = yyS[yypt-6 : yypt+1]
+		{
+			yyVAL.dataTerm = SuffixTerm{
+				Data:   yyDollar[3].dataTerm,
+				Length: yyDollar[5].dataTerm,
+			}
+		}
+	case 71:
+		yyDollar = yyS[yypt-4 : yypt+1]
+		{
+			yyVAL.dataTerm = ConcatTerm(yyDollar[3].dataTermList)
+		}
+	case 72:
+		yyDollar = yyS[yypt-1 : yypt+1]
+		{
+			yyVAL.dataTerm = HardwareTerm{}
+		}
+	case 73:
+		yyDollar = yyS[yypt-6 : yypt+1]
+		{
+			yyVAL.dataTerm = PacketTerm{
+				Offset: yyDollar[3].dataTerm,
+				Length: yyDollar[5].dataTerm,
+			}
+		}
+	case 74:
+		yyDollar = yyS[yypt-1 : yypt+1]
+		{
+			yyVAL.dataTerm = LeasedAddressTerm{}
+		}
+	case 75:
+		yyDollar = yyS[yypt-1 : yypt+1]
+		{
+			yyVAL.dataTerm = HostDeclNameTerm{}
+		}
+	case 76:
+		yyDollar = yyS[yypt-10 : yypt+1]
+		{
+			yyVAL.dataTerm = BinaryToASCIITerm{
+				Base:      yyDollar[3].dataTerm,
+				Width:     yyDollar[5].dataTerm,
+				Separator: yyDollar[7].dataTerm,
+				Data:      yyDollar[9].dataTerm,
+			}
+		}
+	case 77:
+		yyDollar = yyS[yypt-6 : yypt+1]
+		{
+			yyVAL.dataTerm = EncodeIntTerm{
+				Value: yyDollar[3].dataTerm,
+				Width: yyDollar[5].num,
+			}
+		}
+	case 78:
+		yyDollar = yyS[yypt-6 : yypt+1]
+		{
+			yyVAL.dataTerm = ExtractIntTerm{
+				Data:  yyDollar[3].dataTerm,
+				Width: yyDollar[5].num,
+			}
+		}
+	case 79:
+		yyDollar = yyS[yypt-4 : yypt+1]
+		{
+			yyVAL.dataTerm = PickFirstValueTerm(yyDollar[3].dataTermList)
+		}
+	case 80:
+		yyDollar = yyS[yypt-6 : yypt+1]
+		{
+			yyVAL.dataTerm = ReverseTerm{
+				Width: yyDollar[3].dataTerm,
+				Data:  yyDollar[5].dataTerm,
+			}
+		}
+	case 81:
+		yyDollar = yyS[yypt-4 : yypt+1]
+		{
+			yyVAL.dataTerm = LcaseTerm{
+				Data: yyDollar[3].dataTerm,
+			}
+		}
+	case 82:
+		yyDollar = yyS[yypt-4 : yypt+1]
+		{
+			yyVAL.dataTerm = UcaseTerm{
+				Data: yyDollar[3].dataTerm,
+			}
+		}
+	case 83:
+		yyDollar = yyS[yypt-1 : yypt+1]
+		{
+			yyVAL.dataTerm = ClientStateTerm{}
+		}
+	case 84:
+		yyDollar = yyS[yypt-1 : yypt+1]
+		{
+			yyVAL.dataTerm = LeaseTimeTerm{}
+		}
+	case 85:
+		yyDollar = yyS[yypt-1 : yypt+1]
+		{
+			yyVAL.dataTerm = NumberTerm(yyDollar[1].num)
+		}
+	case 86:
+		yyDollar = yyS[yypt-3 : yypt+1]
+		{
+			yyVAL.dataTermList = append(yyVAL.dataTermList, yyDollar[3].dataTerm)
+		}
+	case 87:
+		yyDollar = yyS[yypt-1 : yypt+1]
+		{
+			yyVAL.dataTermList = []fmt.Stringer{yyDollar[1].dataTerm}
+		}
+	case 132:
+		yyDollar = yyS[yypt-2 : yypt+1]
+		{
+			yyVAL.strList = append(yyVAL.strList, yyDollar[2].str)
+		}
+	case 133:
+		yyDollar = yyS[yypt-1 : yypt+1]
+		{
+			yyVAL.strList = []string{yyDollar[1].str}
+		}
+	case 136:
+		yyDollar = yyS[yypt-1 : yypt+1]
+		{
+			yyVAL.num = 0
+			cmpText := strings.ToLower(yyDollar[1].str)
+			if cmpText == "on" || cmpText == "true" {
+				yyVAL.num = 1
+			}
+		}
+	case 137:
 		yyDollar = yyS[yypt-3 : yypt+1]
 		{
 			yyVAL.ipList = append(yyVAL.ipList, net.ParseIP(yyDollar[3].str))
 		}
-	case 33:
+	case 138:
 		yyDollar = yyS[yypt-1 : yypt+1]
 		{
 			yyVAL.ipList = []net.IP{net.ParseIP(yyDollar[1].str)}
 		}
-	case 34:
+	case 139:
+		yyDollar = yyS[yypt-1 : yypt+1]
+		{
+			yyVAL.ip = net.ParseIP(yyDollar[1].str)
+		}
+	case 140:
+		yyDollar = yyS[yypt-1 : yypt+1]
+		{
+			yyVAL.ip = net.ParseIP(yyDollar[1].str)
+			if yyVAL.ip == nil {
+				yylex.Error(fmt.Sprintf("invalid IPv6 address %q", yyDollar[1].str))
+			}
+		}
+	case 141:
+		yyDollar = yyS[yypt-1 : yypt+1]
+		{
+			ip, ipNet, err := net.ParseCIDR(yyDollar[1].str)
+			if err != nil {
+				yylex.Error(fmt.Sprintf("invalid IPv6 prefix %q", yyDollar[1].str))
+			} else {
+				ipNet.IP = ip
+				yyVAL.ipNet = ipNet
+			}
+		}
+	case 142:
+		yyDollar = yyS[yypt-3 : yypt+1]
+		{
+			cs := ClassStatement{
+				Name:       yyDollar[2].str,
+				Statements: yyDollar[3].statementList,
+			}
+			yyVAL.statement = cs
+		}
+	case 143:
 		yyDollar = yyS[yypt-2 : yypt+1]
 		{
 			gs := GroupStatement{
@@ -702,7 +1269,7 @@ yydefault:
 			}
 			yyVAL.statement = gs
 		}
-	case 35:
+	case 144:
 		yyDollar = yyS[yypt-3 : yypt+1]
 		{
 			hs := HostStatement{
@@ -711,15 +1278,129 @@ yydefault:
 			}
 			yyVAL.statement = hs
 		}
-	case 36:
+	case 145:
+		yyDollar = yyS[yypt-3 : yypt+1]
+		{
+			yyVAL.statement = includeStatement(yylex, yyDollar[2].str)
+		}
+	case 146:
+		yyDollar = yyS[yypt-2 : yypt+1]
+		{
+			ps := PoolStatement{
+				Statements: yyDollar[2].statementList,
+			}
+			yyVAL.statement = ps
+		}
+	case 147:
+		yyDollar = yyS[yypt-5 : yypt+1]
+		{
+			if yyDollar[4].num > 128 {
+				yylex.Error(fmt.Sprintf("invalid prefix length %q", yyDollar[4].str))
+			}
+			yyVAL.statement = Prefix6Statement{
+				Low:       yyDollar[2].ip,
+				High:      yyDollar[3].ip,
+				PrefixLen: yyDollar[4].num,
+			}
+		}
+	case 148:
+		yyDollar = yyS[yypt-3 : yypt+1]
+		{
+			yyVAL.statement = RangeStatement{
+				Low:  yyDollar[2].ipList[0],
+				High: yyDollar[2].ipList[1],
+			}
+		}
+	case 149:
+		yyDollar = yyS[yypt-4 : yypt+1]
+		{
+			yyVAL.statement = RangeStatement{
+				DynamicBootp: true,
+				Low:          yyDollar[3].ipList[0],
+				High:         yyDollar[3].ipList[1],
+			}
+		}
+	case 150:
+		yyDollar = yyS[yypt-1 : yypt+1]
+		{
+			yyVAL.ipList = []net.IP{net.ParseIP(yyDollar[1].str), nil}
+		}
+	case 151:
+		yyDollar = yyS[yypt-2 : yypt+1]
+		{
+			yyVAL.ipList = []net.IP{net.ParseIP(yyDollar[1].str), net.ParseIP(yyDollar[2].str)}
+		}
+	case 152:
+		yyDollar = yyS[yypt-4 : yypt+1]
+		{
+			yyVAL.statement = Range6Statement{
+				Low:  yyDollar[2].ip,
+				High: yyDollar[3].ip,
+			}
+		}
+	case 153:
 		yyDollar = yyS[yypt-3 : yypt+1]
 		{
-			is := IncludeStatement{
-				Filename: yyDollar[2].str,
+			yyVAL.statement = Range6Statement{
+				Prefix: yyDollar[2].ipNet,
+			}
+		}
+	case 154:
+		yyDollar = yyS[yypt-4 : yypt+1]
+		{
+			yyVAL.statement = Range6Statement{
+				Prefix:    yyDollar[2].ipNet,
+				Temporary: true,
+			}
+		}
+	case 155:
+		yyDollar = yyS[yypt-4 : yypt+1]
+		{
+			yyVAL.statement = Range6Statement{
+				Low:       yyDollar[2].ip,
+				Temporary: true,
+			}
+		}
+	case 156:
+		yyDollar = yyS[yypt-3 : yypt+1]
+		{
+			sns := SharedNetworkStatement{
+				Name:       yyDollar[2].str,
+				Statements: yyDollar[3].statementList,
+			}
+			yyVAL.statement = sns
+		}
+	case 157:
+		yyDollar = yyS[yypt-3 : yypt+1]
+		{
+			sns := SharedNetworkStatement{
+				Name:       yyDollar[2].str,
+				Statements: yyDollar[3].statementList,
+			}
+			yyVAL.statement = sns
+		}
+	case 158:
+		yyDollar = yyS[yypt-4 : yypt+1]
+		{
+			yyVAL.statement = SubclassStatement{
+				ClassName: yyDollar[2].str,
+				Data:      yyDollar[3].dataTerm,
 			}
-			yyVAL.statement = is
 		}
-	case 37:
+	case 159:
+		yyDollar = yyS[yypt-4 : yypt+1]
+		{
+			statements := yyDollar[4].statementList
+			if statements == nil {
+				statements = []Statement{}
+			}
+			yyVAL.statement = SubclassStatement{
+				ClassName:  yyDollar[2].str,
+				Data:       yyDollar[3].dataTerm,
+				Statements: statements,
+			}
+		}
+	case 160:
 		yyDollar = yyS[yypt-5 : yypt+1]
 		{
 			sns := SubnetStatement{
@@ -729,17 +1410,158 @@ yydefault:
 			}
 			yyVAL.statement = sns
 		}
-	case 38:
+	case 161:
+		yyDollar = yyS[yypt-3 : yypt+1]
+		{
+			yyVAL.statement = Subnet6Statement{
+				Prefix:     yyDollar[2].ipNet,
+				Statements: yyDollar[3].statementList,
+			}
+		}
+	case 162:
+		yyDollar = yyS[yypt-3 : yypt+1]
+		{
+			yyVAL.statement = AdaptiveLeaseThresholdStatement(yyDollar[2].num)
+		}
+	case 163:
+		yyDollar = yyS[yypt-3 : yypt+1]
+		{
+			yyVAL.statement = AllowDenyStatement{
+				Operator: yyDollar[1].num,
+				Flag:     strings.Join(yyDollar[2].strList, " "),
+			}
+		}
+	case 164:
+		yyDollar = yyS[yypt-5 : yypt+1]
+		{
+			yyVAL.statement = AllowDenyStatement{
+				Operator:  yyDollar[1].num,
+				Flag:      "members of",
+				ClassName: yyDollar[4].str,
+			}
+		}
+	case 165:
+		yyDollar = yyS[yypt-1 : yypt+1]
+		{
+			yyVAL.num = AccessAllow
+		}
+	case 166:
+		yyDollar = yyS[yypt-1 : yypt+1]
+		{
+			yyVAL.num = AccessDeny
+		}
+	case 167:
+		yyDollar = yyS[yypt-1 : yypt+1]
+		{
+			yyVAL.num = AccessIgnore
+		}
+	case 168:
+		yyDollar = yyS[yypt-3 : yypt+1]
+		{
+			yyVAL.statement = AlwaysBroadcastStatement(yyDollar[2].num == 1)
+		}
+	case 169:
+		yyDollar = yyS[yypt-3 : yypt+1]
+		{
+			yyVAL.statement = AlwaysReplyRFC1048Statement(yyDollar[2].num == 1)
+		}
+	case 170:
 		yyDollar = yyS[yypt-3 : yypt+1]
 		{
 			yyVAL.statement = AuthoritativeStatement(false)
 		}
-	case 39:
+	case 171:
 		yyDollar = yyS[yypt-2 : yypt+1]
 		{
 			yyVAL.statement = AuthoritativeStatement(true)
 		}
-	case 40:
+	case 172:
+		yyDollar = yyS[yypt-3 : yypt+1]
+		{
+			yyVAL.statement = BootUnknownClientsStatement(yyDollar[2].num == 1)
+		}
+	case 173:
+		yyDollar = yyS[yypt-3 : yypt+1]
+		{
+			switch strings.ToLower(yyDollar[2].str) {
+			case "default":
+				yyVAL.statement = DBTimeFormatStatement{LocalTime: false}
+			case "local":
+				yyVAL.statement = DBTimeFormatStatement{LocalTime: true}
+			default:
+				yylex.Error(fmt.Sprintf("unknown db-time-format %q", yyDollar[2].str))
+			}
+		}
+	case 174:
+		yyDollar = yyS[yypt-3 : yypt+1]
+		{
+			yyVAL.statement = DDNSDomainNameStatement(yyDollar[2].str)
+		}
+	case 175:
+		yyDollar = yyS[yypt-3 : yypt+1]
+		{
+			yyVAL.statement = DDNSHostNameStatement(yyDollar[2].str)
+		}
+	case 176:
+		yyDollar = yyS[yypt-3 : yypt+1]
+		{
+			yyVAL.statement = DDNSRevDomainNameStatement(yyDollar[2].str)
+		}
+	case 177:
+		yyDollar = yyS[yypt-3 : yypt+1]
+		{
+			found := false
+			for style, styleString := range ddnsUpdateStyleStrings {
+				if strings.ToLower(yyDollar[2].str) == styleString {
+					yyVAL.statement = DDNSUpdateStyleStatement(style)
+					found = true
+				}
+			}
+			if !found {
+				yylex.Error(fmt.Sprintf("unknown ddns-update-style %q", yyDollar[2].str))
+			}
+		}
+	case 178:
+		yyDollar = yyS[yypt-3 : yypt+1]
+		{
+			yyVAL.statement = DDNSUpdatesStatement(yyDollar[2].num == 1)
+		}
+	case 179:
+		yyDollar = yyS[yypt-3 : yypt+1]
+		{
+			yyVAL.statement = DefaultLeaseTimeStatement(yyDollar[2].num)
+		}
+	case 180:
+		yyDollar = yyS[yypt-3 : yypt+1]
+		{
+			yyVAL.statement = DelayedAckStatement(yyDollar[2].num)
+		}
+	case 181:
+		yyDollar = yyS[yypt-3 : yypt+1]
+		{
+			yyVAL.statement = DoForwardUpdatesStatement(yyDollar[2].num == 1)
+		}
+	case 182:
+		yyDollar = yyS[yypt-5 : yypt+1]
+		{
+			dblcs := DynamicBootpLeaseCutoffStatement{
+				DayOfWeek: yyDollar[2].num,
+			}
+			_, errDate := fmt.Sscanf(yyDollar[3].str, "%d/%d/%d", &dblcs.Year, &dblcs.Month, &dblcs.DayOfMonth)
+			_, errTime := fmt.Sscanf(yyDollar[4].str, "%d:%d:%d", &dblcs.Hours, &dblcs.Minutes, &dblcs.Seconds)
+			if errDate != nil || errTime != nil {
+				yylex.Error(fmt.Sprintf("invalid dynamic-bootp-lease-cutoff date %q", yyDollar[3].str+" "+yyDollar[4].str))
+			}
+			yyVAL.statement = dblcs
+		}
+	case 185:
+		yyDollar = yyS[yypt-4 : yypt+1]
+		{
+			yyVAL.statement = FailoverPeerStatement{
+				Name: yyDollar[3].str,
+			}
+		}
+	case 186:
 		yyDollar = yyS[yypt-4 : yypt+1]
 		{
 			yyVAL.statement = HardwareStatement{
@@ -747,30 +1569,185 @@ yydefault:
 				HardwareAddress: yyDollar[3].str,
 			}
 		}
-	case 41:
+	case 187:
 		yyDollar = yyS[yypt-3 : yypt+1]
 		{
 			yyVAL.statement = FixedAddressStatement(yyDollar[2].ipList)
 		}
-	case 42:
+	case 188:
 		yyDollar = yyS[yypt-3 : yypt+1]
 		{
-			val := false
-			cmpText := strings.ToLower(yyDollar[2].str)
-			if cmpText == "on" || cmpText == "true" {
-				val = true
+			yyVAL.statement = FixedAddress6Statement(yyDollar[2].ip)
+		}
+	case 189:
+		yyDollar = yyS[yypt-3 : yypt+1]
+		{
+			yyVAL.statement = FixedPrefix6Statement{
+				Prefix: yyDollar[2].ipNet,
 			}
-			yyVAL.statement = UseHostDeclNamesStatement(val)
 		}
-	case 43:
+	case 190:
+		yyDollar = yyS[yypt-4 : yypt+1]
+		{
+			yyVAL.statement = LeaseLimitStatement(yyDollar[3].num)
+		}
+	case 191:
+		yyDollar = yyS[yypt-4 : yypt+1]
+		{
+			yyVAL.statement = MatchIfStatement{
+				Condition: yyDollar[3].boolExpr,
+			}
+		}
+	case 192:
+		yyDollar = yyS[yypt-3 : yypt+1]
+		{
+			yyVAL.statement = MatchStatement{
+				Data: yyDollar[2].dataTerm,
+			}
+		}
+	case 193:
+		yyDollar = yyS[yypt-3 : yypt+1]
+		{
+			yyVAL.statement = MaxAckDelayStatement(yyDollar[2].num)
+		}
+	case 194:
+		yyDollar = yyS[yypt-3 : yypt+1]
+		{
+			yyVAL.statement = MaxLeaseTimeStatement(yyDollar[2].num)
+		}
+	case 195:
+		yyDollar = yyS[yypt-3 : yypt+1]
+		{
+			yyVAL.statement = MinLeaseTimeStatement(yyDollar[2].num)
+		}
+	case 196:
+		yyDollar = yyS[yypt-4 : yypt+1]
+		{
+			yyVAL.statement = SpawnWithStatement{
+				Data: yyDollar[3].dataTerm,
+			}
+		}
+	case 197:
+		yyDollar = yyS[yypt-3 : yypt+1]
+		{
+			yyVAL.statement = UseHostDeclNamesStatement(yyDollar[2].num == 1)
+		}
+	case 198:
+		yyDollar = yyS[yypt-3 : yypt+1]
+		{
+			yyVAL.statement = VendorOptionSpaceStatement(yyDollar[2].str)
+		}
+	case 199:
+		yyDollar = yyS[yypt-4 : yypt+1]
+		{
+			yyVAL.statement = optionStatement(yylex, yyDollar[2].str, yyDollar[3].optionTokens)
+		}
+	case 200:
 		yyDollar = yyS[yypt-2 : yypt+1]
 		{
-			yyVAL.statement = yyDollar[2].statement
+			if l, ok := yylex.(*lexer); ok {
+				l.options.defineSpace(yyDollar[1].statement.(OptionSpaceStatement))
+			}
 		}
-	case 45:
+	case 201:
+		yyDollar = yyS[yypt-7 : yypt+1]
+		{
+			yyVAL.statement = optionDefinitionStatement(yylex, yyDollar[2].str, yyDollar[4].num, yyDollar[6].strList)
+		}
+	case 202:
 		yyDollar = yyS[yypt-3 : yypt+1]
 		{
-			yyVAL.statement = DomainNameServersOption(yyDollar[2].ipList)
+			yyVAL.statement = OptionSpaceStatement{Name: yyDollar[3].str}
+		}
+	case 203:
+		yyDollar = yyS[yypt-4 : yypt+1]
+		{
+			yyVAL.statement = optionSpaceParam(yylex, yyDollar[1].statement.(OptionSpaceStatement), yyDollar[2].str, yyDollar[3].str, yyDollar[4].num)
+		}
+	case 204:
+		yyDollar = yyS[yypt-4 : yypt+1]
+		{
+			yyVAL.statement = optionSpaceParam(yylex, yyDollar[1].statement.(OptionSpaceStatement), yyDollar[2].str, yyDollar[3].str, yyDollar[4].num)
+		}
+	case 205:
+		yyDollar = yyS[yypt-1 : yypt+1]
+		{
+			yyVAL.strList = []string{yyDollar[1].str}
+		}
+	case 206:
+		yyDollar = yyS[yypt-2 : yypt+1]
+		{
+			yyVAL.strList = append(yyDollar[1].strList, yyDollar[2].str)
+		}
+	case 210:
+		yyDollar = yyS[yypt-1 : yypt+1]
+		{
+			yyVAL.str = "{"
+		}
+	case 211:
+		yyDollar = yyS[yypt-1 : yypt+1]
+		{
+			yyVAL.str = "}"
+		}
+	case 212:
+		yyDollar = yyS[yypt-1 : yypt+1]
+		{
+			yyVAL.str = ","
+		}
+	case 214:
+		yyDollar = yyS[yypt-2 : yypt+1]
+		{
+			yyVAL.optionTokens = append(yyDollar[1].optionTokens, yyDollar[2].optionTokens...)
+		}
+	case 215:
+		yyDollar = yyS[yypt-1 : yypt+1]
+		{
+			yyVAL.optionTokens = []optionToken{{word, yyDollar[1].str}}
+		}
+	case 216:
+		yyDollar = yyS[yypt-1 : yypt+1]
+		{
+			yyVAL.optionTokens = []optionToken{{number, yyDollar[1].str}}
+		}
+	case 217:
+		yyDollar = yyS[yypt-1 : yypt+1]
+		{
+			yyVAL.optionTokens = []optionToken{{ipAddr, yyDollar[1].str}}
+		}
+	case 218:
+		yyDollar = yyS[yypt-1 : yypt+1]
+		{
+			yyVAL.optionTokens = []optionToken{{cidr, yyDollar[1].str}}
+		}
+	case 219:
+		yyDollar = yyS[yypt-1 : yypt+1]
+		{
+			yyVAL.optionTokens = []optionToken{{stringConst, yyDollar[1].str}}
+		}
+	case 220:
+		yyDollar = yyS[yypt-1 : yypt+1]
+		{
+			yyVAL.optionTokens = []optionToken{{macAddr, yyDollar[1].str}}
+		}
+	case 221:
+		yyDollar = yyS[yypt-1 : yypt+1]
+		{
+			yyVAL.optionTokens = []optionToken{{hexString, yyDollar[1].str}}
+		}
+	case 222:
+		yyDollar = yyS[yypt-1 : yypt+1]
+		{
+			yyVAL.optionTokens = []optionToken{{ip6Addr, yyDollar[1].str}}
+		}
+	case 223:
+		yyDollar = yyS[yypt-1 : yypt+1]
+		{
+			yyVAL.optionTokens = []optionToken{{stateTok, yyDollar[1].str}}
+		}
+	case 224:
+		yyDollar = yyS[yypt-1 : yypt+1]
+		{
+			yyVAL.optionTokens = []optionToken{{comma, yyDollar[1].str}}
 		}
 	}
 	goto yystack /* stack new state and value */